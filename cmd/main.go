@@ -2,51 +2,172 @@ package main
 
 import (
     "context"
+    "flag"
+    "fmt"
     "log"
     "os"
     "os/signal"
+    "strings"
+    "sync"
     "syscall"
-    "time"
 
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/rollup"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
 )
 
+const usage = `Usage:
+  ml-monitoring [serve] [--config FILE] [--print-config] [--skip-migrations]
+  ml-monitoring migrate [--config FILE] up | down [N|all] | goto VERSION | version | force VERSION
+
+Commands:
+  serve     Apply pending migrations and run the HTTP server (the default)
+  migrate   Manage the database schema with the migrations built into the binary
+`
+
 func main() {
-    // 1. Load configuration
-    cfg, err := config.LoadConfig()
+    cmd, args := "serve", os.Args[1:]
+    if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+        cmd, args = args[0], args[1:]
+    }
+    switch cmd {
+    case "serve":
+        serve(args)
+    case "migrate":
+        runMigrate(args)
+    case "help":
+        fmt.Print(usage)
+    default:
+        fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", cmd, usage)
+        os.Exit(2)
+    }
+}
+
+// newFlagSet returns the flags of a command, with the --config flag every
+// command takes.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+    fs := flag.NewFlagSet(name, flag.ExitOnError)
+    fs.Usage = func() {
+        fmt.Fprint(fs.Output(), usage+"\nFlags:\n")
+        fs.PrintDefaults()
+    }
+    configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file; env vars override it")
+    return fs, configFile
+}
+
+func loadConfig(path string) *config.Config {
+    cfg, err := config.LoadConfigFile(path)
     if err != nil {
         log.Fatalf("Error loading config: %v", err)
     }
+    return cfg
+}
 
-    // 2. Run migrations
-    if err := db.RunMigrations(cfg); err != nil {
-        log.Fatalf("Error running migrations: %v", err)
+func serve(args []string) {
+    fs, configFile := newFlagSet("serve")
+    printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets masked and exit")
+    skipMigrations := fs.Bool("skip-migrations", false, "start without applying pending migrations")
+    fs.Parse(args)
+
+    // 1. Load configuration
+    cfg := loadConfig(*configFile)
+    if *printConfig {
+        if err := cfg.Print(os.Stdout); err != nil {
+            log.Fatalf("Error printing config: %v", err)
+        }
+        return
     }
 
-    // 3. Connect to DB
+    // 2. Connect to DB, waiting for it to come up
     database, err := db.ConnectDB(cfg)
     if err != nil {
         log.Fatalf("Error connecting to the database: %v", err)
     }
     defer database.Close()
 
+    // 3. Run migrations
+    if *skipMigrations {
+        log.Println("Skipping migrations")
+    } else if err := db.RunMigrations(cfg); err != nil {
+        log.Fatalf("Error running migrations: %v", err)
+    }
+
     // 4. Create and start HTTP server
-    srv := server.NewServer(database)
-    go srv.Start("8080") // run in goroutine
+    srv := server.NewServer(database, cfg)
+    go srv.Start(cfg.ListenAddr) // run in goroutine
 
-    // 5. Shutdown handling
+    // 5. Background workers
+    workerCtx, stopWorkers := context.WithCancel(context.Background())
+    defer stopWorkers()
+    if cfg.RollupEnabled {
+        worker := rollup.NewWorker(repository.NewRollupRepository(database),
+            cfg.RollupBucket, cfg.RollupInterval, cfg.RollupLag, cfg.RollupBatchSize)
+        go worker.Run(workerCtx)
+    }
+    if srv.Evaluations != nil {
+        go srv.Evaluations.Run(workerCtx)
+    }
+    if srv.Certs != nil {
+        go srv.Certs.Watch(workerCtx, cfg.TLSReloadInterval)
+    }
+    // The profile and schema flushers save once more when stopped, so
+    // shutdown waits for them.
+    var flushers sync.WaitGroup
+    if srv.Quality != nil && srv.Quality.FlushInterval > 0 {
+        flushers.Add(1)
+        go func() {
+            defer flushers.Done()
+            srv.Quality.Run(workerCtx)
+        }()
+    }
+    if srv.Schemas != nil && srv.Schemas.FlushInterval > 0 {
+        flushers.Add(1)
+        go func() {
+            defer flushers.Done()
+            srv.Schemas.Run(workerCtx)
+        }()
+    }
+    // The audit writer outlives the HTTP server, so the entries of requests
+    // finishing during shutdown are still written.
+    auditCtx, stopAudit := context.WithCancel(context.Background())
+    auditDone := make(chan struct{})
+    if srv.AuditWriter != nil {
+        go func() {
+            srv.AuditWriter.Run(auditCtx)
+            close(auditDone)
+        }()
+    } else {
+        close(auditDone)
+    }
+
+    // 6. Shutdown handling
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
     <-quit
     log.Println("Received shutdown signal")
 
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGracePeriod)
     defer cancel()
 
     if err := srv.Shutdown(ctx); err != nil {
         log.Printf("Server Shutdown Failed:%+v", err)
     }
+    // Requests finishing during shutdown may still queue evaluations, so the
+    // runner drains only once the server is down, within the same deadline.
+    if srv.Evaluations != nil {
+        if err := srv.Evaluations.Drain(ctx); err != nil {
+            log.Printf("Evaluation queue not drained, dropping pending evaluations: %v\n", err)
+        }
+    }
+    // Erasure jobs started before the shutdown run on within the deadline.
+    if err := srv.DrainErasures(ctx); err != nil {
+        log.Printf("Erasure jobs not finished, resume them with the same request: %v\n", err)
+    }
+    stopWorkers()
+    flushers.Wait()
+    stopAudit()
+    <-auditDone
     log.Println("Server exited properly")
 }
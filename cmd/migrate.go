@@ -0,0 +1,89 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+)
+
+// runMigrate runs one migrate subcommand against the configured database:
+//   up               apply every pending migration
+//   down [N|all]     roll back the last N migrations (default 1), or all
+//   goto VERSION     migrate up or down to VERSION
+//   version          print the applied version
+//   force VERSION    mark VERSION applied and clean without running it
+func runMigrate(args []string) {
+    fs, configFile := newFlagSet("migrate")
+    fs.Parse(args)
+    args = fs.Args()
+    if len(args) == 0 {
+        fs.Usage()
+        os.Exit(2)
+    }
+    cmd, args := args[0], args[1:]
+    // Validate the arguments before connecting.
+    var steps, version int
+    var err error
+    switch {
+    case (cmd == "up" || cmd == "version") && len(args) == 0:
+    case cmd == "down" && len(args) == 0:
+        steps = 1
+    case cmd == "down" && len(args) == 1 && args[0] == "all":
+        steps = 0
+    case cmd == "down" && len(args) == 1:
+        if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+            log.Fatalf("Invalid number of migrations to roll back: %q", args[0])
+        }
+    case cmd == "goto" && len(args) == 1:
+        if version, err = strconv.Atoi(args[0]); err != nil || version < 1 {
+            log.Fatalf("Invalid version: %q", args[0])
+        }
+    case cmd == "force" && len(args) == 1:
+        if version, err = strconv.Atoi(args[0]); err != nil || version < -1 {
+            log.Fatalf("Invalid version: %q, want a migration version or -1 for none", args[0])
+        }
+    default:
+        fs.Usage()
+        os.Exit(2)
+    }
+
+    cfg := loadConfig(*configFile)
+    m, err := db.NewMigrator(cfg)
+    if err != nil {
+        log.Fatalf("Error connecting to the database: %v", err)
+    }
+    defer m.Close()
+
+    switch cmd {
+    case "up":
+        err = m.Up()
+    case "down":
+        err = m.Down(steps)
+    case "goto":
+        err = m.Goto(uint(version))
+    case "force":
+        err = m.Force(version)
+    }
+    if err != nil {
+        m.Close()
+        log.Fatalf("Error running migrate %s: %v", cmd, err)
+    }
+
+    current, dirty, err := m.Version()
+    if err != nil {
+        m.Close()
+        log.Fatalf("Error reading the migration version: %v", err)
+    }
+    latest, _ := db.LatestMigration()
+    switch {
+    case current == 0:
+        fmt.Printf("No migrations applied (latest %d)\n", latest)
+    case dirty:
+        fmt.Printf("Version %d (dirty; fix it, then run migrate force VERSION) (latest %d)\n", current, latest)
+    default:
+        fmt.Printf("Version %d (latest %d)\n", current, latest)
+    }
+}
@@ -0,0 +1,118 @@
+// Package audit hash-chains audit log entries: each entry stores the hash of
+// its predecessor and a hash over its own fields and that predecessor hash.
+// With a key the hashes are HMACs, so rewriting the chain after tampering
+// also requires the key.
+package audit
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "hash"
+    "strconv"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// GenesisHash is the PrevHash of the first entry.
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Outcomes recorded for a request, derived from its status code.
+const (
+    OutcomeSuccess  = "success"
+    OutcomeDenied   = "denied"
+    OutcomeRejected = "rejected"
+    OutcomeError    = "error"
+)
+
+// Outcome classifies an HTTP status code.
+func Outcome(status int) string {
+    switch {
+    case status < 400:
+        return OutcomeSuccess
+    case status == 401 || status == 403:
+        return OutcomeDenied
+    case status < 500:
+        return OutcomeRejected
+    default:
+        return OutcomeError
+    }
+}
+
+// ChainError reports the first entry whose hash or link does not verify.
+type ChainError struct {
+    Seq    int64
+    Reason string
+}
+
+func (e *ChainError) Error() string {
+    return fmt.Sprintf("audit chain broken at seq %d: %s", e.Seq, e.Reason)
+}
+
+// Chain computes and verifies entry hashes.
+type Chain struct {
+    key []byte
+}
+
+// NewChain returns a chain hashing with HMAC-SHA256 under key, or plain
+// SHA-256 when key is empty.
+func NewChain(key []byte) *Chain {
+    return &Chain{key: key}
+}
+
+// Link makes e the successor of the entry with prevSeq and prevHash (0 and
+// GenesisHash for the first entry) and sets its hash. CreatedAt is truncated
+// to the microsecond precision the database stores.
+func (c *Chain) Link(e *models.AuditEntry, prevSeq int64, prevHash string) {
+    e.Seq = prevSeq + 1
+    e.PrevHash = prevHash
+    e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+    e.Hash = c.Hash(*e)
+}
+
+// Hash returns the hash of e over every field except Hash itself. Fields are
+// length-prefixed so that no two entries encode the same way.
+func (c *Chain) Hash(e models.AuditEntry) string {
+    var h hash.Hash
+    if len(c.key) > 0 {
+        h = hmac.New(sha256.New, c.key)
+    } else {
+        h = sha256.New()
+    }
+    fields := []string{
+        strconv.FormatInt(e.Seq, 10),
+        e.PrevHash,
+        e.Actor,
+        e.RemoteAddr,
+        e.Method,
+        e.Route,
+        e.ResourceID,
+        strconv.Itoa(e.Status),
+        e.Outcome,
+        e.CreatedAt.UTC().Format(time.RFC3339Nano),
+    }
+    for _, f := range fields {
+        fmt.Fprintf(h, "%d:%s\n", len(f), f)
+    }
+    return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks consecutive entries, in sequence order, that follow the
+// entry with prevSeq and prevHash. It returns a *ChainError for the first
+// entry that was modified or does not follow its predecessor.
+func (c *Chain) Verify(entries []models.AuditEntry, prevSeq int64, prevHash string) error {
+    for _, e := range entries {
+        switch {
+        case e.Seq != prevSeq+1:
+            return &ChainError{Seq: e.Seq, Reason: fmt.Sprintf("expected seq %d", prevSeq+1)}
+        case e.PrevHash != prevHash:
+            return &ChainError{Seq: e.Seq, Reason: "previous hash does not match"}
+        case !hmac.Equal([]byte(e.Hash), []byte(c.Hash(e))):
+            return &ChainError{Seq: e.Seq, Reason: "entry hash does not match"}
+        }
+        prevSeq, prevHash = e.Seq, e.Hash
+    }
+    return nil
+}
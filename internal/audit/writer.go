@@ -0,0 +1,143 @@
+package audit
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// Appender stores entries in order, each linked to the one before it.
+type Appender interface {
+    Append(ctx context.Context, entries ...models.AuditEntry) ([]models.AuditEntry, error)
+}
+
+// Writer appends entries in the background. Appends are serialised by a
+// database lock, so entries queued by concurrent requests are written
+// together, up to BatchSize per transaction, instead of one lock per
+// request.
+//
+// A batch that fails to append is retried with exponential backoff, from
+// RetryInitial up to RetryMax, and the entries queued behind it wait. Once
+// Run's ctx is cancelled every remaining batch gets one last attempt; the
+// entries of a batch that still fails are logged and lost.
+type Writer struct {
+    Repo         Appender
+    BatchSize    int
+    RetryInitial time.Duration
+    RetryMax     time.Duration
+
+    queue   chan models.AuditEntry
+    pending sync.WaitGroup
+}
+
+// NewWriter returns a writer with a queue of queueSize entries. Call Run to
+// start writing.
+func NewWriter(repo Appender, batchSize, queueSize int) *Writer {
+    if batchSize <= 0 {
+        batchSize = 1
+    }
+    return &Writer{
+        Repo:         repo,
+        BatchSize:    batchSize,
+        RetryInitial: 100 * time.Millisecond,
+        RetryMax:     10 * time.Second,
+        queue:        make(chan models.AuditEntry, queueSize),
+    }
+}
+
+// Write queues e. While the queue is full, Write blocks, slowing callers to
+// the rate the log is written at.
+func (w *Writer) Write(e models.AuditEntry) {
+    w.pending.Add(1)
+    w.queue <- e
+}
+
+// Run appends queued entries until ctx is cancelled, then appends the
+// entries still queued and returns. Stop the callers of Write first.
+func (w *Writer) Run(ctx context.Context) {
+    log.Printf("Starting audit writer (batches of %d)\n", w.BatchSize)
+    for {
+        select {
+        case <-ctx.Done():
+            w.drain(nil)
+            return
+        case e := <-w.queue:
+            batch := w.collect(e)
+            if !w.append(ctx, batch) {
+                w.drain(batch)
+                return
+            }
+        }
+    }
+}
+
+// Wait blocks until every queued entry has been appended. It only returns
+// while Run is active.
+func (w *Writer) Wait() {
+    w.pending.Wait()
+}
+
+// collect adds the entries already queued after first, up to BatchSize.
+func (w *Writer) collect(first models.AuditEntry) []models.AuditEntry {
+    batch := []models.AuditEntry{first}
+    for len(batch) < w.BatchSize {
+        select {
+        case e := <-w.queue:
+            batch = append(batch, e)
+        default:
+            return batch
+        }
+    }
+    return batch
+}
+
+// append stores a batch, retrying with backoff until it is stored or ctx
+// is cancelled. It reports whether the batch was stored.
+func (w *Writer) append(ctx context.Context, batch []models.AuditEntry) bool {
+    delay := w.RetryInitial
+    for {
+        _, err := w.Repo.Append(ctx, batch...)
+        if err == nil {
+            w.pending.Add(-len(batch))
+            return true
+        }
+        log.Printf("Error appending %d audit entries, retrying in %s: %v\n", len(batch), delay, err)
+        select {
+        case <-ctx.Done():
+            return false
+        case <-time.After(delay):
+        }
+        if delay *= 2; delay > w.RetryMax {
+            delay = w.RetryMax
+        }
+    }
+}
+
+// drain makes a last attempt at held, if any, and at every entry still
+// queued, each batch bounded by RetryMax.
+func (w *Writer) drain(held []models.AuditEntry) {
+    if held != nil {
+        w.appendOnce(held)
+    }
+    for {
+        select {
+        case e := <-w.queue:
+            w.appendOnce(w.collect(e))
+        default:
+            log.Println("Audit writer stopped")
+            return
+        }
+    }
+}
+
+func (w *Writer) appendOnce(batch []models.AuditEntry) {
+    defer w.pending.Add(-len(batch))
+    ctx, cancel := context.WithTimeout(context.Background(), w.RetryMax)
+    defer cancel()
+    if _, err := w.Repo.Append(ctx, batch...); err != nil {
+        log.Printf("Error appending %d audit entries on shutdown, dropping them: %v\n", len(batch), err)
+    }
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+// APIKeyHeader carries API keys.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeys authenticates callers by API key. Only SHA-256 digests of the keys
+// are held.
+type APIKeys struct {
+    byDigest map[[sha256.Size]byte]Identity
+}
+
+// NewAPIKeys returns an empty key set.
+func NewAPIKeys() *APIKeys {
+    return &APIKeys{byDigest: map[[sha256.Size]byte]Identity{}}
+}
+
+// Add registers a key given in clear.
+func (k *APIKeys) Add(key string, id Identity) {
+    k.byDigest[sha256.Sum256([]byte(key))] = id
+}
+
+// AddDigest registers a key by the hex SHA-256 digest of its value.
+func (k *APIKeys) AddDigest(digest string, id Identity) error {
+    b, err := hex.DecodeString(strings.TrimSpace(digest))
+    if err != nil || len(b) != sha256.Size {
+        return fmt.Errorf("key digest of %q is not a hex SHA-256", id.Name)
+    }
+    var d [sha256.Size]byte
+    copy(d[:], b)
+    k.byDigest[d] = id
+    return nil
+}
+
+// Len returns the number of registered keys.
+func (k *APIKeys) Len() int {
+    return len(k.byDigest)
+}
+
+// Authenticate looks up the X-API-Key header.
+func (k *APIKeys) Authenticate(r *http.Request) (*Identity, error) {
+    key := r.Header.Get(APIKeyHeader)
+    if key == "" {
+        return nil, nil
+    }
+    id, ok := k.byDigest[sha256.Sum256([]byte(key))]
+    if !ok {
+        return nil, ErrInvalidCredentials
+    }
+    return &id, nil
+}
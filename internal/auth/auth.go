@@ -0,0 +1,113 @@
+// Package auth identifies API callers and maps their roles to permissions.
+package auth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+// Role is the access level granted to a caller.
+type Role string
+
+const (
+    // RoleViewer reads aggregates only.
+    RoleViewer Role = "viewer"
+    // RoleAnalyst also reads raw payloads.
+    RoleAnalyst Role = "analyst"
+    // RoleWriter ingests inferences, feedback and LLM calls.
+    RoleWriter Role = "writer"
+    // RoleAdmin can do everything, including configuration, key rotation
+    // and deletion.
+    RoleAdmin Role = "admin"
+)
+
+// Permission is what a route requires.
+type Permission int
+
+const (
+    // PermAggregates covers metrics, rollups and inference metadata.
+    PermAggregates Permission = iota
+    // PermPayloads covers raw input_data, feedback and prompts.
+    PermPayloads
+    // PermIngest covers writing inferences, feedback and LLM calls.
+    PermIngest
+    // PermAdmin covers configuration, keys, erasure and the audit log.
+    PermAdmin
+)
+
+var grants = map[Role][]Permission{
+    RoleViewer:  {PermAggregates},
+    RoleAnalyst: {PermAggregates, PermPayloads},
+    RoleWriter:  {PermIngest},
+    RoleAdmin:   {PermAggregates, PermPayloads, PermIngest, PermAdmin},
+}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+    r := Role(strings.ToLower(strings.TrimSpace(s)))
+    if _, ok := grants[r]; !ok {
+        return "", fmt.Errorf("unknown role %q (want viewer, analyst, writer or admin)", s)
+    }
+    return r, nil
+}
+
+// Can reports whether the role grants p.
+func (r Role) Can(p Permission) bool {
+    for _, g := range grants[r] {
+        if g == p {
+            return true
+        }
+    }
+    return false
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+    Name string
+    Role Role
+}
+
+// Can reports whether the caller has p; a nil identity has nothing.
+func (id *Identity) Can(p Permission) bool {
+    return id != nil && id.Role.Can(p)
+}
+
+// ErrInvalidCredentials is returned for credentials that are present but
+// not accepted.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Authenticator identifies the caller of a request. It returns a nil
+// identity and no error when the request carries no credentials it handles.
+type Authenticator interface {
+    Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Authenticators tries each authenticator in turn and returns the first
+// identity, or the first error.
+type Authenticators []Authenticator
+
+func (as Authenticators) Authenticate(r *http.Request) (*Identity, error) {
+    for _, a := range as {
+        id, err := a.Authenticate(r)
+        if err != nil || id != nil {
+            return id, err
+        }
+    }
+    return nil, nil
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying id.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+    return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the caller identity, or nil.
+func FromContext(ctx context.Context) *Identity {
+    id, _ := ctx.Value(identityKey{}).(*Identity)
+    return id
+}
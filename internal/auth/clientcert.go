@@ -0,0 +1,25 @@
+package auth
+
+import "net/http"
+
+// ClientCerts authenticates callers by the common name of a verified TLS
+// client certificate. Roles maps common names to roles; the "*" entry
+// applies to every other verified certificate.
+type ClientCerts struct {
+    Roles map[string]Role
+}
+
+// Authenticate maps the verified client certificate of r, if any.
+func (c *ClientCerts) Authenticate(r *http.Request) (*Identity, error) {
+    if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+        return nil, nil
+    }
+    cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+    role, ok := c.Roles[cn]
+    if !ok {
+        if role, ok = c.Roles["*"]; !ok {
+            return nil, nil
+        }
+    }
+    return &Identity{Name: "cert:" + cn, Role: role}, nil
+}
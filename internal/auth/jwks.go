@@ -0,0 +1,233 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math/big"
+    "net/http"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+// minJWKSRefresh limits refetching a JWKS URL when tokens name unknown keys.
+const minJWKSRefresh = time.Minute
+
+// ErrUnknownKey is returned for tokens signed with a key not in the JWKS.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type jwk struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+    Crv string `json:"crv"`
+    X   string `json:"x"`
+    Y   string `json:"y"`
+}
+
+// ParseJWKS decodes the RSA and EC signing keys of a JSON Web Key Set by key
+// ID. Keys of other types or uses are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+    var set struct {
+        Keys []jwk `json:"keys"`
+    }
+    if err := json.Unmarshal(data, &set); err != nil {
+        return nil, err
+    }
+    keys := map[string]crypto.PublicKey{}
+    for _, k := range set.Keys {
+        if k.Use != "" && k.Use != "sig" {
+            continue
+        }
+        var key crypto.PublicKey
+        var err error
+        switch k.Kty {
+        case "RSA":
+            key, err = rsaKey(k)
+        case "EC":
+            key, err = ecKey(k)
+        default:
+            continue
+        }
+        if err != nil {
+            return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+        }
+        keys[k.Kid] = key
+    }
+    if len(keys) == 0 {
+        return nil, errors.New("no RSA or EC signing keys")
+    }
+    return keys, nil
+}
+
+func b64Int(s string) (*big.Int, error) {
+    b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+    if err != nil || len(b) == 0 {
+        return nil, fmt.Errorf("invalid base64url integer")
+    }
+    return new(big.Int).SetBytes(b), nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+    n, err := b64Int(k.N)
+    if err != nil {
+        return nil, err
+    }
+    e, err := b64Int(k.E)
+    if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+        return nil, fmt.Errorf("invalid RSA exponent")
+    }
+    if n.BitLen() < 2048 {
+        return nil, fmt.Errorf("RSA key is %d bits, want at least 2048", n.BitLen())
+    }
+    return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+    var curve elliptic.Curve
+    switch k.Crv {
+    case "P-256":
+        curve = elliptic.P256()
+    case "P-384":
+        curve = elliptic.P384()
+    default:
+        return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+    }
+    x, err := b64Int(k.X)
+    if err != nil {
+        return nil, err
+    }
+    y, err := b64Int(k.Y)
+    if err != nil {
+        return nil, err
+    }
+    if !curve.IsOnCurve(x, y) {
+        return nil, fmt.Errorf("point is not on %s", k.Crv)
+    }
+    return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// KeySet holds the keys of a JWKS file or URL. URLs are refetched after the
+// refresh interval and, at most once a minute, when a token names an
+// unknown key. One refetch runs at a time and outside the lock, so tokens
+// signed with a known key are not held up by a slow JWKS endpoint.
+type KeySet struct {
+    source     string
+    refresh    time.Duration
+    client     *http.Client
+    mu         sync.Mutex
+    keys       map[string]crypto.PublicKey
+    loadedAt   time.Time
+    refreshing chan struct{}
+    refreshErr error
+}
+
+// NewKeySet loads the JWKS at source, a file path or an http(s) URL.
+func NewKeySet(source string, refresh time.Duration) (*KeySet, error) {
+    ks := &KeySet{source: source, refresh: refresh, client: &http.Client{Timeout: 10 * time.Second}}
+    keys, err := ks.read()
+    if err != nil {
+        return nil, err
+    }
+    ks.keys, ks.loadedAt = keys, time.Now()
+    return ks, nil
+}
+
+func (ks *KeySet) isURL() bool {
+    return strings.HasPrefix(ks.source, "http://") || strings.HasPrefix(ks.source, "https://")
+}
+
+func (ks *KeySet) read() (map[string]crypto.PublicKey, error) {
+    var data []byte
+    var err error
+    if ks.isURL() {
+        data, err = ks.fetch()
+    } else {
+        data, err = os.ReadFile(ks.source)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("loading JWKS: %w", err)
+    }
+    keys, err := ParseJWKS(data)
+    if err != nil {
+        return nil, fmt.Errorf("parsing JWKS: %w", err)
+    }
+    return keys, nil
+}
+
+func (ks *KeySet) fetch() ([]byte, error) {
+    resp, err := ks.client.Get(ks.source)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("GET %s: %s", ks.source, resp.Status)
+    }
+    return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Key returns the key with the given ID. An empty ID matches the only key of
+// a single-key set.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+    ks.mu.Lock()
+    key, known := ks.lookup(kid)
+    age := time.Since(ks.loadedAt)
+    stale := ks.isURL() && ((ks.refresh > 0 && age > ks.refresh) || (!known && age > minJWKSRefresh))
+    if !stale || (known && ks.refreshing != nil) {
+        ks.mu.Unlock()
+        if !known {
+            return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+        }
+        return key, nil
+    }
+
+    done := ks.refreshing
+    if done == nil {
+        done = make(chan struct{})
+        ks.refreshing = done
+        ks.mu.Unlock()
+        keys, err := ks.read()
+        ks.mu.Lock()
+        // Keep serving the old keys if the refresh fails.
+        if err == nil {
+            ks.keys = keys
+        }
+        ks.loadedAt, ks.refreshErr, ks.refreshing = time.Now(), err, nil
+        close(done)
+    } else {
+        ks.mu.Unlock()
+        <-done
+        ks.mu.Lock()
+    }
+    key, ok := ks.lookup(kid)
+    refreshErr := ks.refreshErr
+    ks.mu.Unlock()
+    if !ok {
+        if refreshErr != nil {
+            return nil, refreshErr
+        }
+        return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+    }
+    return key, nil
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+    if kid == "" && len(ks.keys) == 1 {
+        for _, k := range ks.keys {
+            return k, true
+        }
+    }
+    key, ok := ks.keys[kid]
+    return key, ok
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash"
+    "math/big"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+)
+
+// rolePrecedence orders roles when a token maps to several.
+var rolePrecedence = []Role{RoleAdmin, RoleAnalyst, RoleWriter, RoleViewer}
+
+type jwsAlg struct {
+    hash   crypto.Hash
+    newFn  func() hash.Hash
+    ec     bool
+    keyLen int // bytes of r and s for ECDSA
+}
+
+var jwsAlgs = map[string]jwsAlg{
+    "RS256": {hash: crypto.SHA256, newFn: sha256.New},
+    "RS384": {hash: crypto.SHA384, newFn: sha512.New384},
+    "RS512": {hash: crypto.SHA512, newFn: sha512.New},
+    "ES256": {hash: crypto.SHA256, newFn: sha256.New, ec: true, keyLen: 32},
+    "ES384": {hash: crypto.SHA384, newFn: sha512.New384, ec: true, keyLen: 48},
+}
+
+// JWTConfig holds the checks applied to bearer tokens.
+type JWTConfig struct {
+    Issuer   string
+    Audience string
+    // RolesClaim is the dotted path of the claim (a string or list of
+    // strings) mapped to roles through RoleMap, e.g. "realm_access.roles".
+    // Without RoleMap, claim values are role names.
+    RolesClaim string
+    RoleMap    map[string]Role
+    // NameClaim names the claim used as the caller name; "sub" by default.
+    // RolesClaim defaults to "roles".
+    NameClaim  string
+    Leeway     time.Duration
+}
+
+// JWTAuthenticator accepts "Authorization: Bearer" JWTs signed with RS256,
+// RS384, RS512, ES256 or ES384 by a key of the key set.
+type JWTAuthenticator struct {
+    Keys   *KeySet
+    Config JWTConfig
+    // Now returns the current time; tests override it.
+    Now    func() time.Time
+}
+
+// NewJWTAuthenticator returns an authenticator checking tokens against keys.
+func NewJWTAuthenticator(keys *KeySet, cfg JWTConfig) *JWTAuthenticator {
+    if cfg.NameClaim == "" {
+        cfg.NameClaim = "sub"
+    }
+    if cfg.RolesClaim == "" {
+        cfg.RolesClaim = "roles"
+    }
+    return &JWTAuthenticator{Keys: keys, Config: cfg, Now: time.Now}
+}
+
+// Authenticate validates the bearer token of r, if any.
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+    scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+    if !ok || !strings.EqualFold(scheme, "Bearer") {
+        return nil, nil
+    }
+    id, err := a.Verify(strings.TrimSpace(token))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+    }
+    return id, nil
+}
+
+// Verify checks the signature and claims of a compact JWT and maps it to an
+// identity.
+func (a *JWTAuthenticator) Verify(token string) (*Identity, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return nil, errors.New("malformed token")
+    }
+    var header struct {
+        Alg string `json:"alg"`
+        Kid string `json:"kid"`
+    }
+    if err := decodeSegment(parts[0], &header); err != nil {
+        return nil, fmt.Errorf("header: %w", err)
+    }
+    alg, ok := jwsAlgs[header.Alg]
+    if !ok {
+        return nil, fmt.Errorf("unsupported alg %q", header.Alg)
+    }
+    key, err := a.Keys.Key(header.Kid)
+    if err != nil {
+        return nil, err
+    }
+    sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil {
+        return nil, errors.New("malformed signature")
+    }
+    h := alg.newFn()
+    h.Write([]byte(parts[0] + "." + parts[1]))
+    if err := verifySignature(alg, key, h.Sum(nil), sig); err != nil {
+        return nil, err
+    }
+
+    var claims map[string]interface{}
+    if err := decodeSegment(parts[1], &claims); err != nil {
+        return nil, fmt.Errorf("claims: %w", err)
+    }
+    if err := a.checkClaims(claims); err != nil {
+        return nil, err
+    }
+    name, _ := claims[a.Config.NameClaim].(string)
+    if name == "" {
+        return nil, fmt.Errorf("missing %s claim", a.Config.NameClaim)
+    }
+    role, ok := a.role(claims)
+    if !ok {
+        return nil, errors.New("no role granted")
+    }
+    return &Identity{Name: name, Role: role}, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+    b, err := base64.RawURLEncoding.DecodeString(seg)
+    if err != nil {
+        return err
+    }
+    dec := json.NewDecoder(strings.NewReader(string(b)))
+    dec.UseNumber()
+    return dec.Decode(v)
+}
+
+func verifySignature(alg jwsAlg, key crypto.PublicKey, digest, sig []byte) error {
+    if alg.ec {
+        k, ok := key.(*ecdsa.PublicKey)
+        if !ok || (k.Curve.Params().BitSize+7)/8 != alg.keyLen {
+            return errors.New("key does not match alg")
+        }
+        if len(sig) != 2*alg.keyLen {
+            return errors.New("invalid signature")
+        }
+        r := new(big.Int).SetBytes(sig[:alg.keyLen])
+        s := new(big.Int).SetBytes(sig[alg.keyLen:])
+        if !ecdsa.Verify(k, digest, r, s) {
+            return errors.New("invalid signature")
+        }
+        return nil
+    }
+    k, ok := key.(*rsa.PublicKey)
+    if !ok {
+        return errors.New("key does not match alg")
+    }
+    if err := rsa.VerifyPKCS1v15(k, alg.hash, digest, sig); err != nil {
+        return errors.New("invalid signature")
+    }
+    return nil
+}
+
+func (a *JWTAuthenticator) checkClaims(claims map[string]interface{}) error {
+    now := a.Now()
+    exp, ok := numericDate(claims["exp"])
+    if !ok {
+        return errors.New("missing exp claim")
+    }
+    if now.After(exp.Add(a.Config.Leeway)) {
+        return errors.New("token expired")
+    }
+    if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.Config.Leeway).Before(nbf) {
+        return errors.New("token not yet valid")
+    }
+    if a.Config.Issuer != "" {
+        if iss, _ := claims["iss"].(string); iss != a.Config.Issuer {
+            return fmt.Errorf("unexpected issuer %q", iss)
+        }
+    }
+    if a.Config.Audience != "" && !contains(stringList(claims["aud"]), a.Config.Audience) {
+        return errors.New("token is not for this audience")
+    }
+    return nil
+}
+
+// role maps the roles claim to the most privileged granted role.
+func (a *JWTAuthenticator) role(claims map[string]interface{}) (Role, bool) {
+    granted := map[Role]bool{}
+    value, _ := jsonpath.Lookup(claims, a.Config.RolesClaim)
+    for _, v := range stringList(value) {
+        if len(a.Config.RoleMap) > 0 {
+            if r, ok := a.Config.RoleMap[v]; ok {
+                granted[r] = true
+            }
+            continue
+        }
+        if r, err := ParseRole(v); err == nil {
+            granted[r] = true
+        }
+    }
+    for _, r := range rolePrecedence {
+        if granted[r] {
+            return r, true
+        }
+    }
+    return "", false
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+    n, ok := v.(json.Number)
+    if !ok {
+        return time.Time{}, false
+    }
+    f, err := n.Float64()
+    if err != nil {
+        return time.Time{}, false
+    }
+    return time.Unix(int64(f), 0), true
+}
+
+// stringList reads a claim that is a string or a list of strings.
+func stringList(v interface{}) []string {
+    switch t := v.(type) {
+    case string:
+        return []string{t}
+    case []interface{}:
+        var out []string
+        for _, item := range t {
+            if s, ok := item.(string); ok {
+                out = append(out, s)
+            }
+        }
+        return out
+    }
+    return nil
+}
+
+func contains(list []string, s string) bool {
+    for _, v := range list {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,149 @@
+// Package certs serves TLS certificates and client CAs from files and
+// reloads them when the files change.
+package certs
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "sync"
+    "time"
+)
+
+// Reloader holds the server certificate and, for mutual TLS, the client CA
+// pool loaded from files. Watch reloads them when a file changes; handshakes
+// always use the latest good copy.
+type Reloader struct {
+    CertFile     string
+    KeyFile      string
+    ClientCAFile string
+
+    mu       sync.RWMutex
+    cert     *tls.Certificate
+    clientCA *x509.CertPool
+    modTimes map[string]time.Time
+}
+
+// NewReloader loads the certificate, key and optional client CA bundle.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+    r := &Reloader{CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCAFile}
+    if err := r.Reload(); err != nil {
+        return nil, err
+    }
+    return r, nil
+}
+
+// Reload reads the files again. On error the previous certificates stay in
+// use.
+func (r *Reloader) Reload() error {
+    modTimes, err := r.stat()
+    if err != nil {
+        return err
+    }
+    cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+    if err != nil {
+        return fmt.Errorf("loading certificate: %w", err)
+    }
+    var pool *x509.CertPool
+    if r.ClientCAFile != "" {
+        pem, err := os.ReadFile(r.ClientCAFile)
+        if err != nil {
+            return fmt.Errorf("loading client CA: %w", err)
+        }
+        pool = x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) {
+            return errors.New("loading client CA: no certificates found")
+        }
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.cert, r.clientCA, r.modTimes = &cert, pool, modTimes
+    return nil
+}
+
+func (r *Reloader) files() []string {
+    files := []string{r.CertFile, r.KeyFile}
+    if r.ClientCAFile != "" {
+        files = append(files, r.ClientCAFile)
+    }
+    return files
+}
+
+func (r *Reloader) stat() (map[string]time.Time, error) {
+    out := map[string]time.Time{}
+    for _, f := range r.files() {
+        info, err := os.Stat(f)
+        if err != nil {
+            return nil, err
+        }
+        out[f] = info.ModTime()
+    }
+    return out, nil
+}
+
+// Changed reports whether any file was modified since the last load.
+func (r *Reloader) Changed() bool {
+    current, err := r.stat()
+    if err != nil {
+        // Files being replaced may briefly be missing; check again later.
+        return false
+    }
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    for f, t := range current {
+        if !t.Equal(r.modTimes[f]) {
+            return true
+        }
+    }
+    return false
+}
+
+// Watch polls the files every interval and reloads them when they change,
+// until ctx is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if !r.Changed() {
+                continue
+            }
+            if err := r.Reload(); err != nil {
+                log.Printf("Error reloading TLS certificates: %v\n", err)
+                continue
+            }
+            log.Println("Reloaded TLS certificates")
+        }
+    }
+}
+
+// TLSConfig returns a server configuration that picks up reloaded files on
+// every handshake. With a client CA, client certificates are verified
+// against it; clientAuth says whether they are required.
+func (r *Reloader) TLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+    base := &tls.Config{MinVersion: tls.VersionTLS12}
+    base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+        r.mu.RLock()
+        defer r.mu.RUnlock()
+        cfg := &tls.Config{
+            MinVersion:   tls.VersionTLS12,
+            Certificates: []tls.Certificate{*r.cert},
+            // The server only adds these to the base config.
+            NextProtos: []string{"h2", "http/1.1"},
+        }
+        if r.clientCA != nil {
+            cfg.ClientCAs = r.clientCA
+            cfg.ClientAuth = clientAuth
+        }
+        return cfg, nil
+    }
+    return base
+}
@@ -1,9 +1,22 @@
 package config
 
 import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
     "fmt"
+    "io"
+    "net"
+    "net/url"
     "os"
+    "regexp"
+    "sort"
     "strconv"
+    "strings"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/pii"
 )
 
 type Config struct {
@@ -13,27 +26,793 @@ type Config struct {
     DBPassword string
     DBName     string
     SSLMode    string
+
+    // DBDSN is a libpq keyword/value string or postgres:// URL. When set,
+    // it replaces the DB_* connection settings above.
+    DBDSN string
+
+    // Connection pool limits. DBMaxOpenConns of 0 means unlimited.
+    DBMaxOpenConns    int
+    DBMaxIdleConns    int
+    DBConnMaxLifetime time.Duration
+
+    // DBStartupTimeout is how long startup keeps retrying the database.
+    DBStartupTimeout time.Duration
+
+    // HTTP server. A timeout of 0 disables it.
+    ListenAddr          string
+    ReadTimeout         time.Duration
+    ReadHeaderTimeout   time.Duration
+    WriteTimeout        time.Duration
+    IdleTimeout         time.Duration
+    ShutdownGracePeriod time.Duration
+
+    // ReadyTimeout bounds the dependency checks of /readyz.
+    ReadyTimeout time.Duration
+
+    // FairnessAttributes maps a model name to the input_data path of its
+    // protected attribute. The "*" entry applies to every other model.
+    FairnessAttributes map[string]string
+
+    // CalibrationECEThreshold flags calibration reports whose Expected
+    // Calibration Error exceeds it. Zero disables the alert.
+    CalibrationECEThreshold float64
+
+    // MetricsWindowLimit is the most inferences a metric endpoint reads
+    // from its window; beyond it the newest are used.
+    MetricsWindowLimit int
+
+    // Rollup worker settings
+    RollupEnabled   bool
+    RollupBucket    time.Duration
+    RollupInterval  time.Duration
+    RollupLag       time.Duration
+    RollupBatchSize int
+
+    // Data quality monitoring of input_data
+    DataQualityEnabled         bool
+    DataQualityLearningSamples int64
+    // DataQualityFlushInterval is how often changed profiles are saved; 0
+    // saves them on every inference.
+    DataQualityFlushInterval time.Duration
+
+    // Schema inference of input_data/output_data
+    SchemaTrackingEnabled bool
+    SchemaRemovalWindow   int64
+    // SchemaFlushInterval is how often changed schemas are saved; 0 saves
+    // them on every inference.
+    SchemaFlushInterval time.Duration
+
+    // LLMPrices maps an LLM model name to its token prices. A key ending in
+    // "*" matches every model with that prefix.
+    LLMPrices map[string]LLMPrice
+
+    // Heuristic evaluators of LLM outputs
+    EvaluatorsEnabled       bool
+    EvaluatorWorkers        int
+    EvaluatorQueueSize      int
+    EvaluatorTextField      string
+    EvaluatorReferenceField string
+    EvaluatorBlocklist      []string
+
+    // PII redaction of input_data/output_data. PIIActions maps a model name
+    // to mask, hash, drop or reject; the "*" entry applies to every other
+    // model.
+    PIIEnabled        bool
+    PIIScanNumbers    bool
+    PIIActions        map[string]string
+    PIIHMACKey        string
+    PIICustomPatterns map[string]string
+
+    // Envelope encryption of stored payloads. Encryption is enabled when
+    // EncryptionKeys is non-empty; EncryptionSafeFields ("input_data.age",
+    // ...) stay readable in clear for aggregations.
+    EncryptionPrimaryKey string
+    EncryptionKeys       map[string][]byte
+    EncryptionSafeFields []string
+
+    // Hash-chained audit log of mutating calls and raw payload reads. With
+    // AuditHMACKey set, entry hashes are HMACs under that key. Entries are
+    // queued and appended up to AuditBatchSize per transaction.
+    AuditEnabled   bool
+    AuditHMACKey   string
+    AuditBatchSize int
+    AuditQueueSize int
+
+    // API keys and their roles. Access control is enforced when at least
+    // one key is configured.
+    APIKeys []APIKey
+
+    // Bearer JWT authentication, enabled when JWTJWKS (a file path or URL)
+    // is set. JWTRoleMap maps values of the roles claim to roles.
+    JWTJWKS             string
+    JWTIssuer           string
+    JWTAudience         string
+    JWTRolesClaim       string
+    JWTRoleMap          map[string]string
+    JWTNameClaim        string
+    JWTLeeway           time.Duration
+    JWKSRefreshInterval time.Duration
+
+    // Token-bucket rate limits per caller (API key or JWT identity, else
+    // client IP) for ingest and query routes; a rate of 0 disables the
+    // limit. MaxBodyBytes caps ingest request bodies.
+    RateLimitIngestRPS         float64
+    RateLimitIngestBurst       int
+    RateLimitQueryRPS          float64
+    RateLimitQueryBurst        int
+    RateLimitTrustForwardedFor bool
+    MaxBodyBytes               int64
+
+    // TLS termination. With TLSClientCAFile, client certificates are
+    // verified (required unless TLSClientAuth is "optional") and their
+    // common names mapped to roles by TLSClientRoles, with a "*" fallback.
+    TLSCertFile       string
+    TLSKeyFile        string
+    TLSClientCAFile   string
+    TLSClientAuth     string
+    TLSClientRoles    map[string]string
+    TLSReloadInterval time.Duration
+
+    // Settings lists every resolved setting with its origin, sorted by
+    // name, for Print.
+    Settings []Setting
+}
+
+// Setting is the effective value of one env var and where it came from:
+// "env", "default" or the config file and line.
+type Setting struct {
+    Name   string
+    Value  string
+    Origin string
 }
 
+// source resolves settings from the environment, then the config file,
+// then the defaults.
+type source struct {
+    path     string
+    file     map[string]fileSetting
+    resolved map[string]Setting
+}
+
+// secretSettings are masked by Print. Settings that may be a file path
+// are only masked when given inline.
+var secretSettings = map[string]bool{
+    "DB_PASSWORD":     true,
+    "PII_HMAC_KEY":    true,
+    "AUDIT_HMAC_KEY":  true,
+    "ENCRYPTION_KEYS": false,
+    "API_KEYS":        false,
+}
+
+// APIKey is one entry of API_KEYS. The key is given either in clear or as
+// the hex SHA-256 digest of its value.
+type APIKey struct {
+    Name      string `json:"name"`
+    Role      string `json:"role"`
+    Key       string `json:"key"`
+    KeySHA256 string `json:"key_sha256"`
+}
+
+// KeyFile is the JSON layout of ENCRYPTION_KEYS: base64-encoded 32-byte
+// master keys by ID, and the ID used for new data.
+type KeyFile struct {
+    Primary string            `json:"primary"`
+    Keys    map[string]string `json:"keys"`
+}
+
+// LLMPrice is the USD price of one million tokens.
+type LLMPrice struct {
+    PromptPerMillion     float64 `json:"prompt_per_million"`
+    CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// Cost returns the USD cost of a call with the given token counts.
+func (p LLMPrice) Cost(promptTokens, completionTokens int) float64 {
+    return (float64(promptTokens)*p.PromptPerMillion + float64(completionTokens)*p.CompletionPerMillion) / 1e6
+}
+
+// LoadConfig reads the settings from the environment and, when CONFIG_FILE
+// is set, from that YAML or TOML file. Environment variables take
+// precedence over the file.
 func LoadConfig() (*Config, error) {
-    port, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
+    return LoadConfigFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadConfigFile is LoadConfig with an explicit config file; an empty path
+// reads the environment only. Keys in the file that match no setting are
+// an error.
+func LoadConfigFile(path string) (*Config, error) {
+    src := &source{path: path, resolved: map[string]Setting{}}
+    if path != "" {
+        file, err := readConfigFile(path)
+        if err != nil {
+            return nil, err
+        }
+        src.file = file
+    }
+    cfg, err := src.load()
+    if err != nil {
+        return nil, src.explain(err)
+    }
+    for name, f := range src.file {
+        if _, ok := src.resolved[name]; !ok {
+            msg := fmt.Sprintf("config file %s: line %d: unknown setting %q", path, f.Line, f.Key)
+            if guess := src.closest(name); guess != "" {
+                msg += fmt.Sprintf(" (did you mean %q?)", fileKey(guess))
+            }
+            return nil, errors.New(msg)
+        }
+    }
+    for _, st := range src.resolved {
+        cfg.Settings = append(cfg.Settings, st)
+    }
+    sort.Slice(cfg.Settings, func(i, j int) bool { return cfg.Settings[i].Name < cfg.Settings[j].Name })
+    return cfg, nil
+}
+
+// explain points a validation error at the config file line of the
+// setting it names, if the value came from the file.
+func (src *source) explain(err error) error {
+    for name, f := range src.file {
+        if st, ok := src.resolved[name]; ok && st.Origin != "env" &&
+            regexp.MustCompile(`\b`+name+`\b`).MatchString(err.Error()) {
+            return fmt.Errorf("%w (set as %s in %s line %d)", err, f.Key, src.path, f.Line)
+        }
+    }
+    return err
+}
+
+// closest returns the known setting nearest to an unknown name, if any is
+// within a few edits.
+func (src *source) closest(name string) string {
+    best, bestDist := "", 4
+    for known := range src.resolved {
+        if d := editDistance(name, known); d < bestDist {
+            best, bestDist = known, d
+        }
+    }
+    return best
+}
+
+func editDistance(a, b string) int {
+    prev := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+    for i := 1; i <= len(a); i++ {
+        cur := make([]int, len(b)+1)
+        cur[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+        }
+        prev = cur
+    }
+    return prev[len(b)]
+}
+
+// Print writes the effective settings as a flat YAML config file, with the
+// origin of each value as a comment and secrets masked.
+func (c *Config) Print(w io.Writer) error {
+    for _, st := range c.Settings {
+        value := st.Value
+        if always, ok := secretSettings[st.Name]; ok && value != "" &&
+            (always || strings.HasPrefix(strings.TrimSpace(value), "{") || strings.HasPrefix(strings.TrimSpace(value), "[")) {
+            value = "********"
+        }
+        if st.Name == "DB_DSN" {
+            value = maskDSN(value)
+        }
+        if _, err := fmt.Fprintf(w, "%s: %s # %s\n", fileKey(st.Name), strconv.Quote(value), st.Origin); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'])*'|\S+)`)
+
+// maskDSN hides the password of a keyword/value DSN or postgres:// URL.
+func maskDSN(dsn string) string {
+    if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+        q := u.Query()
+        if q.Has("password") {
+            q.Set("password", "xxxxx")
+            u.RawQuery = q.Encode()
+        }
+        return u.Redacted()
+    }
+    return dsnPassword.ReplaceAllString(dsn, "${1}********")
+}
+
+// load reads every setting through src and validates it.
+func (src *source) load() (*Config, error) {
+    port, err := strconv.Atoi(src.get("DB_PORT", "5432"))
     if err != nil {
         return nil, fmt.Errorf("invalid DB_PORT: %w", err)
     }
+    maxOpen, err := strconv.Atoi(src.get("DB_MAX_OPEN_CONNS", "20"))
+    if err != nil || maxOpen < 0 {
+        return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: %q", src.get("DB_MAX_OPEN_CONNS", "20"))
+    }
+    maxIdle, err := strconv.Atoi(src.get("DB_MAX_IDLE_CONNS", "5"))
+    if err != nil || maxIdle < 0 {
+        return nil, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: %q", src.get("DB_MAX_IDLE_CONNS", "5"))
+    }
+    if maxOpen > 0 && maxIdle > maxOpen {
+        return nil, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", maxIdle, maxOpen)
+    }
+    connLifetime, err := time.ParseDuration(src.get("DB_CONN_MAX_LIFETIME", "30m"))
+    if err != nil || connLifetime < 0 {
+        return nil, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: %q", src.get("DB_CONN_MAX_LIFETIME", "30m"))
+    }
+    startupTimeout, err := time.ParseDuration(src.get("DB_STARTUP_TIMEOUT", "60s"))
+    if err != nil || startupTimeout < 0 {
+        return nil, fmt.Errorf("invalid DB_STARTUP_TIMEOUT: %q", src.get("DB_STARTUP_TIMEOUT", "60s"))
+    }
+
+    listenAddr := src.get("LISTEN_ADDR", ":8080")
+    if _, _, err := net.SplitHostPort(listenAddr); err != nil {
+        return nil, fmt.Errorf("invalid LISTEN_ADDR: %q, want host:port or :port", listenAddr)
+    }
+    timeouts := map[string]time.Duration{}
+    for _, t := range []struct{ name, def string }{
+        {"HTTP_READ_TIMEOUT", "30s"},
+        {"HTTP_READ_HEADER_TIMEOUT", "10s"},
+        {"HTTP_WRITE_TIMEOUT", "60s"},
+        {"HTTP_IDLE_TIMEOUT", "120s"},
+        {"SHUTDOWN_GRACE_PERIOD", "5s"},
+        {"READYZ_TIMEOUT", "2s"},
+    } {
+        d, err := time.ParseDuration(src.get(t.name, t.def))
+        if err != nil || d < 0 {
+            return nil, fmt.Errorf("invalid %s: %q, want a duration such as %q", t.name, src.get(t.name, t.def), t.def)
+        }
+        timeouts[t.name] = d
+    }
+
+    fairness, err := parseKeyValueList(src.get("FAIRNESS_PROTECTED_ATTRIBUTES", ""))
+    if err != nil {
+        return nil, fmt.Errorf("invalid FAIRNESS_PROTECTED_ATTRIBUTES: %w", err)
+    }
+
+    eceThreshold, err := strconv.ParseFloat(src.get("CALIBRATION_ECE_THRESHOLD", "0.05"), 64)
+    if err != nil {
+        return nil, fmt.Errorf("invalid CALIBRATION_ECE_THRESHOLD: %w", err)
+    }
+    windowLimit, err := strconv.Atoi(src.get("METRICS_WINDOW_LIMIT", "100000"))
+    if err != nil || windowLimit <= 0 {
+        return nil, fmt.Errorf("invalid METRICS_WINDOW_LIMIT: %q", src.get("METRICS_WINDOW_LIMIT", "100000"))
+    }
+
+    rollupEnabled, err := strconv.ParseBool(src.get("ROLLUP_ENABLED", "true"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid ROLLUP_ENABLED: %w", err)
+    }
+    rollupBucket, err := time.ParseDuration(src.get("ROLLUP_BUCKET", "1h"))
+    if err != nil || rollupBucket <= 0 {
+        return nil, fmt.Errorf("invalid ROLLUP_BUCKET: %q", src.get("ROLLUP_BUCKET", "1h"))
+    }
+    rollupInterval, err := time.ParseDuration(src.get("ROLLUP_INTERVAL", "1m"))
+    if err != nil || rollupInterval <= 0 {
+        return nil, fmt.Errorf("invalid ROLLUP_INTERVAL: %q", src.get("ROLLUP_INTERVAL", "1m"))
+    }
+    rollupLag, err := time.ParseDuration(src.get("ROLLUP_LAG", "30s"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid ROLLUP_LAG: %w", err)
+    }
+    rollupBatch, err := strconv.Atoi(src.get("ROLLUP_BATCH_SIZE", "5000"))
+    if err != nil || rollupBatch <= 0 {
+        return nil, fmt.Errorf("invalid ROLLUP_BATCH_SIZE: %q", src.get("ROLLUP_BATCH_SIZE", "5000"))
+    }
+
+    dqEnabled, err := strconv.ParseBool(src.get("DATA_QUALITY_ENABLED", "true"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid DATA_QUALITY_ENABLED: %w", err)
+    }
+    dqLearning, err := strconv.ParseInt(src.get("DATA_QUALITY_LEARNING_SAMPLES", "1000"), 10, 64)
+    if err != nil || dqLearning <= 0 {
+        return nil, fmt.Errorf("invalid DATA_QUALITY_LEARNING_SAMPLES: %q", src.get("DATA_QUALITY_LEARNING_SAMPLES", "1000"))
+    }
+    dqFlush, err := time.ParseDuration(src.get("DATA_QUALITY_FLUSH_INTERVAL", "10s"))
+    if err != nil || dqFlush < 0 {
+        return nil, fmt.Errorf("invalid DATA_QUALITY_FLUSH_INTERVAL: %q", src.get("DATA_QUALITY_FLUSH_INTERVAL", "10s"))
+    }
+
+    schemaEnabled, err := strconv.ParseBool(src.get("SCHEMA_TRACKING_ENABLED", "true"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid SCHEMA_TRACKING_ENABLED: %w", err)
+    }
+    schemaRemoval, err := strconv.ParseInt(src.get("SCHEMA_REMOVAL_WINDOW", "1000"), 10, 64)
+    if err != nil || schemaRemoval <= 0 {
+        return nil, fmt.Errorf("invalid SCHEMA_REMOVAL_WINDOW: %q", src.get("SCHEMA_REMOVAL_WINDOW", "1000"))
+    }
+    schemaFlush, err := time.ParseDuration(src.get("SCHEMA_FLUSH_INTERVAL", "10s"))
+    if err != nil || schemaFlush < 0 {
+        return nil, fmt.Errorf("invalid SCHEMA_FLUSH_INTERVAL: %q", src.get("SCHEMA_FLUSH_INTERVAL", "10s"))
+    }
+
+    llmPrices, err := loadPriceTable(src.get("LLM_PRICE_TABLE", ""))
+    if err != nil {
+        return nil, fmt.Errorf("invalid LLM_PRICE_TABLE: %w", err)
+    }
+
+    evalEnabled, err := strconv.ParseBool(src.get("EVALUATORS_ENABLED", "true"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid EVALUATORS_ENABLED: %w", err)
+    }
+    evalWorkers, err := strconv.Atoi(src.get("EVALUATOR_WORKERS", "2"))
+    if err != nil || evalWorkers <= 0 {
+        return nil, fmt.Errorf("invalid EVALUATOR_WORKERS: %q", src.get("EVALUATOR_WORKERS", "2"))
+    }
+    evalQueue, err := strconv.Atoi(src.get("EVALUATOR_QUEUE_SIZE", "1000"))
+    if err != nil || evalQueue <= 0 {
+        return nil, fmt.Errorf("invalid EVALUATOR_QUEUE_SIZE: %q", src.get("EVALUATOR_QUEUE_SIZE", "1000"))
+    }
+    blocklist, err := loadBlocklist(src.get("EVALUATOR_BLOCKLIST", ""))
+    if err != nil {
+        return nil, fmt.Errorf("invalid EVALUATOR_BLOCKLIST: %w", err)
+    }
+
+    piiEnabled, err := strconv.ParseBool(src.get("PII_ENABLED", "false"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid PII_ENABLED: %w", err)
+    }
+    piiScanNumbers, err := strconv.ParseBool(src.get("PII_SCAN_NUMBERS", "false"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid PII_SCAN_NUMBERS: %w", err)
+    }
+    piiActions, err := parseKeyValueList(src.get("PII_ACTIONS", "*=mask"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid PII_ACTIONS: %w", err)
+    }
+    if _, ok := piiActions["*"]; !ok {
+        piiActions["*"] = string(pii.ActionMask)
+    }
+    piiKey := src.get("PII_HMAC_KEY", "")
+    for model, a := range piiActions {
+        action, err := pii.ParseAction(a)
+        if err != nil {
+            return nil, fmt.Errorf("invalid PII_ACTIONS: %w", err)
+        }
+        if action == pii.ActionHash && piiKey == "" && piiEnabled {
+            return nil, fmt.Errorf("PII_HMAC_KEY is required to hash PII of %q", model)
+        }
+        piiActions[model] = string(action)
+    }
+    piiPatterns := map[string]string{}
+    if err := loadJSONSetting(src.get("PII_CUSTOM_PATTERNS", ""), &piiPatterns); err != nil {
+        return nil, fmt.Errorf("invalid PII_CUSTOM_PATTERNS: %w", err)
+    }
+
+    var keyFile KeyFile
+    if err := loadJSONSetting(src.get("ENCRYPTION_KEYS", ""), &keyFile); err != nil {
+        return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: %w", err)
+    }
+    encKeys := map[string][]byte{}
+    for id, k := range keyFile.Keys {
+        b, err := base64.StdEncoding.DecodeString(k)
+        if err != nil {
+            return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: key %q: %w", id, err)
+        }
+        encKeys[id] = b
+    }
+    if len(encKeys) > 0 && keyFile.Primary == "" {
+        return nil, errors.New("invalid ENCRYPTION_KEYS: primary key ID is required")
+    }
+    var safeFields []string
+    for _, f := range strings.Split(src.get("ENCRYPTION_SAFE_FIELDS", ""), ",") {
+        if f = strings.TrimSpace(f); f != "" {
+            safeFields = append(safeFields, f)
+        }
+    }
+    auditEnabled, err := strconv.ParseBool(src.get("AUDIT_ENABLED", "true"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid AUDIT_ENABLED: %w", err)
+    }
+    auditBatch, err := strconv.Atoi(src.get("AUDIT_BATCH_SIZE", "100"))
+    if err != nil || auditBatch <= 0 {
+        return nil, fmt.Errorf("invalid AUDIT_BATCH_SIZE: %q", src.get("AUDIT_BATCH_SIZE", "100"))
+    }
+    auditQueue, err := strconv.Atoi(src.get("AUDIT_QUEUE_SIZE", "10000"))
+    if err != nil || auditQueue <= 0 {
+        return nil, fmt.Errorf("invalid AUDIT_QUEUE_SIZE: %q", src.get("AUDIT_QUEUE_SIZE", "10000"))
+    }
+    var apiKeys []APIKey
+    if err := loadJSONSetting(src.get("API_KEYS", ""), &apiKeys); err != nil {
+        return nil, fmt.Errorf("invalid API_KEYS: %w", err)
+    }
+    for i, k := range apiKeys {
+        role, err := auth.ParseRole(k.Role)
+        if err != nil {
+            return nil, fmt.Errorf("invalid API_KEYS: %w", err)
+        }
+        if k.Name == "" || (k.Key == "") == (k.KeySHA256 == "") {
+            return nil, fmt.Errorf("invalid API_KEYS: entry %d needs a name and one of key or key_sha256", i)
+        }
+        apiKeys[i].Role = string(role)
+    }
+    jwks := src.get("JWT_JWKS", "")
+    jwtIssuer, jwtAudience := src.get("JWT_ISSUER", ""), src.get("JWT_AUDIENCE", "")
+    if jwks != "" && (jwtIssuer == "" || jwtAudience == "") {
+        return nil, errors.New("JWT_ISSUER and JWT_AUDIENCE are required with JWT_JWKS")
+    }
+    jwtRoleMap, err := parseKeyValueList(src.get("JWT_ROLE_MAP", ""))
+    if err != nil {
+        return nil, fmt.Errorf("invalid JWT_ROLE_MAP: %w", err)
+    }
+    for claim, r := range jwtRoleMap {
+        role, err := auth.ParseRole(r)
+        if err != nil {
+            return nil, fmt.Errorf("invalid JWT_ROLE_MAP: %w", err)
+        }
+        jwtRoleMap[claim] = string(role)
+    }
+    jwtLeeway, err := time.ParseDuration(src.get("JWT_LEEWAY", "1m"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid JWT_LEEWAY: %w", err)
+    }
+    jwksRefresh, err := time.ParseDuration(src.get("JWKS_REFRESH_INTERVAL", "1h"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid JWKS_REFRESH_INTERVAL: %w", err)
+    }
+    ingestRPS, err := strconv.ParseFloat(src.get("RATE_LIMIT_INGEST_RPS", "200"), 64)
+    if err != nil || ingestRPS < 0 {
+        return nil, fmt.Errorf("invalid RATE_LIMIT_INGEST_RPS: %q", src.get("RATE_LIMIT_INGEST_RPS", "200"))
+    }
+    ingestBurst, err := strconv.Atoi(src.get("RATE_LIMIT_INGEST_BURST", "400"))
+    if err != nil || ingestBurst < 1 {
+        return nil, fmt.Errorf("invalid RATE_LIMIT_INGEST_BURST: %q", src.get("RATE_LIMIT_INGEST_BURST", "400"))
+    }
+    queryRPS, err := strconv.ParseFloat(src.get("RATE_LIMIT_QUERY_RPS", "20"), 64)
+    if err != nil || queryRPS < 0 {
+        return nil, fmt.Errorf("invalid RATE_LIMIT_QUERY_RPS: %q", src.get("RATE_LIMIT_QUERY_RPS", "20"))
+    }
+    queryBurst, err := strconv.Atoi(src.get("RATE_LIMIT_QUERY_BURST", "40"))
+    if err != nil || queryBurst < 1 {
+        return nil, fmt.Errorf("invalid RATE_LIMIT_QUERY_BURST: %q", src.get("RATE_LIMIT_QUERY_BURST", "40"))
+    }
+    trustForwarded, err := strconv.ParseBool(src.get("RATE_LIMIT_TRUST_FORWARDED_FOR", "false"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid RATE_LIMIT_TRUST_FORWARDED_FOR: %w", err)
+    }
+    maxBody, err := strconv.ParseInt(src.get("MAX_BODY_BYTES", "1048576"), 10, 64)
+    if err != nil || maxBody <= 0 {
+        return nil, fmt.Errorf("invalid MAX_BODY_BYTES: %q", src.get("MAX_BODY_BYTES", "1048576"))
+    }
+    tlsCert, tlsKey := src.get("TLS_CERT_FILE", ""), src.get("TLS_KEY_FILE", "")
+    tlsClientCA := src.get("TLS_CLIENT_CA_FILE", "")
+    if (tlsCert == "") != (tlsKey == "") {
+        return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+    }
+    if tlsClientCA != "" && tlsCert == "" {
+        return nil, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+    }
+    tlsClientAuth := src.get("TLS_CLIENT_AUTH", "require")
+    if tlsClientAuth != "require" && tlsClientAuth != "optional" {
+        return nil, fmt.Errorf("invalid TLS_CLIENT_AUTH: %q (want require or optional)", tlsClientAuth)
+    }
+    tlsClientRoles, err := parseKeyValueList(src.get("TLS_CLIENT_ROLES", ""))
+    if err != nil {
+        return nil, fmt.Errorf("invalid TLS_CLIENT_ROLES: %w", err)
+    }
+    for cn, r := range tlsClientRoles {
+        role, err := auth.ParseRole(r)
+        if err != nil {
+            return nil, fmt.Errorf("invalid TLS_CLIENT_ROLES: %w", err)
+        }
+        tlsClientRoles[cn] = string(role)
+    }
+    tlsReload, err := time.ParseDuration(src.get("TLS_RELOAD_INTERVAL", "30s"))
+    if err != nil || tlsReload <= 0 {
+        return nil, fmt.Errorf("invalid TLS_RELOAD_INTERVAL: %q", src.get("TLS_RELOAD_INTERVAL", "30s"))
+    }
 
     return &Config{
-        DBHost:     getEnv("DB_HOST", "localhost"),
+        DBHost:     src.get("DB_HOST", "localhost"),
         DBPort:     port,
-        DBUser:     getEnv("DB_USER", "postgres"),
-        DBPassword: getEnv("DB_PASSWORD", "postgres"),
-        DBName:     getEnv("DB_NAME", "postgres"),
-        SSLMode:    getEnv("DB_SSLMODE", "disable"),
+        DBUser:     src.get("DB_USER", "postgres"),
+        DBPassword: src.get("DB_PASSWORD", "postgres"),
+        DBName:     src.get("DB_NAME", "postgres"),
+        SSLMode:    src.get("DB_SSLMODE", "disable"),
+
+        DBDSN: src.get("DB_DSN", ""),
+
+        DBMaxOpenConns:    maxOpen,
+        DBMaxIdleConns:    maxIdle,
+        DBConnMaxLifetime: connLifetime,
+        DBStartupTimeout:  startupTimeout,
+
+        ListenAddr:          listenAddr,
+        ReadTimeout:         timeouts["HTTP_READ_TIMEOUT"],
+        ReadHeaderTimeout:   timeouts["HTTP_READ_HEADER_TIMEOUT"],
+        WriteTimeout:        timeouts["HTTP_WRITE_TIMEOUT"],
+        IdleTimeout:         timeouts["HTTP_IDLE_TIMEOUT"],
+        ShutdownGracePeriod: timeouts["SHUTDOWN_GRACE_PERIOD"],
+        ReadyTimeout:        timeouts["READYZ_TIMEOUT"],
+
+        FairnessAttributes:      fairness,
+        CalibrationECEThreshold: eceThreshold,
+        MetricsWindowLimit:      windowLimit,
+
+        RollupEnabled:   rollupEnabled,
+        RollupBucket:    rollupBucket,
+        RollupInterval:  rollupInterval,
+        RollupLag:       rollupLag,
+        RollupBatchSize: rollupBatch,
+
+        DataQualityEnabled:         dqEnabled,
+        DataQualityLearningSamples: dqLearning,
+        DataQualityFlushInterval:   dqFlush,
+
+        SchemaTrackingEnabled: schemaEnabled,
+        SchemaRemovalWindow:   schemaRemoval,
+        SchemaFlushInterval:   schemaFlush,
+
+        LLMPrices: llmPrices,
+
+        EvaluatorsEnabled:       evalEnabled,
+        EvaluatorWorkers:        evalWorkers,
+        EvaluatorQueueSize:      evalQueue,
+        EvaluatorTextField:      src.get("EVALUATOR_TEXT_FIELD", ""),
+        EvaluatorReferenceField: src.get("EVALUATOR_REFERENCE_FIELD", "reference"),
+        EvaluatorBlocklist:      blocklist,
+
+        PIIEnabled:        piiEnabled,
+        PIIScanNumbers:    piiScanNumbers,
+        PIIActions:        piiActions,
+        PIIHMACKey:        piiKey,
+        PIICustomPatterns: piiPatterns,
+
+        EncryptionPrimaryKey: keyFile.Primary,
+        EncryptionKeys:       encKeys,
+        EncryptionSafeFields: safeFields,
+
+        AuditEnabled:   auditEnabled,
+        AuditHMACKey:   src.get("AUDIT_HMAC_KEY", ""),
+        AuditBatchSize: auditBatch,
+        AuditQueueSize: auditQueue,
+
+        APIKeys: apiKeys,
+
+        JWTJWKS:             jwks,
+        JWTIssuer:           jwtIssuer,
+        JWTAudience:         jwtAudience,
+        JWTRolesClaim:       src.get("JWT_ROLES_CLAIM", "roles"),
+        JWTRoleMap:          jwtRoleMap,
+        JWTNameClaim:        src.get("JWT_NAME_CLAIM", "sub"),
+        JWTLeeway:           jwtLeeway,
+        JWKSRefreshInterval: jwksRefresh,
+
+        RateLimitIngestRPS:         ingestRPS,
+        RateLimitIngestBurst:       ingestBurst,
+        RateLimitQueryRPS:          queryRPS,
+        RateLimitQueryBurst:        queryBurst,
+        RateLimitTrustForwardedFor: trustForwarded,
+        MaxBodyBytes:               maxBody,
+
+        TLSCertFile:       tlsCert,
+        TLSKeyFile:        tlsKey,
+        TLSClientCAFile:   tlsClientCA,
+        TLSClientAuth:     tlsClientAuth,
+        TLSClientRoles:    tlsClientRoles,
+        TLSReloadInterval: tlsReload,
     }, nil
 }
 
-func getEnv(key, defaultVal string) string {
-    if value := os.Getenv(key); value != "" {
-        return value
+// ProtectedAttribute returns the configured protected attribute path for a
+// model, falling back to the "*" entry.
+func (c *Config) ProtectedAttribute(model string) string {
+    if path, ok := c.FairnessAttributes[model]; ok {
+        return path
+    }
+    return c.FairnessAttributes["*"]
+}
+
+// PIIAction returns the PII action configured for a model, falling back to
+// the "*" entry.
+func (c *Config) PIIAction(model string) pii.Action {
+    if a, ok := c.PIIActions[model]; ok {
+        return pii.Action(a)
+    }
+    if a, ok := c.PIIActions["*"]; ok {
+        return pii.Action(a)
+    }
+    return pii.ActionMask
+}
+
+// LLMPrice returns the price of a model: an exact entry, or else the entry
+// with the longest matching "prefix*" key.
+func (c *Config) LLMPrice(model string) (LLMPrice, bool) {
+    if p, ok := c.LLMPrices[model]; ok {
+        return p, true
+    }
+    best, found := "", false
+    for key := range c.LLMPrices {
+        prefix, ok := strings.CutSuffix(key, "*")
+        if ok && strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+            best, found = prefix, true
+        }
+    }
+    if !found {
+        return LLMPrice{}, false
+    }
+    return c.LLMPrices[best+"*"], true
+}
+
+// loadPriceTable reads a JSON price table given inline or as a file path:
+//   {"gpt-4o": {"prompt_per_million": 2.5, "completion_per_million": 10}}
+func loadPriceTable(s string) (map[string]LLMPrice, error) {
+    out := map[string]LLMPrice{}
+    if err := loadJSONSetting(s, &out); err != nil {
+        return nil, err
+    }
+    for model, p := range out {
+        if p.PromptPerMillion < 0 || p.CompletionPerMillion < 0 {
+            return nil, fmt.Errorf("negative price for %q", model)
+        }
+    }
+    return out, nil
+}
+
+// loadBlocklist reads a JSON array of regular expressions given inline or as
+// a file path: ["password", "\\bssn\\b"]. Matching is case-insensitive.
+func loadBlocklist(s string) ([]string, error) {
+    var out []string
+    if err := loadJSONSetting(s, &out); err != nil {
+        return nil, err
+    }
+    return out, nil
+}
+
+// loadJSONSetting decodes a JSON value given inline (starting with "{" or
+// "[") or as a path to a file holding it. An empty setting leaves v alone.
+func loadJSONSetting(s string, v interface{}) error {
+    s = strings.TrimSpace(s)
+    if s == "" {
+        return nil
+    }
+    raw := []byte(s)
+    if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+        b, err := os.ReadFile(s)
+        if err != nil {
+            return err
+        }
+        raw = b
+    }
+    return json.Unmarshal(raw, v)
+}
+
+// parseKeyValueList parses "a=x,b=y" into a map.
+func parseKeyValueList(s string) (map[string]string, error) {
+    out := map[string]string{}
+    for _, pair := range strings.Split(s, ",") {
+        pair = strings.TrimSpace(pair)
+        if pair == "" {
+            continue
+        }
+        k, v, ok := strings.Cut(pair, "=")
+        if !ok || strings.TrimSpace(k) == "" {
+            return nil, fmt.Errorf("expected key=value, got %q", pair)
+        }
+        out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+    }
+    return out, nil
+}
+
+// get returns the env var key if set, else its config file value, else
+// defaultVal, and records which one was used.
+func (src *source) get(key, defaultVal string) string {
+    value, origin := defaultVal, "default"
+    if v := os.Getenv(key); v != "" {
+        value, origin = v, "env"
+    } else if f, ok := src.file[key]; ok {
+        value, origin = f.Value, fmt.Sprintf("%s:%d", src.path, f.Line)
     }
-    return defaultVal
+    src.resolved[key] = Setting{Name: key, Value: value, Origin: origin}
+    return value
 }
@@ -0,0 +1,232 @@
+package config
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// fileSetting is one value from a config file.
+type fileSetting struct {
+    Key   string // as written, e.g. "http.read_timeout"
+    Value string
+    Line  int
+}
+
+var settingKey = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// readConfigFile parses a YAML (.yaml, .yml) or TOML (.toml) config file
+// into settings keyed by env var name. Sections and dotted keys are joined
+// with underscores, so
+//
+//   http:
+//     read_timeout: 30s
+//
+// and `[http]` followed by `read_timeout = "30s"` both set HTTP_READ_TIMEOUT.
+// Values are scalars; lists and maps are given as strings in the same
+// format as the env var.
+func readConfigFile(path string) (map[string]fileSetting, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("config file: %w", err)
+    }
+    var out map[string]fileSetting
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yaml", ".yml":
+        out, err = parseYAML(string(data))
+    case ".toml":
+        out, err = parseTOML(string(data))
+    default:
+        return nil, fmt.Errorf("config file %s: unknown format, want .yaml, .yml or .toml", path)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("config file %s: %w", path, err)
+    }
+    return out, nil
+}
+
+// parseYAML reads the block-mapping subset of YAML: nested "key:" sections
+// indented with spaces and "key: value" scalars.
+func parseYAML(data string) (map[string]fileSetting, error) {
+    type section struct {
+        indent int
+        key    string
+    }
+    out := map[string]fileSetting{}
+    var stack []section
+    leafIndent := -1
+    for i, line := range strings.Split(data, "\n") {
+        n := i + 1
+        line = strings.TrimRight(line, "\r")
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+            continue
+        }
+        indent := len(line) - len(strings.TrimLeft(line, " "))
+        if strings.HasPrefix(line[indent:], "\t") {
+            return nil, fmt.Errorf("line %d: indent with spaces, not tabs", n)
+        }
+        if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+            return nil, fmt.Errorf("line %d: lists are not supported; give the value as a string", n)
+        }
+        if leafIndent >= 0 && indent > leafIndent {
+            return nil, fmt.Errorf("line %d: unexpected indentation", n)
+        }
+        key, raw, ok := strings.Cut(trimmed, ":")
+        key = strings.TrimSpace(key)
+        if !ok || !settingKey.MatchString(key) {
+            return nil, fmt.Errorf("line %d: expected \"key: value\" or \"section:\", got %q", n, trimmed)
+        }
+        if raw != "" && !strings.HasPrefix(raw, " ") && !strings.HasPrefix(raw, "\t") {
+            return nil, fmt.Errorf("line %d: expected a space after %q", n, key+":")
+        }
+        for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+            stack = stack[:len(stack)-1]
+        }
+        path := make([]string, 0, len(stack)+1)
+        for _, s := range stack {
+            path = append(path, s.key)
+        }
+        path = append(path, key)
+
+        raw = strings.TrimSpace(raw)
+        if raw == "" || strings.HasPrefix(raw, "#") {
+            stack = append(stack, section{indent: indent, key: key})
+            leafIndent = -1
+            continue
+        }
+        value, err := parseScalar(raw)
+        if err != nil {
+            return nil, fmt.Errorf("line %d: %w", n, err)
+        }
+        if err := addSetting(out, path, value, n); err != nil {
+            return nil, err
+        }
+        leafIndent = indent
+    }
+    return out, nil
+}
+
+// parseTOML reads the subset of TOML made of [section] tables and
+// "key = value" scalars.
+func parseTOML(data string) (map[string]fileSetting, error) {
+    out := map[string]fileSetting{}
+    var table []string
+    for i, line := range strings.Split(data, "\n") {
+        n := i + 1
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+            continue
+        }
+        if strings.HasPrefix(trimmed, "[[") {
+            return nil, fmt.Errorf("line %d: arrays of tables are not supported", n)
+        }
+        if strings.HasPrefix(trimmed, "[") {
+            name, rest, ok := strings.Cut(trimmed[1:], "]")
+            name = strings.TrimSpace(name)
+            rest = strings.TrimSpace(rest)
+            if !ok || !settingKey.MatchString(name) || (rest != "" && !strings.HasPrefix(rest, "#")) {
+                return nil, fmt.Errorf("line %d: invalid table header %q", n, trimmed)
+            }
+            table = strings.Split(name, ".")
+            continue
+        }
+        key, raw, ok := strings.Cut(trimmed, "=")
+        key = strings.TrimSpace(key)
+        if !ok || !settingKey.MatchString(key) {
+            return nil, fmt.Errorf("line %d: expected \"key = value\", got %q", n, trimmed)
+        }
+        value, err := parseScalar(strings.TrimSpace(raw))
+        if err != nil {
+            return nil, fmt.Errorf("line %d: %w", n, err)
+        }
+        path := append(append([]string{}, table...), strings.Split(key, ".")...)
+        if err := addSetting(out, path, value, n); err != nil {
+            return nil, err
+        }
+    }
+    return out, nil
+}
+
+func addSetting(out map[string]fileSetting, path []string, value string, line int) error {
+    key := strings.Join(path, ".")
+    name := envName(key)
+    if prev, ok := out[name]; ok {
+        return fmt.Errorf("line %d: %s is already set on line %d", line, key, prev.Line)
+    }
+    out[name] = fileSetting{Key: key, Value: value, Line: line}
+    return nil
+}
+
+// envName maps a config file key to its env var, e.g. "http.read_timeout"
+// to HTTP_READ_TIMEOUT.
+func envName(key string) string {
+    return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// fileKey is the flat config file key of an env var.
+func fileKey(name string) string {
+    return strings.ToLower(name)
+}
+
+// parseScalar reads a double-quoted, single-quoted or bare value followed
+// by an optional comment.
+func parseScalar(raw string) (string, error) {
+    if raw == "" {
+        return "", nil
+    }
+    var value, rest string
+    switch raw[0] {
+    case '"':
+        end := 1
+        for end < len(raw) && raw[end] != '"' {
+            if raw[end] == '\\' {
+                end++
+            }
+            end++
+        }
+        if end >= len(raw) {
+            return "", errors.New("unterminated string")
+        }
+        v, err := strconv.Unquote(raw[:end+1])
+        if err != nil {
+            return "", fmt.Errorf("invalid string %s", raw[:end+1])
+        }
+        value, rest = v, raw[end+1:]
+    case '\'':
+        // A doubled quote stands for one quote, as in YAML.
+        var b strings.Builder
+        end := 1
+        for ; end < len(raw); end++ {
+            if raw[end] == '\'' {
+                if end+1 < len(raw) && raw[end+1] == '\'' {
+                    b.WriteByte('\'')
+                    end++
+                    continue
+                }
+                break
+            }
+            b.WriteByte(raw[end])
+        }
+        if end >= len(raw) {
+            return "", errors.New("unterminated string")
+        }
+        value, rest = b.String(), raw[end+1:]
+    case '[', '{':
+        return "", fmt.Errorf("lists and maps are not supported; quote the value: '%s'", raw)
+    default:
+        value = raw
+        if i := strings.Index(raw, " #"); i >= 0 {
+            value = raw[:i]
+        }
+        return strings.TrimSpace(value), nil
+    }
+    if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+        return "", fmt.Errorf("unexpected %q after string", rest)
+    }
+    return value, nil
+}
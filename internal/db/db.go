@@ -1,26 +1,110 @@
 package db
 
 import (
+    "context"
     "database/sql"
     "fmt"
+    "log"
+    "strings"
+    "time"
 
-    _ "github.com/lib/pq" 
+    "github.com/lib/pq"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
 )
 
-func ConnectDB(cfg *config.Config) (*sql.DB, error) {
-    dsn := fmt.Sprintf(
-        "host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-        cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.SSLMode,
-    )
+// DefaultRetry is the backoff between startup pings; its Timeout comes
+// from DB_STARTUP_TIMEOUT.
+var DefaultRetry = Retry{Initial: 500 * time.Millisecond, Max: 10 * time.Second}
+
+// Retry pings the database with exponential backoff until it answers or
+// Timeout passes. A zero Timeout pings once.
+type Retry struct {
+    Timeout time.Duration
+    Initial time.Duration
+    Max     time.Duration
+}
 
-    db, err := sql.Open("postgres", dsn)
+// DSN returns DB_DSN as given, or a libpq keyword/value string built from
+// the DB_* settings with every value quoted.
+func DSN(cfg *config.Config) string {
+    if cfg.DBDSN != "" {
+        return cfg.DBDSN
+    }
+    return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+        quoteDSN(cfg.DBHost), cfg.DBPort, quoteDSN(cfg.DBUser), quoteDSN(cfg.DBPassword),
+        quoteDSN(cfg.DBName), quoteDSN(cfg.SSLMode))
+}
+
+// quoteDSN quotes a keyword/value DSN value, so spaces, quotes and
+// backslashes survive.
+func quoteDSN(s string) string {
+    return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// Open returns a connection pool with the configured limits. It does not
+// connect.
+func Open(cfg *config.Config) (*sql.DB, error) {
+    connector, err := pq.NewConnector(DSN(cfg))
     if err != nil {
+        if cfg.DBDSN != "" {
+            return nil, fmt.Errorf("invalid DB_DSN: %w", err)
+        }
         return nil, fmt.Errorf("failed to open db: %w", err)
     }
+    db := sql.OpenDB(connector)
+    db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+    db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+    db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+    return db, nil
+}
+
+// ConnectDB opens the pool and waits for the database to accept
+// connections, retrying for up to DB_STARTUP_TIMEOUT.
+func ConnectDB(cfg *config.Config) (*sql.DB, error) {
+    db, err := Open(cfg)
+    if err != nil {
+        return nil, err
+    }
 
-    if err := db.Ping(); err != nil {
+    retry := DefaultRetry
+    retry.Timeout = cfg.DBStartupTimeout
+    if err := retry.Ping(context.Background(), db); err != nil {
+        db.Close()
         return nil, fmt.Errorf("failed to ping db: %w", err)
     }
     return db, nil
 }
+
+// Ping pings db until it succeeds, ctx is done or r.Timeout passes.
+func (r Retry) Ping(ctx context.Context, db *sql.DB) error {
+    if r.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+        defer cancel()
+    }
+    wait := r.Initial
+    var lastErr error
+    for attempt := 1; ; attempt++ {
+        err := db.PingContext(ctx)
+        if err == nil {
+            return nil
+        }
+        if r.Timeout <= 0 {
+            return err
+        }
+        // A ping cut short by the deadline only reports the deadline; keep
+        // the error of the last attempt that completed.
+        if lastErr == nil || ctx.Err() == nil {
+            lastErr = err
+        }
+        log.Printf("Database not ready (attempt %d), retrying in %s: %v\n", attempt, wait, err)
+        select {
+        case <-ctx.Done():
+            return fmt.Errorf("gave up after %s (%d attempts): %w", r.Timeout, attempt, lastErr)
+        case <-time.After(wait):
+        }
+        if wait = wait * 2; wait > r.Max {
+            wait = r.Max
+        }
+    }
+}
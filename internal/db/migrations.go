@@ -1,29 +1,179 @@
 package db
 
 import (
+    "context"
+    "database/sql"
+    "errors"
     "fmt"
+    "io/fs"
     "log"
+    "strconv"
+    "strings"
 
     migrate "github.com/golang-migrate/migrate"
-    _ "github.com/golang-migrate/migrate/database/postgres"
-    _ "github.com/golang-migrate/migrate/source/file"
+    "github.com/golang-migrate/migrate/database/postgres"
+    bindata "github.com/golang-migrate/migrate/source/go_bindata"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/migrations"
 )
 
-func RunMigrations(cfg *config.Config) error {
-    migrationPath := "file://migrations"
-    dbURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-        cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.SSLMode)
+// Migrator applies the migrations embedded in the binary to the
+// configured database. Concurrent migrators wait on a database lock.
+type Migrator struct {
+    m *migrate.Migrate
+}
+
+// NewMigrator connects to the database, retrying for up to
+// DB_STARTUP_TIMEOUT, and loads the embedded migrations.
+func NewMigrator(cfg *config.Config) (*Migrator, error) {
+    names, err := fs.Glob(migrations.FS, "*.sql")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations: %w", err)
+    }
+    src, err := bindata.WithInstance(bindata.Resource(names, func(name string) ([]byte, error) {
+        return fs.ReadFile(migrations.FS, name)
+    }))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations: %w", err)
+    }
 
-    m, err := migrate.New(migrationPath, dbURL)
+    // The migration driver holds a connection and closes its pool when
+    // done, so it gets a pool of its own from the same DSN.
+    db, err := Open(cfg)
     if err != nil {
-        return fmt.Errorf("failed to create migrate instance: %w", err)
+        return nil, err
+    }
+    retry := DefaultRetry
+    retry.Timeout = cfg.DBStartupTimeout
+    if err := retry.Ping(context.Background(), db); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("failed to ping db: %w", err)
+    }
+    driver, err := postgres.WithInstance(db, &postgres.Config{})
+    if err != nil {
+        db.Close()
+        return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+    }
+    m, err := migrate.NewWithInstance("go-bindata", src, "postgres", driver)
+    if err != nil {
+        driver.Close()
+        return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+    }
+    m.Log = migrateLogger{}
+    return &Migrator{m: m}, nil
+}
+
+// Up applies every pending migration.
+func (mg *Migrator) Up() error {
+    return ignoreNoChange(mg.m.Up())
+}
+
+// Down rolls back the last steps migrations, or all of them when steps is
+// 0.
+func (mg *Migrator) Down(steps int) error {
+    if steps == 0 {
+        return ignoreNoChange(mg.m.Down())
     }
+    return ignoreNoChange(mg.m.Steps(-steps))
+}
+
+// Goto migrates up or down to version.
+func (mg *Migrator) Goto(version uint) error {
+    return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Version returns the applied version, 0 if none, and whether the last
+// migration stopped halfway.
+func (mg *Migrator) Version() (uint, bool, error) {
+    version, dirty, err := mg.m.Version()
+    if err == migrate.ErrNilVersion {
+        return 0, false, nil
+    }
+    return version, dirty, err
+}
+
+// Force records version as applied and clean without running anything,
+// to recover from a dirty migration once it has been fixed by hand. A
+// version of -1 records that nothing is applied.
+func (mg *Migrator) Force(version int) error {
+    if version < -1 {
+        return errors.New("version must be -1 or more")
+    }
+    return mg.m.Force(version)
+}
 
-    if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+// Close releases the migrator's database connections.
+func (mg *Migrator) Close() error {
+    srcErr, dbErr := mg.m.Close()
+    if srcErr != nil {
+        return srcErr
+    }
+    return dbErr
+}
+
+func ignoreNoChange(err error) error {
+    if err == migrate.ErrNoChange {
+        return nil
+    }
+    return err
+}
+
+// migrateLogger reports each applied migration through the standard log.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+    log.Printf(strings.TrimSpace(format), v...)
+}
+
+func (migrateLogger) Verbose() bool { return false }
+
+// RunMigrations applies every pending migration.
+func RunMigrations(cfg *config.Config) error {
+    m, err := NewMigrator(cfg)
+    if err != nil {
+        return err
+    }
+    defer m.Close()
+
+    if err := m.Up(); err != nil {
         return fmt.Errorf("migration failed: %w", err)
     }
 
     log.Println("Database migrated successfully!")
     return nil
 }
+
+// LatestMigration returns the highest version among the embedded
+// migrations.
+func LatestMigration() (uint, error) {
+    names, err := fs.Glob(migrations.FS, "*.up.sql")
+    if err != nil {
+        return 0, fmt.Errorf("LatestMigration: %w", err)
+    }
+    var latest uint64
+    for _, name := range names {
+        prefix, _, _ := strings.Cut(name, "_")
+        if v, err := strconv.ParseUint(prefix, 10, 64); err == nil && v > latest {
+            latest = v
+        }
+    }
+    if latest == 0 {
+        return 0, errors.New("LatestMigration: no migrations embedded")
+    }
+    return uint(latest), nil
+}
+
+// MigrationVersion returns the applied migration version and whether the
+// last migration stopped halfway. A database without migrations is at 0.
+func MigrationVersion(ctx context.Context, database *sql.DB) (uint, bool, error) {
+    var version int64
+    var dirty bool
+    err := database.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+    if err == sql.ErrNoRows {
+        return 0, false, nil
+    }
+    if err != nil {
+        return 0, false, fmt.Errorf("MigrationVersion: %w", err)
+    }
+    return uint(version), dirty, nil
+}
@@ -0,0 +1,424 @@
+// Package drift detects distribution shift between a reference and a
+// current window of traffic.
+package drift
+
+import (
+    "errors"
+    "math"
+    "math/rand"
+    "sort"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+)
+
+var (
+    ErrDimensionMismatch = errors.New("reference and current vectors have different dimensions")
+    ErrTooFewSamples     = errors.New("not enough vectors in one of the windows")
+)
+
+// EmbeddingOptions configures Embeddings.
+type EmbeddingOptions struct {
+    // MaxSamples caps the vectors used per window; the kernel matrix grows
+    // with the square of the pooled sample.
+    MaxSamples int
+    // Permutations is the number of label shuffles of the MMD permutation
+    // test.
+    Permutations int
+    // Folds is the number of cross-validation folds of the domain classifier.
+    Folds int
+    // Epochs is the number of gradient descent steps per fold.
+    Epochs int
+    Seed   int64
+    // Alpha is the significance level of the MMD test used for Drifted.
+    Alpha float64
+}
+
+var DefaultEmbeddingOptions = EmbeddingOptions{
+    MaxSamples:   500,
+    Permutations: 200,
+    Folds:        5,
+    Epochs:       100,
+    Seed:         1,
+    Alpha:        0.05,
+}
+
+// CentroidShift compares the mean vectors of both windows.
+type CentroidShift struct {
+    CosineDistance    float64 `json:"cosine_distance"`
+    EuclideanDistance float64 `json:"euclidean_distance"`
+    ReferenceNorm     float64 `json:"reference_norm"`
+    CurrentNorm       float64 `json:"current_norm"`
+}
+
+// MMDResult is the unbiased squared Maximum Mean Discrepancy under an RBF
+// kernel whose bandwidth is the median pairwise distance of the pooled
+// sample, with a permutation test p-value.
+type MMDResult struct {
+    MMD2         float64 `json:"mmd2"`
+    Bandwidth    float64 `json:"bandwidth"`
+    PValue       float64 `json:"p_value"`
+    Permutations int     `json:"permutations"`
+}
+
+// ClassifierResult is the cross-validated ROC AUC of a logistic regression
+// trained to tell current from reference vectors. DriftScore rescales it to
+// [0, 1]: 0 when the windows are indistinguishable, 1 when fully separable.
+type ClassifierResult struct {
+    AUC        float64 `json:"auc"`
+    DriftScore float64 `json:"drift_score"`
+    Folds      int     `json:"folds"`
+}
+
+// EmbeddingReport holds the three drift measures for one vector field.
+type EmbeddingReport struct {
+    Dimension      int              `json:"dimension"`
+    ReferenceCount int              `json:"reference_count"`
+    CurrentCount   int              `json:"current_count"`
+    Centroid       CentroidShift    `json:"centroid"`
+    MMD            MMDResult        `json:"mmd"`
+    Classifier     ClassifierResult `json:"classifier"`
+    Drifted        bool             `json:"drifted"`
+}
+
+// ParseVectors decodes JSON arrays of numbers. Values that are not numeric
+// arrays, or whose length differs from the first valid vector, are skipped.
+func ParseVectors(raw []string) (vectors [][]float64, skipped int) {
+    dim := 0
+    for _, r := range raw {
+        doc, err := jsonpath.Decode(r)
+        arr, ok := doc.([]interface{})
+        if err != nil || !ok || len(arr) == 0 || (dim > 0 && len(arr) != dim) {
+            skipped++
+            continue
+        }
+        vec := make([]float64, len(arr))
+        valid := true
+        for i, v := range arr {
+            f, ok := v.(float64)
+            if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+                valid = false
+                break
+            }
+            vec[i] = f
+        }
+        if !valid {
+            skipped++
+            continue
+        }
+        dim = len(vec)
+        vectors = append(vectors, vec)
+    }
+    return vectors, skipped
+}
+
+// Embeddings compares reference and current vectors.
+func Embeddings(ref, cur [][]float64, opts EmbeddingOptions) (*EmbeddingReport, error) {
+    minSamples := 2
+    if opts.Folds > minSamples {
+        minSamples = opts.Folds
+    }
+    if len(ref) < minSamples || len(cur) < minSamples {
+        return nil, ErrTooFewSamples
+    }
+    dim := len(ref[0])
+    for _, set := range [][][]float64{ref, cur} {
+        for _, v := range set {
+            if len(v) != dim {
+                return nil, ErrDimensionMismatch
+            }
+        }
+    }
+
+    rng := rand.New(rand.NewSource(opts.Seed))
+    ref = subsample(ref, opts.MaxSamples, rng)
+    cur = subsample(cur, opts.MaxSamples, rng)
+
+    report := &EmbeddingReport{
+        Dimension:      dim,
+        ReferenceCount: len(ref),
+        CurrentCount:   len(cur),
+        Centroid:       centroidShift(ref, cur),
+        MMD:            mmd(ref, cur, opts.Permutations, rng),
+        Classifier:     domainClassifier(ref, cur, opts.Folds, opts.Epochs, rng),
+    }
+    report.Drifted = report.MMD.PValue < opts.Alpha
+    return report, nil
+}
+
+func subsample(vs [][]float64, max int, rng *rand.Rand) [][]float64 {
+    if max <= 0 || len(vs) <= max {
+        return vs
+    }
+    out := append([][]float64(nil), vs...)
+    for i := 0; i < max; i++ {
+        j := i + rng.Intn(len(out)-i)
+        out[i], out[j] = out[j], out[i]
+    }
+    return out[:max]
+}
+
+func mean(vs [][]float64) []float64 {
+    out := make([]float64, len(vs[0]))
+    for _, v := range vs {
+        for i, x := range v {
+            out[i] += x
+        }
+    }
+    for i := range out {
+        out[i] /= float64(len(vs))
+    }
+    return out
+}
+
+func norm(v []float64) float64 {
+    s := 0.0
+    for _, x := range v {
+        s += x * x
+    }
+    return math.Sqrt(s)
+}
+
+func sqDist(a, b []float64) float64 {
+    s := 0.0
+    for i := range a {
+        d := a[i] - b[i]
+        s += d * d
+    }
+    return s
+}
+
+func centroidShift(ref, cur [][]float64) CentroidShift {
+    a, b := mean(ref), mean(cur)
+    out := CentroidShift{
+        EuclideanDistance: math.Sqrt(sqDist(a, b)),
+        ReferenceNorm:     norm(a),
+        CurrentNorm:       norm(b),
+    }
+    if out.ReferenceNorm > 0 && out.CurrentNorm > 0 {
+        dot := 0.0
+        for i := range a {
+            dot += a[i] * b[i]
+        }
+        out.CosineDistance = 1 - dot/(out.ReferenceNorm*out.CurrentNorm)
+    }
+    return out
+}
+
+func mmd(ref, cur [][]float64, permutations int, rng *rand.Rand) MMDResult {
+    pooled := append(append([][]float64(nil), ref...), cur...)
+    n := len(pooled)
+
+    d2 := make([][]float64, n)
+    var upper []float64
+    for i := range d2 {
+        d2[i] = make([]float64, n)
+    }
+    for i := 0; i < n; i++ {
+        for j := i + 1; j < n; j++ {
+            d := sqDist(pooled[i], pooled[j])
+            d2[i][j], d2[j][i] = d, d
+            upper = append(upper, d)
+        }
+    }
+    sort.Float64s(upper)
+    median := upper[len(upper)/2]
+    if median == 0 {
+        median = 1
+    }
+
+    // Kernel matrix with k(x, y) = exp(-||x-y||² / (2σ²)), σ² = median d².
+    k := d2
+    for i := 0; i < n; i++ {
+        for j := 0; j < n; j++ {
+            k[i][j] = math.Exp(-d2[i][j] / (2 * median))
+        }
+    }
+
+    idx := make([]int, n)
+    for i := range idx {
+        idx[i] = i
+    }
+    m := len(ref)
+    observed := mmd2(k, idx, m)
+
+    exceed := 0
+    for p := 0; p < permutations; p++ {
+        rng.Shuffle(n, func(i, j int) { idx[i], idx[j] = idx[j], idx[i] })
+        if mmd2(k, idx, m) >= observed {
+            exceed++
+        }
+    }
+    return MMDResult{
+        MMD2:         observed,
+        Bandwidth:    math.Sqrt(median),
+        PValue:       float64(exceed+1) / float64(permutations+1),
+        Permutations: permutations,
+    }
+}
+
+// mmd2 is the unbiased MMD² estimate when idx[:m] is the first sample and
+// idx[m:] the second.
+func mmd2(k [][]float64, idx []int, m int) float64 {
+    n := len(idx) - m
+    var xx, yy, xy float64
+    for a := 0; a < len(idx); a++ {
+        for b := a + 1; b < len(idx); b++ {
+            v := k[idx[a]][idx[b]]
+            switch {
+            case a < m && b < m:
+                xx += v
+            case a >= m && b >= m:
+                yy += v
+            default:
+                xy += v
+            }
+        }
+    }
+    fm, fn := float64(m), float64(n)
+    return 2*xx/(fm*(fm-1)) + 2*yy/(fn*(fn-1)) - 2*xy/(fm*fn)
+}
+
+// domainClassifier runs stratified k-fold cross-validation of an
+// L2-regularised logistic regression on standardised features and returns
+// the AUC of the pooled held-out predictions.
+func domainClassifier(ref, cur [][]float64, folds, epochs int, rng *rand.Rand) ClassifierResult {
+    x := append(append([][]float64(nil), ref...), cur...)
+    y := make([]bool, len(x))
+    for i := len(ref); i < len(x); i++ {
+        y[i] = true
+    }
+    x = standardize(x)
+
+    fold := make([]int, len(x))
+    for _, group := range [][2]int{{0, len(ref)}, {len(ref), len(x)}} {
+        perm := rng.Perm(group[1] - group[0])
+        for i, p := range perm {
+            fold[group[0]+p] = i % folds
+        }
+    }
+
+    scores := make([]float64, len(x))
+    for f := 0; f < folds; f++ {
+        var trainX [][]float64
+        var trainY []bool
+        for i := range x {
+            if fold[i] != f {
+                trainX = append(trainX, x[i])
+                trainY = append(trainY, y[i])
+            }
+        }
+        w, b := fitLogistic(trainX, trainY, epochs)
+        for i := range x {
+            if fold[i] == f {
+                scores[i] = dot(w, x[i]) + b
+            }
+        }
+    }
+
+    auc := rocAUC(scores, y)
+    return ClassifierResult{
+        AUC:        auc,
+        DriftScore: math.Max(0, 2*auc-1),
+        Folds:      folds,
+    }
+}
+
+func standardize(x [][]float64) [][]float64 {
+    mu := mean(x)
+    sd := make([]float64, len(mu))
+    for _, v := range x {
+        for i, f := range v {
+            d := f - mu[i]
+            sd[i] += d * d
+        }
+    }
+    for i := range sd {
+        sd[i] = math.Sqrt(sd[i] / float64(len(x)))
+    }
+    out := make([][]float64, len(x))
+    for r, v := range x {
+        out[r] = make([]float64, len(v))
+        for i, f := range v {
+            if sd[i] > 0 {
+                out[r][i] = (f - mu[i]) / sd[i]
+            }
+        }
+    }
+    return out
+}
+
+func dot(a, b []float64) float64 {
+    s := 0.0
+    for i := range a {
+        s += a[i] * b[i]
+    }
+    return s
+}
+
+// fitLogistic runs full-batch gradient descent on the mean log loss with a
+// small L2 penalty.
+func fitLogistic(x [][]float64, y []bool, epochs int) ([]float64, float64) {
+    const (
+        learningRate = 0.5
+        l2           = 0.01
+    )
+    w := make([]float64, len(x[0]))
+    b := 0.0
+    grad := make([]float64, len(w))
+    n := float64(len(x))
+    for e := 0; e < epochs; e++ {
+        for i := range grad {
+            grad[i] = l2 * w[i]
+        }
+        gb := 0.0
+        for r, v := range x {
+            p := 1 / (1 + math.Exp(-(dot(w, v) + b)))
+            target := 0.0
+            if y[r] {
+                target = 1
+            }
+            d := (p - target) / n
+            for i, f := range v {
+                grad[i] += d * f
+            }
+            gb += d
+        }
+        for i := range w {
+            w[i] -= learningRate * grad[i]
+        }
+        b -= learningRate * gb
+    }
+    return w, b
+}
+
+// rocAUC is the probability that a random positive scores above a random
+// negative, counting ties as one half.
+func rocAUC(scores []float64, positive []bool) float64 {
+    idx := make([]int, len(scores))
+    for i := range idx {
+        idx[i] = i
+    }
+    sort.Slice(idx, func(a, b int) bool { return scores[idx[a]] < scores[idx[b]] })
+
+    var pos, neg, rankSum float64
+    for i := 0; i < len(idx); {
+        j := i
+        for j < len(idx) && scores[idx[j]] == scores[idx[i]] {
+            j++
+        }
+        rank := float64(i+j+1) / 2
+        for k := i; k < j; k++ {
+            if positive[idx[k]] {
+                rankSum += rank
+                pos++
+            } else {
+                neg++
+            }
+        }
+        i = j
+    }
+    if pos == 0 || neg == 0 {
+        return 0.5
+    }
+    return (rankSum - pos*(pos+1)/2) / (pos * neg)
+}
@@ -0,0 +1,172 @@
+package envelope
+
+import (
+    "encoding/json"
+    "strings"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+)
+
+// Field is the key under which the envelope is stored inside an encrypted
+// JSON payload.
+const Field = "__enc"
+
+// Codec encrypts JSON payload columns. The stored document keeps the
+// declared safe fields in clear, next to the envelope holding the whole
+// payload, so SQL aggregations over safe fields keep working:
+//   {"age": 41, "__enc": {"v": 1, "kid": "2025-04", "dek": "...", "ct": "..."}}
+type Codec struct {
+    Keys *Keyring
+    // Safe maps a column ("input_data", ...) to the dotted paths that are
+    // stored in clear.
+    Safe map[string][]string
+}
+
+// NewCodec returns a codec; safeFields are "column.path" entries such as
+// "input_data.country" or "output_data.prediction".
+func NewCodec(keys *Keyring, safeFields []string) *Codec {
+    c := &Codec{Keys: keys, Safe: map[string][]string{}}
+    for _, f := range safeFields {
+        column, path, ok := strings.Cut(strings.TrimSpace(f), ".")
+        if ok && path != "" {
+            c.Safe[column] = append(c.Safe[column], path)
+        }
+    }
+    return c
+}
+
+func aad(column, id string) string {
+    return column + "/" + id
+}
+
+// Encrypt seals the payload of column in row id. Empty payloads are kept
+// as they are.
+func (c *Codec) Encrypt(column, id, raw string) (string, error) {
+    if raw == "" {
+        return raw, nil
+    }
+    env, err := c.Keys.Seal(aad(column, id), []byte(raw))
+    if err != nil {
+        return "", err
+    }
+    doc, err := c.safeDoc(column, raw)
+    if err != nil {
+        return "", err
+    }
+    doc[Field] = env
+    out, err := json.Marshal(doc)
+    if err != nil {
+        return "", err
+    }
+    return string(out), nil
+}
+
+// Decrypt returns the original payload. Payloads stored before encryption
+// was enabled are returned unchanged.
+func (c *Codec) Decrypt(column, id, stored string) (string, error) {
+    env, ok, err := Extract(stored)
+    if err != nil || !ok {
+        return stored, err
+    }
+    plaintext, err := c.Keys.Open(aad(column, id), env)
+    if err != nil {
+        return "", err
+    }
+    return string(plaintext), nil
+}
+
+// Rotate brings a stored payload up to date: plaintext payloads are
+// encrypted and envelopes under an old master key are rewrapped. It reports
+// whether the payload changed.
+func (c *Codec) Rotate(column, id, stored string) (string, bool, error) {
+    env, ok, err := Extract(stored)
+    if err != nil {
+        return "", false, err
+    }
+    if !ok {
+        out, err := c.Encrypt(column, id, stored)
+        return out, err == nil && stored != "", err
+    }
+    rewrapped, changed, err := c.Keys.Rewrap(env)
+    if err != nil || !changed {
+        return stored, false, err
+    }
+    var doc map[string]json.RawMessage
+    if err := json.Unmarshal([]byte(stored), &doc); err != nil {
+        return "", false, err
+    }
+    b, err := json.Marshal(rewrapped)
+    if err != nil {
+        return "", false, err
+    }
+    doc[Field] = b
+    out, err := json.Marshal(doc)
+    if err != nil {
+        return "", false, err
+    }
+    return string(out), true, nil
+}
+
+// SafeView returns only the safe fields of a plaintext payload, as they are
+// stored in clear.
+func (c *Codec) SafeView(column, raw string) string {
+    doc, err := c.safeDoc(column, raw)
+    if err != nil {
+        return "{}"
+    }
+    out, _ := json.Marshal(doc)
+    return string(out)
+}
+
+// Extract returns the envelope of a stored payload, if it has one.
+func Extract(stored string) (*Envelope, bool, error) {
+    if !strings.Contains(stored, Field) {
+        return nil, false, nil
+    }
+    var doc map[string]json.RawMessage
+    if err := json.Unmarshal([]byte(stored), &doc); err != nil {
+        // Not an object, so not an encrypted payload.
+        return nil, false, nil
+    }
+    raw, ok := doc[Field]
+    if !ok {
+        return nil, false, nil
+    }
+    var env Envelope
+    if err := json.Unmarshal(raw, &env); err != nil {
+        return nil, false, err
+    }
+    return &env, true, nil
+}
+
+// safeDoc copies the safe paths of column from raw into a new object with
+// the same nesting.
+func (c *Codec) safeDoc(column, raw string) (map[string]interface{}, error) {
+    out := map[string]interface{}{}
+    paths := c.Safe[column]
+    if len(paths) == 0 {
+        return out, nil
+    }
+    doc, err := jsonpath.Decode(raw)
+    if err != nil {
+        return nil, err
+    }
+    for _, path := range paths {
+        v, ok := jsonpath.Lookup(doc, path)
+        if !ok {
+            continue
+        }
+        segs := strings.Split(path, ".")
+        node := out
+        for _, seg := range segs[:len(segs)-1] {
+            child, ok := node[seg].(map[string]interface{})
+            if !ok {
+                child = map[string]interface{}{}
+                node[seg] = child
+            }
+            node = child
+        }
+        node[segs[len(segs)-1]] = v
+    }
+    return out, nil
+}
@@ -0,0 +1,160 @@
+// Package envelope implements envelope encryption of stored payloads: each
+// payload is encrypted with its own AES-256-GCM data key, and the data key is
+// wrapped with a master key from the keyring. Rotating the master key only
+// requires rewrapping data keys, not re-encrypting payloads.
+package envelope
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "errors"
+    "fmt"
+)
+
+// KeySize is the length of master and data keys (AES-256).
+const KeySize = 32
+
+// Version is the envelope format version.
+const Version = 1
+
+var (
+    ErrUnknownKey = errors.New("unknown master key")
+    ErrDecrypt    = errors.New("decryption failed")
+)
+
+// Envelope is an encrypted payload with its wrapped data key. Byte fields
+// are base64 encoded in JSON.
+type Envelope struct {
+    Version    int    `json:"v"`
+    KeyID      string `json:"kid"`
+    DataKey    []byte `json:"dek"`
+    Ciphertext []byte `json:"ct"`
+}
+
+// Keyring holds the master keys by ID. New envelopes use the primary key;
+// the others are kept to open and rewrap older envelopes.
+type Keyring struct {
+    primary string
+    keys    map[string][]byte
+}
+
+// NewKeyring validates the keys and the primary key ID.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+    if len(keys) == 0 {
+        return nil, errors.New("no master keys")
+    }
+    for id, k := range keys {
+        if id == "" {
+            return nil, errors.New("empty master key ID")
+        }
+        if len(k) != KeySize {
+            return nil, fmt.Errorf("master key %q is %d bytes, want %d", id, len(k), KeySize)
+        }
+    }
+    if _, ok := keys[primary]; !ok {
+        return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+    }
+    return &Keyring{primary: primary, keys: keys}, nil
+}
+
+// PrimaryID returns the ID of the key used for new envelopes.
+func (k *Keyring) PrimaryID() string {
+    return k.primary
+}
+
+// Seal encrypts plaintext under a fresh data key. aad binds the envelope to
+// its context (e.g. column and row ID) so it cannot be moved elsewhere.
+func (k *Keyring) Seal(aad string, plaintext []byte) (*Envelope, error) {
+    dek := make([]byte, KeySize)
+    if _, err := rand.Read(dek); err != nil {
+        return nil, err
+    }
+    ct, err := seal(dek, plaintext, []byte(aad))
+    if err != nil {
+        return nil, err
+    }
+    wrapped, err := seal(k.keys[k.primary], dek, []byte(k.primary))
+    if err != nil {
+        return nil, err
+    }
+    return &Envelope{Version: Version, KeyID: k.primary, DataKey: wrapped, Ciphertext: ct}, nil
+}
+
+// Open decrypts an envelope sealed with the same aad.
+func (k *Keyring) Open(aad string, e *Envelope) ([]byte, error) {
+    dek, err := k.unwrap(e)
+    if err != nil {
+        return nil, err
+    }
+    plaintext, err := open(dek, e.Ciphertext, []byte(aad))
+    if err != nil {
+        return nil, ErrDecrypt
+    }
+    return plaintext, nil
+}
+
+// Rewrap re-encrypts the data key of e under the primary key. The payload
+// ciphertext is unchanged. It reports false when e already uses the primary
+// key.
+func (k *Keyring) Rewrap(e *Envelope) (*Envelope, bool, error) {
+    if e.KeyID == k.primary {
+        return e, false, nil
+    }
+    dek, err := k.unwrap(e)
+    if err != nil {
+        return nil, false, err
+    }
+    wrapped, err := seal(k.keys[k.primary], dek, []byte(k.primary))
+    if err != nil {
+        return nil, false, err
+    }
+    return &Envelope{Version: Version, KeyID: k.primary, DataKey: wrapped, Ciphertext: e.Ciphertext}, true, nil
+}
+
+func (k *Keyring) unwrap(e *Envelope) ([]byte, error) {
+    if e.Version != Version {
+        return nil, fmt.Errorf("unsupported envelope version %d", e.Version)
+    }
+    master, ok := k.keys[e.KeyID]
+    if !ok {
+        return nil, fmt.Errorf("%w %q", ErrUnknownKey, e.KeyID)
+    }
+    dek, err := open(master, e.DataKey, []byte(e.KeyID))
+    if err != nil {
+        return nil, ErrDecrypt
+    }
+    return dek, nil
+}
+
+// seal returns nonce || AES-GCM(key, plaintext, aad).
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return nil, err
+    }
+    nonce := make([]byte, gcm.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return nil, err
+    }
+    if len(data) < gcm.NonceSize() {
+        return nil, ErrDecrypt
+    }
+    return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    return cipher.NewGCM(block)
+}
@@ -0,0 +1,135 @@
+// Package evaluators scores LLM outputs with local heuristics (length, JSON
+// validity, blocklists, refusals, language, overlap with a reference
+// answer). Evaluators never call external services.
+package evaluators
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// Input is what an evaluator sees for one inference.
+type Input struct {
+    Inference models.Inference
+    // Text is the completion extracted from output_data.
+    Text string
+    // Reference is the reference answer from feedback, empty if there is
+    // none.
+    Reference string
+}
+
+// Score is one named result. Evaluators may return several, e.g. BLEU and
+// ROUGE from the same reference comparison.
+type Score struct {
+    Name   string
+    Value  float64
+    Label  string
+    Detail string
+}
+
+// Evaluator scores an input. It returns no scores when it does not apply.
+type Evaluator interface {
+    Name() string
+    Evaluate(in Input) []Score
+}
+
+// ReferenceEvaluator is implemented by evaluators that need a reference
+// answer. They run when feedback arrives rather than on the inference.
+type ReferenceEvaluator interface {
+    Evaluator
+    RequiresReference() bool
+}
+
+func requiresReference(e Evaluator) bool {
+    re, ok := e.(ReferenceEvaluator)
+    return ok && re.RequiresReference()
+}
+
+// Options configures the built-in evaluators and where texts are read from.
+type Options struct {
+    // TextPath is the output_data path of the completion. When empty, a
+    // string output or the first of the usual keys ("completion", "text",
+    // ...) is used.
+    TextPath string
+    // ReferencePath is the feedback_data path of the reference answer.
+    ReferencePath string
+    // Blocklist holds case-insensitive regular expressions; the blocklist
+    // evaluator is only enabled when it is non-empty.
+    Blocklist []string
+}
+
+var DefaultOptions = Options{
+    ReferencePath: "reference",
+}
+
+// textKeys are tried in order when no text path is configured.
+var textKeys = []string{"completion", "text", "output", "response", "answer", "content"}
+
+// ExtractText returns the text at path in a JSON payload. With an empty path
+// it accepts a bare JSON string or the first string-valued key of textKeys.
+func ExtractText(raw, path string) string {
+    doc, err := jsonpath.Decode(raw)
+    if err != nil || doc == nil {
+        return ""
+    }
+    if path != "" {
+        v, ok := jsonpath.Lookup(doc, path)
+        if !ok {
+            return ""
+        }
+        if s, ok := v.(string); ok {
+            return s
+        }
+        return ""
+    }
+    if s, ok := doc.(string); ok {
+        return s
+    }
+    if obj, ok := doc.(map[string]interface{}); ok {
+        for _, key := range textKeys {
+            if s, ok := obj[key].(string); ok {
+                return s
+            }
+        }
+    }
+    return ""
+}
+
+// Builtin returns the built-in evaluators for the given options.
+func Builtin(opts Options) ([]Evaluator, error) {
+    evals := []Evaluator{
+        lengthEvaluator{},
+        jsonEvaluator{},
+        refusalEvaluator{},
+        languageEvaluator{},
+        overlapEvaluator{},
+    }
+    if len(opts.Blocklist) > 0 {
+        b, err := newBlocklist(opts.Blocklist)
+        if err != nil {
+            return nil, err
+        }
+        evals = append(evals, b)
+    }
+    return evals, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+    out := make([]*regexp.Regexp, 0, len(patterns))
+    for _, p := range patterns {
+        p = strings.TrimSpace(p)
+        if p == "" {
+            continue
+        }
+        re, err := regexp.Compile("(?i)" + p)
+        if err != nil {
+            return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+        }
+        out = append(out, re)
+    }
+    return out, nil
+}
@@ -0,0 +1,124 @@
+package evaluators
+
+import (
+    "strings"
+    "unicode"
+)
+
+// languageEvaluator detects the language of the completion. Non-Latin
+// scripts are identified by their Unicode script; Latin-script languages by
+// the frequency of common function words. The value is the confidence of the
+// label; "und" is reported when there is too little evidence.
+type languageEvaluator struct{}
+
+func (languageEvaluator) Name() string { return "language" }
+
+// minStopwordHits is the number of function words needed to label a
+// Latin-script text.
+const minStopwordHits = 3
+
+var stopwords = map[string][]string{
+    "en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "for", "with", "you", "this", "was", "be", "on", "not", "have", "what", "which"},
+    "de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "es", "ein", "eine", "zu", "mit", "den", "auf", "für", "sich", "auch", "dem", "wir"},
+    "fr": {"le", "la", "les", "et", "est", "des", "une", "un", "que", "pas", "pour", "dans", "je", "vous", "nous", "sur", "avec", "qui", "du", "ce"},
+    "es": {"el", "los", "las", "y", "es", "que", "una", "un", "por", "para", "con", "del", "se", "no", "como", "pero", "su", "al", "lo", "está"},
+    "it": {"il", "gli", "e", "è", "che", "di", "una", "un", "per", "non", "con", "del", "della", "sono", "come", "ma", "questo", "nel", "alla", "anche"},
+    "pt": {"o", "os", "as", "e", "é", "que", "uma", "um", "para", "com", "não", "do", "da", "em", "por", "mais", "como", "mas", "você", "são"},
+    "nl": {"de", "het", "een", "en", "is", "van", "niet", "dat", "ik", "je", "op", "met", "voor", "zijn", "maar", "ook", "wat", "er", "aan", "dit"},
+}
+
+var stopwordIndex = func() map[string][]string {
+    idx := map[string][]string{}
+    for lang, words := range stopwords {
+        for _, w := range words {
+            idx[w] = append(idx[w], lang)
+        }
+    }
+    return idx
+}()
+
+var scripts = []struct {
+    lang  string
+    table *unicode.RangeTable
+}{
+    {"ja", unicode.Hiragana},
+    {"ja", unicode.Katakana},
+    {"zh", unicode.Han},
+    {"ko", unicode.Hangul},
+    {"ru", unicode.Cyrillic},
+    {"ar", unicode.Arabic},
+    {"el", unicode.Greek},
+    {"he", unicode.Hebrew},
+    {"hi", unicode.Devanagari},
+    {"th", unicode.Thai},
+}
+
+func (languageEvaluator) Evaluate(in Input) []Score {
+    lang, confidence := DetectLanguage(in.Text)
+    return []Score{{Name: "language", Value: confidence, Label: lang}}
+}
+
+// DetectLanguage returns an ISO 639-1 code and a confidence in [0, 1], or
+// "und" with zero confidence.
+func DetectLanguage(text string) (string, float64) {
+    letters := 0
+    byScript := map[string]int{}
+    kana := false
+    for _, r := range text {
+        if !unicode.IsLetter(r) {
+            continue
+        }
+        letters++
+        for _, s := range scripts {
+            if unicode.Is(s.table, r) {
+                byScript[s.lang]++
+                if s.lang == "ja" {
+                    kana = true
+                }
+                break
+            }
+        }
+    }
+    if letters == 0 {
+        return "und", 0
+    }
+
+    // Japanese mixes kana with Han characters.
+    if kana {
+        byScript["ja"] += byScript["zh"]
+        delete(byScript, "zh")
+    }
+    bestScript, bestCount := "", 0
+    for lang, n := range byScript {
+        if n > bestCount {
+            bestScript, bestCount = lang, n
+        }
+    }
+    if float64(bestCount) > 0.5*float64(letters) {
+        return bestScript, float64(bestCount) / float64(letters)
+    }
+
+    hits := map[string]int{}
+    total := 0
+    for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && r != '\''
+    }) {
+        langs := stopwordIndex[word]
+        for _, lang := range langs {
+            hits[lang]++
+        }
+        if len(langs) > 0 {
+            total++
+        }
+    }
+    best, bestHits := "", 0
+    for lang, n := range hits {
+        if n > bestHits || (n == bestHits && lang < best) {
+            best, bestHits = lang, n
+        }
+    }
+    if bestHits < minStopwordHits {
+        return "und", 0
+    }
+    return best, float64(bestHits) / float64(total)
+}
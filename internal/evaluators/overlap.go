@@ -0,0 +1,124 @@
+package evaluators
+
+import (
+    "math"
+    "strings"
+    "unicode"
+)
+
+// overlapEvaluator compares the completion with the reference answer from
+// feedback using sentence-level BLEU-4 and ROUGE-1/ROUGE-L F1.
+type overlapEvaluator struct{}
+
+func (overlapEvaluator) Name() string { return "reference_overlap" }
+
+func (overlapEvaluator) RequiresReference() bool { return true }
+
+func (overlapEvaluator) Evaluate(in Input) []Score {
+    if strings.TrimSpace(in.Reference) == "" {
+        return nil
+    }
+    cand, ref := Tokenize(in.Text), Tokenize(in.Reference)
+    return []Score{
+        {Name: "bleu", Value: BLEU(cand, ref)},
+        {Name: "rouge_1", Value: Rouge1(cand, ref)},
+        {Name: "rouge_l", Value: RougeL(cand, ref)},
+    }
+}
+
+// Tokenize lower-cases text and splits it into letter/digit runs.
+func Tokenize(text string) []string {
+    return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+func ngrams(tokens []string, n int) map[string]int {
+    out := map[string]int{}
+    for i := 0; i+n <= len(tokens); i++ {
+        out[strings.Join(tokens[i:i+n], "\x00")]++
+    }
+    return out
+}
+
+// BLEU is sentence-level BLEU-4 with a brevity penalty. Higher-order
+// precisions use add-one smoothing so short sentences do not collapse to 0.
+func BLEU(cand, ref []string) float64 {
+    if len(cand) == 0 || len(ref) == 0 {
+        return 0
+    }
+    logSum := 0.0
+    for n := 1; n <= 4; n++ {
+        c, r := ngrams(cand, n), ngrams(ref, n)
+        matched, total := 0, 0
+        for g, cnt := range c {
+            total += cnt
+            if rc := r[g]; rc < cnt {
+                matched += rc
+            } else {
+                matched += cnt
+            }
+        }
+        var p float64
+        if n == 1 {
+            if matched == 0 {
+                return 0
+            }
+            p = float64(matched) / float64(total)
+        } else {
+            p = float64(matched+1) / float64(total+1)
+        }
+        logSum += math.Log(p) / 4
+    }
+    bp := 1.0
+    if len(cand) < len(ref) {
+        bp = math.Exp(1 - float64(len(ref))/float64(len(cand)))
+    }
+    return bp * math.Exp(logSum)
+}
+
+// Rouge1 is the unigram overlap F1.
+func Rouge1(cand, ref []string) float64 {
+    c, r := ngrams(cand, 1), ngrams(ref, 1)
+    overlap := 0
+    for g, cnt := range c {
+        if rc := r[g]; rc < cnt {
+            overlap += rc
+        } else {
+            overlap += cnt
+        }
+    }
+    return f1(overlap, len(cand), len(ref))
+}
+
+// RougeL is the longest-common-subsequence F1.
+func RougeL(cand, ref []string) float64 {
+    if len(cand) == 0 || len(ref) == 0 {
+        return 0
+    }
+    prev := make([]int, len(ref)+1)
+    cur := make([]int, len(ref)+1)
+    for i := 1; i <= len(cand); i++ {
+        for j := 1; j <= len(ref); j++ {
+            switch {
+            case cand[i-1] == ref[j-1]:
+                cur[j] = prev[j-1] + 1
+            case prev[j] >= cur[j-1]:
+                cur[j] = prev[j]
+            default:
+                cur[j] = cur[j-1]
+            }
+        }
+        prev, cur = cur, prev
+    }
+    return f1(prev[len(ref)], len(cand), len(ref))
+}
+
+func f1(overlap, candLen, refLen int) float64 {
+    if overlap == 0 || candLen == 0 || refLen == 0 {
+        return 0
+    }
+    p := float64(overlap) / float64(candLen)
+    r := float64(overlap) / float64(refLen)
+    return 2 * p * r / (p + r)
+}
@@ -0,0 +1,184 @@
+package evaluators
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "sync"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/google/uuid"
+)
+
+// ErrQueueFull is returned when an inference or feedback cannot be queued
+// for evaluation; it is dropped rather than slowing down ingest.
+var ErrQueueFull = errors.New("evaluation queue full")
+
+// Runner evaluates inferences in the background. New inferences are scored
+// by every evaluator that does not need a reference; when feedback arrives,
+// the reference evaluators run against it.
+type Runner struct {
+    Repo       repository.EvaluationRepository
+    Inferences repository.InferenceRepository
+    Evaluators []Evaluator
+    Options    Options
+    Workers    int
+
+    queue   chan job
+    pending sync.WaitGroup
+}
+
+type job struct {
+    inference *models.Inference
+    feedback  *models.Feedback
+}
+
+// NewRunner returns a runner with a queue of queueSize jobs. Call Run to
+// start processing.
+func NewRunner(repo repository.EvaluationRepository, infRepo repository.InferenceRepository,
+    evals []Evaluator, opts Options, workers, queueSize int) *Runner {
+    if workers <= 0 {
+        workers = 1
+    }
+    return &Runner{
+        Repo:       repo,
+        Inferences: infRepo,
+        Evaluators: evals,
+        Options:    opts,
+        Workers:    workers,
+        queue:      make(chan job, queueSize),
+    }
+}
+
+// ObserveInference queues a new inference for evaluation.
+func (r *Runner) ObserveInference(ctx context.Context, inf models.Inference) error {
+    return r.enqueue(job{inference: &inf})
+}
+
+// ObserveFeedback queues the reference evaluators for the feedback's
+// inference.
+func (r *Runner) ObserveFeedback(ctx context.Context, fb models.Feedback) error {
+    return r.enqueue(job{feedback: &fb})
+}
+
+func (r *Runner) enqueue(j job) error {
+    r.pending.Add(1)
+    select {
+    case r.queue <- j:
+        return nil
+    default:
+        r.pending.Done()
+        return ErrQueueFull
+    }
+}
+
+// Run processes queued jobs with Workers goroutines until ctx is cancelled.
+// Jobs still queued at that point are dropped.
+func (r *Runner) Run(ctx context.Context) {
+    log.Printf("Starting evaluation runner (%d workers, %d evaluators)\n", r.Workers, len(r.Evaluators))
+    var wg sync.WaitGroup
+    for i := 0; i < r.Workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                select {
+                case <-ctx.Done():
+                    return
+                case j := <-r.queue:
+                    if err := r.process(ctx, j); err != nil && ctx.Err() == nil {
+                        log.Printf("Evaluation error: %v\n", err)
+                    }
+                    r.pending.Done()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    log.Println("Evaluation runner stopped")
+}
+
+// Wait blocks until every queued job has been processed. It only returns
+// while Run is active.
+func (r *Runner) Wait() {
+    r.pending.Wait()
+}
+
+// Drain waits like Wait, but gives up when ctx is done and returns its
+// error; the jobs still queued are then dropped when Run stops. Stop
+// queueing jobs first, e.g. by shutting down the HTTP server.
+func (r *Runner) Drain(ctx context.Context) error {
+    done := make(chan struct{})
+    go func() {
+        r.pending.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+func (r *Runner) process(ctx context.Context, j job) error {
+    if j.inference != nil {
+        in := Input{
+            Inference: *j.inference,
+            Text:      ExtractText(j.inference.OutputData, r.Options.TextPath),
+        }
+        return r.Repo.UpsertScores(ctx, r.Evaluate(in, false))
+    }
+
+    reference := ExtractText(j.feedback.FeedbackData, r.Options.ReferencePath)
+    if reference == "" {
+        return nil
+    }
+    inf, err := r.Inferences.GetInferenceByID(ctx, j.feedback.InferenceID)
+    if err != nil {
+        return fmt.Errorf("load inference %s: %w", j.feedback.InferenceID, err)
+    }
+    in := Input{
+        Inference: *inf,
+        Text:      ExtractText(inf.OutputData, r.Options.TextPath),
+        Reference: reference,
+    }
+    return r.Repo.UpsertScores(ctx, r.Evaluate(in, true))
+}
+
+// Evaluate runs the evaluators synchronously: those needing a reference when
+// withReference is set, the others otherwise. A panicking evaluator is
+// logged and skipped.
+func (r *Runner) Evaluate(in Input, withReference bool) []models.EvaluationScore {
+    var out []models.EvaluationScore
+    for _, e := range r.Evaluators {
+        if requiresReference(e) != withReference {
+            continue
+        }
+        for _, s := range safeEvaluate(e, in) {
+            out = append(out, models.EvaluationScore{
+                ID:           uuid.New().String(),
+                InferenceID:  in.Inference.ID,
+                ModelName:    in.Inference.ModelName,
+                ModelVersion: in.Inference.ModelVersion,
+                Evaluator:    s.Name,
+                Value:        s.Value,
+                Label:        s.Label,
+                Detail:       s.Detail,
+            })
+        }
+    }
+    return out
+}
+
+func safeEvaluate(e Evaluator, in Input) (scores []Score) {
+    defer func() {
+        if p := recover(); p != nil {
+            log.Printf("Evaluator %s panicked on inference %s: %v\n", e.Name(), in.Inference.ID, p)
+            scores = nil
+        }
+    }()
+    return e.Evaluate(in)
+}
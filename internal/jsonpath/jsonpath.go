@@ -0,0 +1,117 @@
+package jsonpath
+
+import (
+    "encoding/json"
+    "math"
+    "strconv"
+    "strings"
+)
+
+// Decode parses a stored JSON payload (input_data, output_data, ...) into
+// generic Go values. Numbers are decoded as float64.
+func Decode(raw string) (interface{}, error) {
+    if raw == "" {
+        return nil, nil
+    }
+    var v interface{}
+    if err := json.Unmarshal([]byte(raw), &v); err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+// Lookup resolves a dotted path like "user.address.country" or "items.0.id"
+// against a decoded JSON document. The second return value is false when any
+// segment of the path does not exist.
+func Lookup(doc interface{}, path string) (interface{}, bool) {
+    path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+    if path == "" {
+        return doc, true
+    }
+    cur := doc
+    for _, seg := range strings.Split(path, ".") {
+        switch node := cur.(type) {
+        case map[string]interface{}:
+            v, ok := node[seg]
+            if !ok {
+                return nil, false
+            }
+            cur = v
+        case []interface{}:
+            idx, err := strconv.Atoi(seg)
+            if err != nil || idx < 0 || idx >= len(node) {
+                return nil, false
+            }
+            cur = node[idx]
+        default:
+            return nil, false
+        }
+    }
+    return cur, true
+}
+
+// Normalize renders a scalar JSON value as a string so that values coming
+// from different payloads can be compared (e.g. 1 and 1.0 both become "1").
+func Normalize(v interface{}) string {
+    switch val := v.(type) {
+    case nil:
+        return ""
+    case string:
+        return val
+    case float64:
+        return strconv.FormatFloat(val, 'f', -1, 64)
+    case bool:
+        return strconv.FormatBool(val)
+    case json.Number:
+        return val.String()
+    default:
+        b, _ := json.Marshal(val)
+        return string(b)
+    }
+}
+
+// Float converts a scalar JSON value to a float64. Numeric strings are
+// accepted as well since many producers serialise scores as strings.
+func Float(v interface{}) (float64, bool) {
+    switch val := v.(type) {
+    case float64:
+        return val, true
+    case bool:
+        if val {
+            return 1, true
+        }
+        return 0, true
+    case string:
+        f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+        return f, err == nil && finite(f)
+    case json.Number:
+        f, err := val.Float64()
+        return f, err == nil && finite(f)
+    }
+    return 0, false
+}
+
+// finite rejects the "NaN" and "Inf" strings ParseFloat accepts; they
+// break sorting and comparisons downstream.
+func finite(f float64) bool {
+    return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// TypeOf returns the JSON type name of a decoded value: "null", "number",
+// "string", "boolean", "array" or "object".
+func TypeOf(v interface{}) string {
+    switch v.(type) {
+    case nil:
+        return "null"
+    case float64, json.Number:
+        return "number"
+    case string:
+        return "string"
+    case bool:
+        return "boolean"
+    case []interface{}:
+        return "array"
+    default:
+        return "object"
+    }
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+    "fmt"
+    "sort"
+    "time"
+)
+
+// Bucket is the set of records of one model version inside one time bucket.
+type Bucket struct {
+    Version string
+    Start   time.Time
+    Records []Record
+}
+
+// Bucketize groups records by model version and, when interval is non-zero,
+// by created_at truncated to the interval. Buckets are ordered by version
+// and then by time.
+func Bucketize(recs []Record, interval time.Duration) []Bucket {
+    type key struct {
+        version string
+        start   time.Time
+    }
+    groups := map[key][]Record{}
+    for _, r := range recs {
+        k := key{version: r.Version}
+        if interval > 0 {
+            k.start = r.CreatedAt.UTC().Truncate(interval)
+        }
+        groups[k] = append(groups[k], r)
+    }
+
+    keys := make([]key, 0, len(groups))
+    for k := range groups {
+        keys = append(keys, k)
+    }
+    sort.Slice(keys, func(i, j int) bool {
+        if keys[i].version != keys[j].version {
+            return keys[i].version < keys[j].version
+        }
+        return keys[i].start.Before(keys[j].start)
+    })
+
+    out := make([]Bucket, 0, len(keys))
+    for _, k := range keys {
+        out = append(out, Bucket{Version: k.version, Start: k.start, Records: groups[k]})
+    }
+    return out
+}
+
+// ParseInterval maps the interval query values used by the API to durations.
+func ParseInterval(s string) (time.Duration, error) {
+    switch s {
+    case "":
+        return 0, nil
+    case "hour":
+        return time.Hour, nil
+    case "day":
+        return 24 * time.Hour, nil
+    case "week":
+        return 7 * 24 * time.Hour, nil
+    }
+    return 0, fmt.Errorf("invalid interval %q (want hour, day or week)", s)
+}
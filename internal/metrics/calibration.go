@@ -0,0 +1,153 @@
+package metrics
+
+import (
+    "math"
+    "time"
+)
+
+// logLossEpsilon clips probabilities so log loss stays finite.
+const logLossEpsilon = 1e-15
+
+// ReliabilityBin is one point of a reliability diagram: the mean predicted
+// probability against the observed positive rate for scores in [Lower, Upper).
+type ReliabilityBin struct {
+    Lower        float64  `json:"lower"`
+    Upper        float64  `json:"upper"`
+    Count        int      `json:"count"`
+    MeanScore    *float64 `json:"mean_score"`
+    PositiveRate *float64 `json:"positive_rate"`
+}
+
+// CalibrationReport holds the calibration of one model version and bucket.
+type CalibrationReport struct {
+    ModelVersion string           `json:"model_version"`
+    BucketStart  time.Time        `json:"bucket_start"`
+    Count        int              `json:"count"`
+    ECE          float64          `json:"ece"`
+    Brier        float64          `json:"brier"`
+    LogLoss      float64          `json:"log_loss"`
+    Bins         []ReliabilityBin `json:"bins"`
+    Alert        bool             `json:"alert"`
+}
+
+// CalibrationOptions configures Calibration.
+type CalibrationOptions struct {
+    Bins     int
+    Interval time.Duration
+    // ECEThreshold raises Alert on reports whose ECE exceeds it. Zero
+    // disables alerting.
+    ECEThreshold float64
+}
+
+// scored returns the records with both a score in [0, 1] and a label.
+func scored(recs []Record) []Record {
+    out := make([]Record, 0, len(recs))
+    for _, r := range recs {
+        if r.HasScore && r.HasLabel && r.Score >= 0 && r.Score <= 1 {
+            out = append(out, r)
+        }
+    }
+    return out
+}
+
+// scoredOutcome returns the 0/1 outcome of a record usable for calibration.
+func scoredOutcome(r Record, opts Options) (float64, bool) {
+    if !r.HasScore || !r.HasLabel || r.Score < 0 || r.Score > 1 {
+        return 0, false
+    }
+    if opts.IsPositive(r.Label) {
+        return 1, true
+    }
+    return 0, true
+}
+
+// Calibration computes a reliability diagram with equal-width bins, Expected
+// Calibration Error, Brier score and log loss per model version and time
+// bucket. Scores are read as the probability of the positive class.
+func Calibration(recs []Record, opts Options, co CalibrationOptions) []CalibrationReport {
+    if co.Bins < 1 {
+        co.Bins = 10
+    }
+    var reports []CalibrationReport
+    for _, b := range Bucketize(scored(recs), co.Interval) {
+        rep := calibrationReport(b.Records, opts, co.Bins)
+        rep.ModelVersion = b.Version
+        rep.BucketStart = b.Start
+        rep.Alert = co.ECEThreshold > 0 && rep.ECE > co.ECEThreshold
+        reports = append(reports, rep)
+    }
+    return reports
+}
+
+func calibrationReport(recs []Record, opts Options, bins int) CalibrationReport {
+    rep := CalibrationReport{Count: len(recs), Bins: make([]ReliabilityBin, bins)}
+    sumScore := make([]float64, bins)
+    positives := make([]int, bins)
+    for i := range rep.Bins {
+        rep.Bins[i].Lower = float64(i) / float64(bins)
+        rep.Bins[i].Upper = float64(i+1) / float64(bins)
+    }
+
+    for _, r := range recs {
+        y := 0.0
+        if opts.IsPositive(r.Label) {
+            y = 1
+        }
+        p := r.Score
+        rep.Brier += (p - y) * (p - y)
+        pc := math.Min(math.Max(p, logLossEpsilon), 1-logLossEpsilon)
+        rep.LogLoss -= y*math.Log(pc) + (1-y)*math.Log(1-pc)
+
+        idx := int(p * float64(bins))
+        if idx == bins {
+            idx--
+        }
+        rep.Bins[idx].Count++
+        sumScore[idx] += p
+        positives[idx] += int(y)
+    }
+    if len(recs) == 0 {
+        return rep
+    }
+    n := float64(len(recs))
+    rep.Brier /= n
+    rep.LogLoss /= n
+
+    for i := range rep.Bins {
+        c := rep.Bins[i].Count
+        if c == 0 {
+            continue
+        }
+        mean := sumScore[i] / float64(c)
+        rate := float64(positives[i]) / float64(c)
+        rep.Bins[i].MeanScore, rep.Bins[i].PositiveRate = &mean, &rate
+        rep.ECE += float64(c) / n * math.Abs(mean-rate)
+    }
+    return rep
+}
+
+func init() {
+    // Calibration metrics over the whole record set, so they can be used in
+    // slices and comparisons like any other metric.
+    calibrationMetric := func(pick func(CalibrationReport) float64) func([]Record, Options) (float64, error) {
+        return func(recs []Record, opts Options) (float64, error) {
+            recs = scored(recs)
+            if len(recs) == 0 {
+                return 0, ErrNoData
+            }
+            return pick(calibrationReport(recs, opts, 10)), nil
+        }
+    }
+    Register(Metric{Name: "ece", Compute: calibrationMetric(func(r CalibrationReport) float64 { return r.ECE })})
+    Register(Metric{Name: "brier", Compute: calibrationMetric(func(r CalibrationReport) float64 { return r.Brier }),
+        PerRecord: func(r Record, opts Options) (float64, bool) {
+            y, ok := scoredOutcome(r, opts)
+            return (r.Score - y) * (r.Score - y), ok
+        }})
+    Register(Metric{Name: "log_loss", Compute: calibrationMetric(func(r CalibrationReport) float64 { return r.LogLoss }),
+        PerRecord: func(r Record, opts Options) (float64, bool) {
+            y, ok := scoredOutcome(r, opts)
+            p := math.Min(math.Max(r.Score, logLossEpsilon), 1-logLossEpsilon)
+            return -(y*math.Log(p) + (1-y)*math.Log(1-p)), ok
+        }})
+}
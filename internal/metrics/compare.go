@@ -0,0 +1,245 @@
+package metrics
+
+import (
+    "math"
+    "math/rand"
+    "sort"
+)
+
+// Significance tests used by Compare.
+const (
+    TestTwoProportionZ = "two_proportion_z"
+    TestMannWhitneyU   = "mann_whitney_u"
+    TestBootstrap      = "bootstrap"
+)
+
+// ArmResult is the metric value of one side of a comparison.
+type ArmResult struct {
+    Version string  `json:"version"`
+    Value   float64 `json:"value"`
+    N       int     `json:"n"`
+}
+
+// Comparison is the result of comparing version B against version A.
+// Delta is B - A; CI is its 95% confidence interval.
+type Comparison struct {
+    Metric      string    `json:"metric"`
+    Test        string    `json:"test"`
+    A           ArmResult `json:"a"`
+    B           ArmResult `json:"b"`
+    Delta       float64   `json:"delta"`
+    CI          Interval  `json:"ci"`
+    PValue      float64   `json:"p_value"`
+    Significant bool      `json:"significant"`
+    // Better is "a", "b" or "" when the difference is not significant.
+    Better      string    `json:"better"`
+}
+
+// CompareOptions configures Compare.
+type CompareOptions struct {
+    Alpha     float64
+    Resamples int
+    Seed      int64
+}
+
+// Compare tests whether metric m differs between the records of version A
+// and version B. Proportion metrics use a two-proportion z-test, per-record
+// mean metrics a Mann–Whitney U test (with a Welch interval for the
+// difference of means), and all other metrics a percentile bootstrap.
+func Compare(m Metric, a, b []Record, versionA, versionB string, opts Options, co CompareOptions) (*Comparison, error) {
+    if co.Alpha <= 0 {
+        co.Alpha = 0.05
+    }
+    if co.Resamples <= 0 {
+        co.Resamples = 1000
+    }
+
+    valueA, err := m.Compute(a, opts)
+    if err != nil {
+        return nil, err
+    }
+    valueB, err := m.Compute(b, opts)
+    if err != nil {
+        return nil, err
+    }
+    c := &Comparison{
+        Metric: m.Name,
+        A:      ArmResult{Version: versionA, Value: valueA},
+        B:      ArmResult{Version: versionB, Value: valueB},
+        Delta:  valueB - valueA,
+    }
+
+    switch {
+    case m.Proportion != nil:
+        c.Test = TestTwoProportionZ
+        sa, na := m.Proportion(a, opts)
+        sb, nb := m.Proportion(b, opts)
+        c.A.N, c.B.N = na, nb
+        c.PValue = twoProportionZ(sa, na, sb, nb)
+        c.CI = DiffInterval(valueB, nb, valueA, na)
+    case m.PerRecord != nil:
+        c.Test = TestMannWhitneyU
+        xa, xb := perRecord(m, a, opts), perRecord(m, b, opts)
+        c.A.N, c.B.N = len(xa), len(xb)
+        c.PValue = MannWhitneyU(xa, xb)
+        c.CI = welchInterval(xa, xb)
+    default:
+        c.Test = TestBootstrap
+        c.A.N, c.B.N = len(labeled(a)), len(labeled(b))
+        c.CI, c.PValue = bootstrap(m, a, b, opts, co)
+    }
+
+    c.Significant = c.PValue < co.Alpha
+    if c.Significant {
+        c.Better = "a"
+        if (c.Delta > 0) == m.HigherIsBetter {
+            c.Better = "b"
+        }
+    }
+    return c, nil
+}
+
+func perRecord(m Metric, recs []Record, opts Options) []float64 {
+    var out []float64
+    for _, r := range recs {
+        if v, ok := m.PerRecord(r, opts); ok {
+            out = append(out, v)
+        }
+    }
+    return out
+}
+
+// twoProportionZ returns the two-sided p-value of the pooled z-test for
+// equal proportions.
+func twoProportionZ(s1, n1, s2, n2 int) float64 {
+    if n1 == 0 || n2 == 0 {
+        return 1
+    }
+    pooled := float64(s1+s2) / float64(n1+n2)
+    se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+    if se == 0 {
+        return 1
+    }
+    z := (float64(s2)/float64(n2) - float64(s1)/float64(n1)) / se
+    return 2 * (1 - NormalCDF(math.Abs(z)))
+}
+
+// MannWhitneyU returns the two-sided p-value of the Mann–Whitney U test using
+// the normal approximation with tie correction.
+func MannWhitneyU(x, y []float64) float64 {
+    n1, n2 := len(x), len(y)
+    if n1 == 0 || n2 == 0 {
+        return 1
+    }
+    type obs struct {
+        v     float64
+        fromX bool
+    }
+    all := make([]obs, 0, n1+n2)
+    for _, v := range x {
+        all = append(all, obs{v, true})
+    }
+    for _, v := range y {
+        all = append(all, obs{v, false})
+    }
+    sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+    // Average ranks over ties and accumulate the tie correction term.
+    rankSumX, tieTerm := 0.0, 0.0
+    for i := 0; i < len(all); {
+        j := i
+        for j < len(all) && all[j].v == all[i].v {
+            j++
+        }
+        rank := float64(i+j+1) / 2
+        for k := i; k < j; k++ {
+            if all[k].fromX {
+                rankSumX += rank
+            }
+        }
+        t := float64(j - i)
+        tieTerm += t*t*t - t
+        i = j
+    }
+
+    fn1, fn2 := float64(n1), float64(n2)
+    n := fn1 + fn2
+    u := rankSumX - fn1*(fn1+1)/2
+    mean := fn1 * fn2 / 2
+    variance := fn1 * fn2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+    if variance <= 0 {
+        return 1
+    }
+    z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance) // continuity correction
+    if z < 0 {
+        z = 0
+    }
+    return 2 * (1 - NormalCDF(z))
+}
+
+func meanVar(x []float64) (mean, variance float64) {
+    for _, v := range x {
+        mean += v
+    }
+    mean /= float64(len(x))
+    if len(x) < 2 {
+        return mean, 0
+    }
+    for _, v := range x {
+        variance += (v - mean) * (v - mean)
+    }
+    return mean, variance / float64(len(x)-1)
+}
+
+// welchInterval is the normal-approximation 95% interval for mean(y) - mean(x).
+func welchInterval(x, y []float64) Interval {
+    if len(x) == 0 || len(y) == 0 {
+        return Interval{}
+    }
+    mx, vx := meanVar(x)
+    my, vy := meanVar(y)
+    d := my - mx
+    se := math.Sqrt(vx/float64(len(x)) + vy/float64(len(y)))
+    return Interval{d - z95*se, d + z95*se}
+}
+
+// bootstrap resamples both arms with replacement and returns the percentile
+// interval of B - A and a two-sided p-value for a zero difference.
+func bootstrap(m Metric, a, b []Record, opts Options, co CompareOptions) (Interval, float64) {
+    rng := rand.New(rand.NewSource(co.Seed))
+    resample := func(recs []Record, buf []Record) []Record {
+        for i := range buf {
+            buf[i] = recs[rng.Intn(len(recs))]
+        }
+        return buf
+    }
+    bufA, bufB := make([]Record, len(a)), make([]Record, len(b))
+    var deltas []float64
+    for i := 0; i < co.Resamples; i++ {
+        va, errA := m.Compute(resample(a, bufA), opts)
+        vb, errB := m.Compute(resample(b, bufB), opts)
+        if errA != nil || errB != nil {
+            continue
+        }
+        deltas = append(deltas, vb-va)
+    }
+    if len(deltas) == 0 {
+        return Interval{}, 1
+    }
+    sort.Float64s(deltas)
+    pick := func(q float64) float64 {
+        return deltas[int(q*float64(len(deltas)-1))]
+    }
+
+    below, above := 0, 0
+    for _, d := range deltas {
+        if d <= 0 {
+            below++
+        }
+        if d >= 0 {
+            above++
+        }
+    }
+    p := 2 * math.Min(float64(below), float64(above)) / float64(len(deltas))
+    return Interval{pick(0.025), pick(0.975)}, math.Min(p, 1)
+}
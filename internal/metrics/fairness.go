@@ -0,0 +1,211 @@
+package metrics
+
+import (
+    "sort"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+)
+
+// GroupStats are the per-group rates that the fairness metrics compare.
+type GroupStats struct {
+    Group           string    `json:"group"`
+    Count           int       `json:"count"`
+    Labeled         int       `json:"labeled"`
+    SelectionRate   float64   `json:"selection_rate"`
+    SelectionRateCI Interval  `json:"selection_rate_ci"`
+    TPR             *float64  `json:"true_positive_rate,omitempty"`
+    TPRCI           *Interval `json:"true_positive_rate_ci,omitempty"`
+    FPR             *float64  `json:"false_positive_rate,omitempty"`
+    FPRCI           *Interval `json:"false_positive_rate_ci,omitempty"`
+
+    selected, positives, truePositives, negatives, falsePositives int
+}
+
+// FairnessMetric is one between-group comparison with its 95% interval.
+// Groups names the two groups the value was computed from.
+type FairnessMetric struct {
+    Value  float64   `json:"value"`
+    CI     *Interval `json:"ci,omitempty"`
+    Groups [2]string `json:"groups"`
+}
+
+// FairnessReport summarises one (model version, time bucket).
+type FairnessReport struct {
+    ModelVersion string                     `json:"model_version"`
+    BucketStart  time.Time                  `json:"bucket_start"`
+    Groups       []GroupStats               `json:"groups"`
+    Missing      int                        `json:"missing_attribute"`
+    Metrics      map[string]*FairnessMetric `json:"metrics"`
+}
+
+// FairnessOptions configures the fairness computation.
+type FairnessOptions struct {
+    // Attribute is the path of the protected attribute in input_data.
+    Attribute string
+    // Interval buckets the report over time; zero means one bucket.
+    Interval time.Duration
+    // MinCount excludes groups with fewer records.
+    MinCount int
+}
+
+// Fairness computes demographic parity difference, equal opportunity
+// difference, equalized odds difference and disparate impact ratio between
+// the groups of the protected attribute, per model version and time bucket.
+//
+// Differences are reported as the largest gap between any two groups, with a
+// Wald interval for the two groups that produce it; the disparate impact
+// ratio is the lowest selection rate over the highest, with a log-method
+// interval. Selection rates use all records, true/false positive rates only
+// the labeled ones.
+func Fairness(recs []Record, opts Options, fo FairnessOptions) []FairnessReport {
+    var reports []FairnessReport
+    for _, b := range Bucketize(recs, fo.Interval) {
+        rep := fairnessReport(b.Records, opts, fo)
+        rep.ModelVersion = b.Version
+        rep.BucketStart = b.Start
+        reports = append(reports, rep)
+    }
+    return reports
+}
+
+func fairnessReport(recs []Record, opts Options, fo FairnessOptions) FairnessReport {
+    rep := FairnessReport{Metrics: map[string]*FairnessMetric{}}
+    byGroup := map[string]*GroupStats{}
+    for _, r := range recs {
+        v, ok := jsonpath.Lookup(r.Input, fo.Attribute)
+        if !ok || v == nil {
+            rep.Missing++
+            continue
+        }
+        name := jsonpath.Normalize(v)
+        g := byGroup[name]
+        if g == nil {
+            g = &GroupStats{Group: name}
+            byGroup[name] = g
+        }
+        g.Count++
+        pred := opts.IsPositive(r.Prediction)
+        if pred {
+            g.selected++
+        }
+        if !r.HasLabel {
+            continue
+        }
+        g.Labeled++
+        if opts.IsPositive(r.Label) {
+            g.positives++
+            if pred {
+                g.truePositives++
+            }
+        } else {
+            g.negatives++
+            if pred {
+                g.falsePositives++
+            }
+        }
+    }
+
+    var groups []*GroupStats
+    for _, g := range byGroup {
+        if g.Count < fo.MinCount {
+            continue
+        }
+        g.SelectionRate = float64(g.selected) / float64(g.Count)
+        g.SelectionRateCI = WilsonInterval(g.selected, g.Count)
+        if g.positives > 0 {
+            tpr := float64(g.truePositives) / float64(g.positives)
+            ci := WilsonInterval(g.truePositives, g.positives)
+            g.TPR, g.TPRCI = &tpr, &ci
+        }
+        if g.negatives > 0 {
+            fpr := float64(g.falsePositives) / float64(g.negatives)
+            ci := WilsonInterval(g.falsePositives, g.negatives)
+            g.FPR, g.FPRCI = &fpr, &ci
+        }
+        groups = append(groups, g)
+    }
+    sort.Slice(groups, func(i, j int) bool { return groups[i].Group < groups[j].Group })
+    for _, g := range groups {
+        rep.Groups = append(rep.Groups, *g)
+    }
+    if len(groups) < 2 {
+        return rep
+    }
+
+    selection := func(g *GroupStats) (float64, int, bool) { return g.SelectionRate, g.Count, true }
+    tpr := func(g *GroupStats) (float64, int, bool) {
+        if g.TPR == nil {
+            return 0, 0, false
+        }
+        return *g.TPR, g.positives, true
+    }
+    fpr := func(g *GroupStats) (float64, int, bool) {
+        if g.FPR == nil {
+            return 0, 0, false
+        }
+        return *g.FPR, g.negatives, true
+    }
+
+    if dp := maxGap(groups, selection); dp != nil {
+        rep.Metrics["demographic_parity_difference"] = dp
+    }
+    eo := maxGap(groups, tpr)
+    if eo != nil {
+        rep.Metrics["equal_opportunity_difference"] = eo
+    }
+    if fp := maxGap(groups, fpr); eo != nil && fp != nil {
+        odds := eo
+        if fp.Value > eo.Value {
+            odds = fp
+        }
+        rep.Metrics["equalized_odds_difference"] = &FairnessMetric{Value: odds.Value, CI: odds.CI, Groups: odds.Groups}
+    }
+
+    lo, hi := extremes(groups, selection)
+    if hi != nil && hi.SelectionRate > 0 {
+        di := &FairnessMetric{
+            Value:  lo.SelectionRate / hi.SelectionRate,
+            Groups: [2]string{lo.Group, hi.Group},
+        }
+        if ci, ok := RatioInterval(lo.SelectionRate, lo.Count, hi.SelectionRate, hi.Count); ok {
+            di.CI = &ci
+        }
+        rep.Metrics["disparate_impact_ratio"] = di
+    }
+    return rep
+}
+
+type rateFunc func(g *GroupStats) (rate float64, n int, ok bool)
+
+// extremes returns the groups with the lowest and highest rate.
+func extremes(groups []*GroupStats, rate rateFunc) (lo, hi *GroupStats) {
+    for _, g := range groups {
+        r, _, ok := rate(g)
+        if !ok {
+            continue
+        }
+        if lo == nil {
+            lo, hi = g, g
+            continue
+        }
+        if lr, _, _ := rate(lo); r < lr {
+            lo = g
+        }
+        if hr, _, _ := rate(hi); r >= hr {
+            hi = g
+        }
+    }
+    return lo, hi
+}
+
+func maxGap(groups []*GroupStats, rate rateFunc) *FairnessMetric {
+    lo, hi := extremes(groups, rate)
+    if lo == nil || lo == hi {
+        return nil
+    }
+    pLo, nLo, _ := rate(lo)
+    pHi, nHi, _ := rate(hi)
+    ci := DiffInterval(pHi, nHi, pLo, nLo)
+    return &FairnessMetric{Value: pHi - pLo, CI: &ci, Groups: [2]string{hi.Group, lo.Group}}
+}
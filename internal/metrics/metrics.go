@@ -0,0 +1,306 @@
+package metrics
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+var (
+    ErrUnknownMetric = errors.New("unknown metric")
+    ErrNoData        = errors.New("no usable records")
+)
+
+// Record is one inference reduced to what the metric functions need.
+type Record struct {
+    Version    string
+    CreatedAt  time.Time
+    Prediction interface{}
+    Label      interface{}
+    Score      float64
+    HasScore   bool
+    HasLabel   bool
+    Input      interface{}
+    Tags       interface{}
+}
+
+// Extractor knows where predictions, scores and labels live inside the
+// stored JSON payloads.
+type Extractor struct {
+    PredictionPath string // path in output_data
+    ScorePath      string // path in output_data
+    LabelPath      string // path in feedback_data
+}
+
+// DefaultExtractor matches the payload layout described in the README.
+var DefaultExtractor = Extractor{
+    PredictionPath: "prediction",
+    ScorePath:      "score",
+    LabelPath:      "label",
+}
+
+// Extract converts labeled inferences into records. Rows whose payloads are
+// not valid JSON are skipped.
+func (e Extractor) Extract(rows []models.LabeledInference) []Record {
+    out := make([]Record, 0, len(rows))
+    for _, row := range rows {
+        rec, err := e.ExtractOne(row)
+        if err != nil {
+            continue
+        }
+        out = append(out, rec)
+    }
+    return out
+}
+
+// ExtractOne converts a single labeled inference into a record.
+func (e Extractor) ExtractOne(row models.LabeledInference) (Record, error) {
+    rec := Record{Version: row.ModelVersion, CreatedAt: row.CreatedAt}
+    input, err := jsonpath.Decode(row.InputData)
+    if err != nil {
+        return rec, fmt.Errorf("input_data: %w", err)
+    }
+    output, err := jsonpath.Decode(row.OutputData)
+    if err != nil {
+        return rec, fmt.Errorf("output_data: %w", err)
+    }
+    tags, err := jsonpath.Decode(row.Tags)
+    if err != nil {
+        return rec, fmt.Errorf("tags: %w", err)
+    }
+    rec.Input = input
+    rec.Tags = tags
+    rec.Prediction, _ = jsonpath.Lookup(output, e.PredictionPath)
+    if v, ok := jsonpath.Lookup(output, e.ScorePath); ok {
+        rec.Score, rec.HasScore = jsonpath.Float(v)
+    }
+    if row.FeedbackData != "" {
+        feedback, err := jsonpath.Decode(row.FeedbackData)
+        if err != nil {
+            return rec, fmt.Errorf("feedback_data: %w", err)
+        }
+        rec.Label, rec.HasLabel = jsonpath.Lookup(feedback, e.LabelPath)
+    }
+    return rec, nil
+}
+
+// Options tune how a metric interprets records.
+type Options struct {
+    // Positive is the label value treated as the positive class for binary
+    // metrics such as precision and recall.
+    Positive string
+}
+
+// DefaultOptions treats "1"/true as the positive class.
+var DefaultOptions = Options{Positive: "1"}
+
+// IsPositive reports whether v equals the configured positive class.
+// "1" and "true" are treated as aliases of each other.
+func (o Options) IsPositive(v interface{}) bool {
+    n := jsonpath.Normalize(v)
+    if n == o.Positive {
+        return true
+    }
+    truthy := func(s string) bool { return s == "1" || s == "true" }
+    return truthy(o.Positive) && truthy(n)
+}
+
+// Metric is a named performance metric over a set of records.
+//
+// Proportion is set for metrics that are a share of successes among trials
+// (accuracy, precision, ...), PerRecord for metrics that are the mean of a
+// per-record value (mae, brier, ...). Compare uses them to pick a
+// significance test; metrics with neither are compared by bootstrap.
+type Metric struct {
+    Name           string
+    HigherIsBetter bool
+    Compute        func(recs []Record, opts Options) (float64, error)
+    Proportion     func(recs []Record, opts Options) (successes, trials int)
+    PerRecord      func(r Record, opts Options) (float64, bool)
+}
+
+var registry = map[string]Metric{}
+
+// Register adds a metric to the registry. It panics on duplicate names since
+// registration happens at init time.
+func Register(m Metric) {
+    if _, exists := registry[m.Name]; exists {
+        panic("metrics: duplicate metric " + m.Name)
+    }
+    registry[m.Name] = m
+}
+
+// Lookup returns the metric registered under name.
+func Lookup(name string) (Metric, error) {
+    m, ok := registry[name]
+    if !ok {
+        return Metric{}, fmt.Errorf("%w: %q", ErrUnknownMetric, name)
+    }
+    return m, nil
+}
+
+// Names lists the registered metrics in alphabetical order.
+func Names() []string {
+    names := make([]string, 0, len(registry))
+    for name := range registry {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func init() {
+    Register(Metric{Name: "accuracy", HigherIsBetter: true, Compute: accuracy,
+        Proportion: func(recs []Record, _ Options) (int, int) {
+            correct, n := correctCount(recs)
+            return correct, n
+        }})
+    Register(Metric{Name: "error_rate", Compute: func(recs []Record, opts Options) (float64, error) {
+        acc, err := accuracy(recs, opts)
+        return 1 - acc, err
+    }, Proportion: func(recs []Record, _ Options) (int, int) {
+        correct, n := correctCount(recs)
+        return n - correct, n
+    }})
+    Register(Metric{Name: "precision", HigherIsBetter: true, Compute: precision,
+        Proportion: func(recs []Record, opts Options) (int, int) {
+            c := NewConfusion(recs, opts)
+            return c.TP, c.TP + c.FP
+        }})
+    Register(Metric{Name: "recall", HigherIsBetter: true, Compute: recall,
+        Proportion: func(recs []Record, opts Options) (int, int) {
+            c := NewConfusion(recs, opts)
+            return c.TP, c.TP + c.FN
+        }})
+    Register(Metric{Name: "f1", HigherIsBetter: true, Compute: f1})
+    Register(Metric{Name: "mae", Compute: mae, PerRecord: absError})
+    Register(Metric{Name: "rmse", Compute: rmse})
+}
+
+func labeled(recs []Record) []Record {
+    out := make([]Record, 0, len(recs))
+    for _, r := range recs {
+        if r.HasLabel {
+            out = append(out, r)
+        }
+    }
+    return out
+}
+
+func correctCount(recs []Record) (correct, n int) {
+    for _, r := range labeled(recs) {
+        n++
+        if jsonpath.Normalize(r.Prediction) == jsonpath.Normalize(r.Label) {
+            correct++
+        }
+    }
+    return correct, n
+}
+
+func accuracy(recs []Record, _ Options) (float64, error) {
+    correct, n := correctCount(recs)
+    if n == 0 {
+        return 0, ErrNoData
+    }
+    return float64(correct) / float64(n), nil
+}
+
+// Confusion holds binary confusion matrix counts.
+type Confusion struct {
+    TP, FP, TN, FN int
+}
+
+// NewConfusion counts the binary outcomes of the labeled records.
+func NewConfusion(recs []Record, opts Options) Confusion {
+    var c Confusion
+    for _, r := range labeled(recs) {
+        pred, actual := opts.IsPositive(r.Prediction), opts.IsPositive(r.Label)
+        switch {
+        case pred && actual:
+            c.TP++
+        case pred && !actual:
+            c.FP++
+        case !pred && actual:
+            c.FN++
+        default:
+            c.TN++
+        }
+    }
+    return c
+}
+
+func precision(recs []Record, opts Options) (float64, error) {
+    c := NewConfusion(recs, opts)
+    if c.TP+c.FP == 0 {
+        return 0, ErrNoData
+    }
+    return float64(c.TP) / float64(c.TP+c.FP), nil
+}
+
+func recall(recs []Record, opts Options) (float64, error) {
+    c := NewConfusion(recs, opts)
+    if c.TP+c.FN == 0 {
+        return 0, ErrNoData
+    }
+    return float64(c.TP) / float64(c.TP+c.FN), nil
+}
+
+func f1(recs []Record, opts Options) (float64, error) {
+    c := NewConfusion(recs, opts)
+    if 2*c.TP+c.FP+c.FN == 0 {
+        return 0, ErrNoData
+    }
+    return 2 * float64(c.TP) / float64(2*c.TP+c.FP+c.FN), nil
+}
+
+// absError returns |prediction - label| when both are numeric.
+func absError(r Record, _ Options) (float64, bool) {
+    if !r.HasLabel {
+        return 0, false
+    }
+    p, ok1 := jsonpath.Float(r.Prediction)
+    l, ok2 := jsonpath.Float(r.Label)
+    if !ok1 || !ok2 {
+        return 0, false
+    }
+    return math.Abs(p - l), true
+}
+
+func absErrors(recs []Record) []float64 {
+    var out []float64
+    for _, r := range recs {
+        if e, ok := absError(r, Options{}); ok {
+            out = append(out, e)
+        }
+    }
+    return out
+}
+
+func mae(recs []Record, _ Options) (float64, error) {
+    errs := absErrors(recs)
+    if len(errs) == 0 {
+        return 0, ErrNoData
+    }
+    sum := 0.0
+    for _, e := range errs {
+        sum += e
+    }
+    return sum / float64(len(errs)), nil
+}
+
+func rmse(recs []Record, _ Options) (float64, error) {
+    errs := absErrors(recs)
+    if len(errs) == 0 {
+        return 0, ErrNoData
+    }
+    sum := 0.0
+    for _, e := range errs {
+        sum += e * e
+    }
+    return math.Sqrt(sum / float64(len(errs))), nil
+}
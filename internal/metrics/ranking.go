@@ -0,0 +1,239 @@
+package metrics
+
+import (
+    "math"
+    "sort"
+)
+
+// ROCPoint is one threshold on the ROC curve.
+type ROCPoint struct {
+    Threshold float64 `json:"threshold"`
+    FPR       float64 `json:"fpr"`
+    TPR       float64 `json:"tpr"`
+}
+
+// PRPoint is one threshold on the precision/recall curve.
+type PRPoint struct {
+    Threshold float64 `json:"threshold"`
+    Recall    float64 `json:"recall"`
+    Precision float64 `json:"precision"`
+}
+
+// GainPoint is one quantile of the cumulative gain / lift chart, records
+// ordered by descending score.
+type GainPoint struct {
+    Population float64 `json:"population"` // cumulative share of records
+    Gain       float64 `json:"gain"`       // cumulative share of positives captured
+    Lift       float64 `json:"lift"`       // cumulative lift over random
+    BinLift    float64 `json:"bin_lift"`   // lift of this quantile alone
+}
+
+// RankingReport holds threshold-independent metrics and their curves.
+type RankingReport struct {
+    ModelVersion string          `json:"model_version"`
+    Count        int             `json:"count"`
+    Positives    int             `json:"positives"`
+    ROCAUC       float64         `json:"roc_auc"`
+    PRAUC        float64         `json:"pr_auc"`
+    KS           float64         `json:"ks"`
+    KSThreshold  float64         `json:"ks_threshold"`
+    PrecisionAtK map[int]float64 `json:"precision_at_k"`
+    ROC          []ROCPoint      `json:"roc_curve"`
+    PR           []PRPoint       `json:"pr_curve"`
+    Gain         []GainPoint     `json:"gain_curve"`
+}
+
+// RankingOptions configures Ranking.
+type RankingOptions struct {
+    K []int
+    // Bins is the number of quantiles of the gain/lift chart.
+    Bins int
+    // MaxPoints caps the number of ROC and PR points returned.
+    MaxPoints int
+}
+
+type scoredLabel struct {
+    score    float64
+    positive bool
+}
+
+func scoredLabels(recs []Record, opts Options) []scoredLabel {
+    var out []scoredLabel
+    for _, r := range recs {
+        // NaN never equals itself, so it would never close a tie group.
+        if r.HasScore && r.HasLabel && !math.IsNaN(r.Score) && !math.IsInf(r.Score, 0) {
+            out = append(out, scoredLabel{r.Score, opts.IsPositive(r.Label)})
+        }
+    }
+    sort.SliceStable(out, func(i, j int) bool { return out[i].score > out[j].score })
+    return out
+}
+
+// Ranking computes ROC AUC, PR AUC (average precision), the KS statistic,
+// precision@k and gain/lift curves per model version. It needs both classes
+// to be present; versions with a single class are skipped.
+func Ranking(recs []Record, opts Options, ro RankingOptions) []RankingReport {
+    var reports []RankingReport
+    for _, b := range Bucketize(recs, 0) {
+        rep, ok := rankingReport(scoredLabels(b.Records, opts), ro)
+        if !ok {
+            continue
+        }
+        rep.ModelVersion = b.Version
+        reports = append(reports, rep)
+    }
+    return reports
+}
+
+func rankingReport(sl []scoredLabel, ro RankingOptions) (RankingReport, bool) {
+    rep := RankingReport{Count: len(sl), PrecisionAtK: map[int]float64{}}
+    for _, s := range sl {
+        if s.positive {
+            rep.Positives++
+        }
+    }
+    negatives := rep.Count - rep.Positives
+    if rep.Positives == 0 || negatives == 0 {
+        return rep, false
+    }
+
+    // Walk thresholds from the highest score down; tied scores form one step.
+    rep.ROC = append(rep.ROC, ROCPoint{Threshold: sl[0].score, FPR: 0, TPR: 0})
+    tp, fp := 0, 0
+    prevRecall := 0.0
+    for i := 0; i < len(sl); {
+        threshold := sl[i].score
+        for i < len(sl) && sl[i].score == threshold {
+            if sl[i].positive {
+                tp++
+            } else {
+                fp++
+            }
+            i++
+        }
+        tpr := float64(tp) / float64(rep.Positives)
+        fpr := float64(fp) / float64(negatives)
+        prec := float64(tp) / float64(tp+fp)
+
+        last := rep.ROC[len(rep.ROC)-1]
+        rep.ROCAUC += (fpr - last.FPR) * (tpr + last.TPR) / 2
+        rep.PRAUC += (tpr - prevRecall) * prec
+        prevRecall = tpr
+        if tpr-fpr > rep.KS {
+            rep.KS, rep.KSThreshold = tpr-fpr, threshold
+        }
+        rep.ROC = append(rep.ROC, ROCPoint{Threshold: threshold, FPR: fpr, TPR: tpr})
+        rep.PR = append(rep.PR, PRPoint{Threshold: threshold, Recall: tpr, Precision: prec})
+    }
+
+    for _, k := range ro.K {
+        if k <= 0 {
+            continue
+        }
+        n := k
+        if n > len(sl) {
+            n = len(sl)
+        }
+        hits := 0
+        for _, s := range sl[:n] {
+            if s.positive {
+                hits++
+            }
+        }
+        rep.PrecisionAtK[k] = float64(hits) / float64(n)
+    }
+
+    bins := ro.Bins
+    if bins < 1 {
+        bins = 10
+    }
+    baseRate := float64(rep.Positives) / float64(rep.Count)
+    captured, start := 0, 0
+    for b := 1; b <= bins; b++ {
+        end := len(sl) * b / bins
+        if end == start {
+            continue
+        }
+        inBin := 0
+        for _, s := range sl[start:end] {
+            if s.positive {
+                inBin++
+            }
+        }
+        captured += inBin
+        population := float64(end) / float64(len(sl))
+        gain := float64(captured) / float64(rep.Positives)
+        rep.Gain = append(rep.Gain, GainPoint{
+            Population: population,
+            Gain:       gain,
+            Lift:       gain / population,
+            BinLift:    float64(inBin) / float64(end-start) / baseRate,
+        })
+        start = end
+    }
+
+    rep.ROC = downsampleROC(rep.ROC, ro.MaxPoints)
+    rep.PR = downsamplePR(rep.PR, ro.MaxPoints)
+    return rep, true
+}
+
+// downsample picks at most max evenly spaced indexes out of n, always
+// keeping the first and last.
+func downsample(n, max int) []int {
+    if max < 2 || n <= max {
+        idx := make([]int, n)
+        for i := range idx {
+            idx[i] = i
+        }
+        return idx
+    }
+    idx := make([]int, 0, max)
+    for i := 0; i < max; i++ {
+        idx = append(idx, i*(n-1)/(max-1))
+    }
+    return idx
+}
+
+func downsampleROC(points []ROCPoint, max int) []ROCPoint {
+    out := make([]ROCPoint, 0, len(points))
+    for _, i := range downsample(len(points), max) {
+        out = append(out, points[i])
+    }
+    return out
+}
+
+func downsamplePR(points []PRPoint, max int) []PRPoint {
+    out := make([]PRPoint, 0, len(points))
+    for _, i := range downsample(len(points), max) {
+        out = append(out, points[i])
+    }
+    return out
+}
+
+// AUC returns the ROC AUC of the records, or ErrNoData when only one class
+// is present.
+func AUC(recs []Record, opts Options) (float64, error) {
+    rep, ok := rankingReport(scoredLabels(recs, opts), RankingOptions{})
+    if !ok {
+        return 0, ErrNoData
+    }
+    return rep.ROCAUC, nil
+}
+
+func init() {
+    Register(Metric{Name: "roc_auc", HigherIsBetter: true, Compute: AUC})
+    Register(Metric{Name: "pr_auc", HigherIsBetter: true, Compute: func(recs []Record, opts Options) (float64, error) {
+        rep, ok := rankingReport(scoredLabels(recs, opts), RankingOptions{})
+        if !ok {
+            return 0, ErrNoData
+        }
+        return rep.PRAUC, nil
+    }})
+    Register(Metric{Name: "ks", HigherIsBetter: true, Compute: func(recs []Record, opts Options) (float64, error) {
+        rep, ok := rankingReport(scoredLabels(recs, opts), RankingOptions{})
+        if !ok {
+            return 0, ErrNoData
+        }
+        return rep.KS, nil
+    }})
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+    "math"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// Disagreement is a request where primary and shadow predicted differently.
+type Disagreement struct {
+    RequestID         string      `json:"request_id"`
+    PrimaryID         string      `json:"primary_id"`
+    ShadowID          string      `json:"shadow_id"`
+    Input             interface{} `json:"input_data"`
+    PrimaryPrediction interface{} `json:"primary_prediction"`
+    ShadowPrediction  interface{} `json:"shadow_prediction"`
+    PrimaryScore      *float64    `json:"primary_score,omitempty"`
+    ShadowScore       *float64    `json:"shadow_score,omitempty"`
+}
+
+// ShadowReport compares a primary model version with its shadow on the
+// requests both have served. It needs no feedback.
+type ShadowReport struct {
+    Pairs            int            `json:"pairs"`
+    AgreementRate    *float64       `json:"agreement_rate"`
+    AgreementCI      *Interval      `json:"agreement_ci,omitempty"`
+    ScorePairs       int            `json:"score_pairs"`
+    ScoreCorrelation *float64       `json:"score_correlation"`
+    MeanAbsScoreDiff *float64       `json:"mean_abs_score_diff"`
+    Disagreements    []Disagreement `json:"disagreements"`
+}
+
+// AnalyzeShadow computes the agreement rate of the predictions, the Pearson
+// correlation of the scores and lists up to limit disagreeing requests.
+func AnalyzeShadow(pairs []models.ShadowPair, e Extractor, limit int) ShadowReport {
+    rep := ShadowReport{Disagreements: []Disagreement{}}
+    agree := 0
+    var xs, ys []float64
+    for _, p := range pairs {
+        primary, err1 := jsonpath.Decode(p.PrimaryOutput)
+        shadow, err2 := jsonpath.Decode(p.ShadowOutput)
+        if err1 != nil || err2 != nil {
+            continue
+        }
+        rep.Pairs++
+
+        var ps, ss *float64
+        if v, ok := jsonpath.Lookup(primary, e.ScorePath); ok {
+            if f, ok := jsonpath.Float(v); ok {
+                ps = &f
+            }
+        }
+        if v, ok := jsonpath.Lookup(shadow, e.ScorePath); ok {
+            if f, ok := jsonpath.Float(v); ok {
+                ss = &f
+            }
+        }
+        if ps != nil && ss != nil {
+            xs, ys = append(xs, *ps), append(ys, *ss)
+        }
+
+        pp, _ := jsonpath.Lookup(primary, e.PredictionPath)
+        sp, _ := jsonpath.Lookup(shadow, e.PredictionPath)
+        if jsonpath.Normalize(pp) == jsonpath.Normalize(sp) {
+            agree++
+            continue
+        }
+        if len(rep.Disagreements) < limit {
+            input, _ := jsonpath.Decode(p.InputData)
+            rep.Disagreements = append(rep.Disagreements, Disagreement{
+                RequestID:         p.RequestID,
+                PrimaryID:         p.PrimaryID,
+                ShadowID:          p.ShadowID,
+                Input:             input,
+                PrimaryPrediction: pp,
+                ShadowPrediction:  sp,
+                PrimaryScore:      ps,
+                ShadowScore:       ss,
+            })
+        }
+    }
+
+    if rep.Pairs > 0 {
+        rate := float64(agree) / float64(rep.Pairs)
+        ci := WilsonInterval(agree, rep.Pairs)
+        rep.AgreementRate, rep.AgreementCI = &rate, &ci
+    }
+    rep.ScorePairs = len(xs)
+    if len(xs) > 0 {
+        diff := 0.0
+        for i := range xs {
+            diff += math.Abs(xs[i] - ys[i])
+        }
+        diff /= float64(len(xs))
+        rep.MeanAbsScoreDiff = &diff
+    }
+    if r, ok := Pearson(xs, ys); ok {
+        rep.ScoreCorrelation = &r
+    }
+    return rep
+}
+
+// Pearson returns the Pearson correlation coefficient of x and y. It is
+// undefined for fewer than two points or zero variance.
+func Pearson(x, y []float64) (float64, bool) {
+    if len(x) < 2 || len(x) != len(y) {
+        return 0, false
+    }
+    mx, vx := meanVar(x)
+    my, vy := meanVar(y)
+    if vx == 0 || vy == 0 {
+        return 0, false
+    }
+    cov := 0.0
+    for i := range x {
+        cov += (x[i] - mx) * (y[i] - my)
+    }
+    cov /= float64(len(x) - 1)
+    return cov / math.Sqrt(vx*vy), true
+}
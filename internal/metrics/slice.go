@@ -0,0 +1,564 @@
+package metrics
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+)
+
+// ErrInvalidSlice is returned when a slice expression cannot be parsed.
+var ErrInvalidSlice = errors.New("invalid slice expression")
+
+// tagsPrefix routes a path to the inference tags instead of input_data.
+const tagsPrefix = "tags."
+
+// Slice is a parsed predicate such as
+//
+//   country = 'DE' and age between 18 and 25
+//
+// Paths are dotted JSON paths into input_data; paths starting with "tags."
+// are resolved against the inference tags instead. Supported operators are
+// =, !=, <>, <, <=, >, >=, between, in (...), is null and is not null,
+// combined with "and".
+type Slice struct {
+    Expr  string
+    conds []condition
+}
+
+type condition struct {
+    path   string
+    op     string
+    values []interface{}
+}
+
+// ParseSlice parses a slice expression.
+func ParseSlice(expr string) (*Slice, error) {
+    toks, err := tokenize(expr)
+    if err != nil {
+        return nil, err
+    }
+    p := &parser{toks: toks}
+    s := &Slice{Expr: strings.TrimSpace(expr)}
+    for {
+        c, err := p.condition()
+        if err != nil {
+            return nil, err
+        }
+        s.conds = append(s.conds, c)
+        if p.done() {
+            break
+        }
+        if !p.keyword("and") {
+            return nil, fmt.Errorf("%w: expected 'and' near %q", ErrInvalidSlice, p.peek().text)
+        }
+    }
+    return s, nil
+}
+
+// Match reports whether the record belongs to the slice.
+func (s *Slice) Match(r Record) bool {
+    for _, c := range s.conds {
+        if !c.match(r) {
+            return false
+        }
+    }
+    return true
+}
+
+// Filter returns the records that belong to the slice.
+func (s *Slice) Filter(recs []Record) []Record {
+    var out []Record
+    for _, r := range recs {
+        if s.Match(r) {
+            out = append(out, r)
+        }
+    }
+    return out
+}
+
+func resolve(r Record, path string) (interface{}, bool) {
+    if strings.HasPrefix(path, tagsPrefix) {
+        return jsonpath.Lookup(r.Tags, strings.TrimPrefix(path, tagsPrefix))
+    }
+    return jsonpath.Lookup(r.Input, path)
+}
+
+func (c condition) match(r Record) bool {
+    v, ok := resolve(r, c.path)
+    switch c.op {
+    case "is null":
+        return !ok || v == nil
+    case "is not null":
+        return ok && v != nil
+    }
+    if !ok || v == nil {
+        return false
+    }
+    switch c.op {
+    case "=":
+        return equal(v, c.values[0])
+    case "!=":
+        return !equal(v, c.values[0])
+    case "in":
+        for _, want := range c.values {
+            if equal(v, want) {
+                return true
+            }
+        }
+        return false
+    case "between":
+        return compare(v, c.values[0]) >= 0 && compare(v, c.values[1]) <= 0
+    case "<":
+        return compare(v, c.values[0]) < 0
+    case "<=":
+        return compare(v, c.values[0]) <= 0
+    case ">":
+        return compare(v, c.values[0]) > 0
+    case ">=":
+        return compare(v, c.values[0]) >= 0
+    }
+    return false
+}
+
+func equal(a, b interface{}) bool {
+    fa, okA := a.(float64)
+    fb, okB := b.(float64)
+    if okA || okB {
+        if !okA {
+            fa, okA = jsonpath.Float(a)
+        }
+        if !okB {
+            fb, okB = jsonpath.Float(b)
+        }
+        return okA && okB && fa == fb
+    }
+    return jsonpath.Normalize(a) == jsonpath.Normalize(b)
+}
+
+// compare orders two values numerically when both are numbers and
+// lexicographically otherwise. Incomparable values sort as unequal so that
+// range predicates never match them.
+func compare(a, b interface{}) int {
+    fa, okA := jsonpath.Float(a)
+    fb, okB := jsonpath.Float(b)
+    if okA && okB {
+        switch {
+        case fa < fb:
+            return -1
+        case fa > fb:
+            return 1
+        }
+        return 0
+    }
+    return strings.Compare(jsonpath.Normalize(a), jsonpath.Normalize(b))
+}
+
+// --- tokenizer / parser ---
+
+type tokenKind int
+
+const (
+    tokIdent tokenKind = iota
+    tokString
+    tokNumber
+    tokOp
+    tokLParen
+    tokRParen
+    tokComma
+    tokEOF
+)
+
+type token struct {
+    kind tokenKind
+    text string
+}
+
+func tokenize(expr string) ([]token, error) {
+    var toks []token
+    rs := []rune(expr)
+    for i := 0; i < len(rs); {
+        c := rs[i]
+        switch {
+        case unicode.IsSpace(c):
+            i++
+        case c == '(':
+            toks = append(toks, token{tokLParen, "("})
+            i++
+        case c == ')':
+            toks = append(toks, token{tokRParen, ")"})
+            i++
+        case c == ',':
+            toks = append(toks, token{tokComma, ","})
+            i++
+        case c == '\'' || c == '"':
+            quote := c
+            var sb strings.Builder
+            i++
+            for {
+                if i >= len(rs) {
+                    return nil, fmt.Errorf("%w: unterminated string", ErrInvalidSlice)
+                }
+                if rs[i] == quote {
+                    // a doubled quote is an escaped quote, as in SQL
+                    if i+1 < len(rs) && rs[i+1] == quote {
+                        sb.WriteRune(quote)
+                        i += 2
+                        continue
+                    }
+                    i++
+                    break
+                }
+                sb.WriteRune(rs[i])
+                i++
+            }
+            toks = append(toks, token{tokString, sb.String()})
+        case strings.ContainsRune("=!<>", c):
+            j := i + 1
+            if j < len(rs) && strings.ContainsRune("=>", rs[j]) {
+                j++
+            }
+            op := string(rs[i:j])
+            switch op {
+            case "=", "!=", "<>", "<", "<=", ">", ">=":
+            default:
+                return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidSlice, op)
+            }
+            if op == "<>" {
+                op = "!="
+            }
+            toks = append(toks, token{tokOp, op})
+            i = j
+        case unicode.IsDigit(c) || c == '-' || c == '+':
+            j := i + 1
+            for j < len(rs) && (unicode.IsDigit(rs[j]) || strings.ContainsRune(".eE+-", rs[j])) {
+                j++
+            }
+            toks = append(toks, token{tokNumber, string(rs[i:j])})
+            i = j
+        case unicode.IsLetter(c) || c == '_' || c == '$':
+            j := i + 1
+            for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || strings.ContainsRune("_.$", rs[j])) {
+                j++
+            }
+            toks = append(toks, token{tokIdent, string(rs[i:j])})
+            i = j
+        default:
+            return nil, fmt.Errorf("%w: unexpected character %q", ErrInvalidSlice, c)
+        }
+    }
+    return append(toks, token{tokEOF, ""}), nil
+}
+
+type parser struct {
+    toks []token
+    pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+    t := p.toks[p.pos]
+    if t.kind != tokEOF {
+        p.pos++
+    }
+    return t
+}
+
+func (p *parser) done() bool { return p.peek().kind == tokEOF }
+
+func (p *parser) keyword(kw string) bool {
+    t := p.peek()
+    if t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+        p.pos++
+        return true
+    }
+    return false
+}
+
+func (p *parser) condition() (condition, error) {
+    t := p.next()
+    if t.kind != tokIdent {
+        return condition{}, fmt.Errorf("%w: expected field path, got %q", ErrInvalidSlice, t.text)
+    }
+    c := condition{path: t.text}
+
+    switch {
+    case p.keyword("between"):
+        lo, err := p.value()
+        if err != nil {
+            return c, err
+        }
+        if !p.keyword("and") {
+            return c, fmt.Errorf("%w: expected 'and' in between", ErrInvalidSlice)
+        }
+        hi, err := p.value()
+        if err != nil {
+            return c, err
+        }
+        c.op, c.values = "between", []interface{}{lo, hi}
+    case p.keyword("in"):
+        if p.next().kind != tokLParen {
+            return c, fmt.Errorf("%w: expected '(' after in", ErrInvalidSlice)
+        }
+        for {
+            v, err := p.value()
+            if err != nil {
+                return c, err
+            }
+            c.values = append(c.values, v)
+            sep := p.next()
+            if sep.kind == tokRParen {
+                break
+            }
+            if sep.kind != tokComma {
+                return c, fmt.Errorf("%w: expected ',' or ')' in list", ErrInvalidSlice)
+            }
+        }
+        c.op = "in"
+    case p.keyword("is"):
+        c.op = "is null"
+        if p.keyword("not") {
+            c.op = "is not null"
+        }
+        if !p.keyword("null") {
+            return c, fmt.Errorf("%w: expected null after is", ErrInvalidSlice)
+        }
+    default:
+        op := p.next()
+        if op.kind != tokOp {
+            return c, fmt.Errorf("%w: expected operator after %q", ErrInvalidSlice, c.path)
+        }
+        v, err := p.value()
+        if err != nil {
+            return c, err
+        }
+        c.op, c.values = op.text, []interface{}{v}
+    }
+    return c, nil
+}
+
+func (p *parser) value() (interface{}, error) {
+    t := p.next()
+    switch t.kind {
+    case tokString:
+        return t.text, nil
+    case tokNumber:
+        f, err := strconv.ParseFloat(t.text, 64)
+        if err != nil {
+            return nil, fmt.Errorf("%w: bad number %q", ErrInvalidSlice, t.text)
+        }
+        return f, nil
+    case tokIdent:
+        switch strings.ToLower(t.text) {
+        case "true":
+            return true, nil
+        case "false":
+            return false, nil
+        }
+    }
+    return nil, fmt.Errorf("%w: expected value, got %q", ErrInvalidSlice, t.text)
+}
+
+// --- per-slice evaluation ---
+
+// SliceResult is the value of a metric on one slice.
+type SliceResult struct {
+    Slice string   `json:"slice"`
+    Count int      `json:"count"`
+    Value *float64 `json:"value"`
+    Delta *float64 `json:"delta,omitempty"`
+    Error string   `json:"error,omitempty"`
+}
+
+// EvaluateSlices computes the metric over all records and for every slice.
+// Slices where the metric is undefined get a nil value and an error message
+// instead of failing the whole request.
+func EvaluateSlices(m Metric, recs []Record, slices []*Slice, opts Options) (overall SliceResult, results []SliceResult) {
+    overall = evaluateSlice(m, "all", recs, opts)
+    results = make([]SliceResult, 0, len(slices))
+    for _, s := range slices {
+        res := evaluateSlice(m, s.Expr, s.Filter(recs), opts)
+        res.Delta = delta(res, overall)
+        results = append(results, res)
+    }
+    return overall, results
+}
+
+func delta(res, overall SliceResult) *float64 {
+    if res.Value == nil || overall.Value == nil {
+        return nil
+    }
+    d := *res.Value - *overall.Value
+    return &d
+}
+
+func evaluateSlice(m Metric, expr string, recs []Record, opts Options) SliceResult {
+    res := SliceResult{Slice: expr, Count: len(recs)}
+    v, err := m.Compute(recs, opts)
+    if err != nil {
+        res.Error = err.Error()
+        return res
+    }
+    res.Value = &v
+    return res
+}
+
+// WorstSliceOptions controls the automatic slice search.
+type WorstSliceOptions struct {
+    // Fields to slice on. When empty, every top-level scalar field of
+    // input_data and the tags is considered.
+    Fields []string
+    // MinCount drops slices with fewer records than this.
+    MinCount int
+    // MaxValues caps the number of categorical values tried per field.
+    MaxValues int
+    // Bins is the number of quantile bins used for numeric fields.
+    Bins int
+    Limit int
+}
+
+// FindWorstSlices enumerates single-field slices (one per categorical value,
+// quantile bins for numeric fields), evaluates the metric on each and returns
+// them ordered from worst to best relative to the overall value.
+func FindWorstSlices(m Metric, recs []Record, opts Options, wo WorstSliceOptions) (overall SliceResult, worst []SliceResult) {
+    overall = evaluateSlice(m, "all", recs, opts)
+
+    fields := wo.Fields
+    if len(fields) == 0 {
+        fields = scalarFields(recs)
+    }
+
+    var candidates []SliceResult
+    for _, field := range fields {
+        for _, expr := range candidateSlices(recs, field, wo) {
+            s, err := ParseSlice(expr)
+            if err != nil {
+                continue
+            }
+            res := evaluateSlice(m, expr, s.Filter(recs), opts)
+            if res.Value == nil || res.Count < wo.MinCount {
+                continue
+            }
+            res.Delta = delta(res, overall)
+            candidates = append(candidates, res)
+        }
+    }
+
+    sort.SliceStable(candidates, func(i, j int) bool {
+        if m.HigherIsBetter {
+            return *candidates[i].Value < *candidates[j].Value
+        }
+        return *candidates[i].Value > *candidates[j].Value
+    })
+    if wo.Limit > 0 && len(candidates) > wo.Limit {
+        candidates = candidates[:wo.Limit]
+    }
+    return overall, candidates
+}
+
+// scalarFields lists the top-level fields of input_data and tags that hold
+// scalar values in at least one record.
+func scalarFields(recs []Record) []string {
+    seen := map[string]bool{}
+    add := func(doc interface{}, prefix string) {
+        obj, ok := doc.(map[string]interface{})
+        if !ok {
+            return
+        }
+        for k, v := range obj {
+            switch v.(type) {
+            case string, float64, bool:
+                seen[prefix+k] = true
+            }
+        }
+    }
+    for _, r := range recs {
+        add(r.Input, "")
+        add(r.Tags, tagsPrefix)
+    }
+    fields := make([]string, 0, len(seen))
+    for f := range seen {
+        fields = append(fields, f)
+    }
+    sort.Strings(fields)
+    return fields
+}
+
+func candidateSlices(recs []Record, field string, wo WorstSliceOptions) []string {
+    counts := map[string]int{}
+    var numbers []float64
+    for _, r := range recs {
+        v, ok := resolve(r, field)
+        if !ok || v == nil {
+            continue
+        }
+        switch val := v.(type) {
+        case float64:
+            numbers = append(numbers, val)
+        case string, bool:
+            counts[jsonpath.Normalize(val)]++
+        }
+    }
+
+    var exprs []string
+    if len(counts) > 0 {
+        values := make([]string, 0, len(counts))
+        for v := range counts {
+            values = append(values, v)
+        }
+        sort.Slice(values, func(i, j int) bool {
+            if counts[values[i]] != counts[values[j]] {
+                return counts[values[i]] > counts[values[j]]
+            }
+            return values[i] < values[j]
+        })
+        if wo.MaxValues > 0 && len(values) > wo.MaxValues {
+            values = values[:wo.MaxValues]
+        }
+        for _, v := range values {
+            exprs = append(exprs, fmt.Sprintf("%s = '%s'", field, strings.ReplaceAll(v, "'", "''")))
+        }
+    }
+    if len(numbers) > 0 {
+        exprs = append(exprs, numericBins(field, numbers, wo.Bins)...)
+    }
+    return exprs
+}
+
+// numericBins splits the observed values of a numeric field into quantile
+// bins and renders each bin as a slice expression.
+func numericBins(field string, values []float64, bins int) []string {
+    if bins < 1 {
+        bins = 1
+    }
+    sort.Float64s(values)
+    var edges []float64
+    for i := 0; i <= bins; i++ {
+        q := values[(len(values)-1)*i/bins]
+        if len(edges) == 0 || q > edges[len(edges)-1] {
+            edges = append(edges, q)
+        }
+    }
+    if len(edges) == 1 {
+        return []string{fmt.Sprintf("%s = %s", field, formatNumber(edges[0]))}
+    }
+    var exprs []string
+    for i := 0; i+1 < len(edges); i++ {
+        lo, hi := formatNumber(edges[i]), formatNumber(edges[i+1])
+        if i+2 == len(edges) {
+            exprs = append(exprs, fmt.Sprintf("%s between %s and %s", field, lo, hi))
+        } else {
+            exprs = append(exprs, fmt.Sprintf("%s >= %s and %s < %s", field, lo, field, hi))
+        }
+    }
+    return exprs
+}
+
+func formatNumber(f float64) string {
+    return strconv.FormatFloat(f, 'g', -1, 64)
+}
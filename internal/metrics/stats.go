@@ -0,0 +1,54 @@
+package metrics
+
+import (
+    "math"
+)
+
+// z95 is the two-sided 95% standard normal quantile.
+const z95 = 1.959963984540054
+
+// Interval is a confidence interval around an estimate.
+type Interval struct {
+    Lower float64 `json:"lower"`
+    Upper float64 `json:"upper"`
+}
+
+// WilsonInterval is the 95% Wilson score interval for a binomial proportion.
+func WilsonInterval(successes, n int) Interval {
+    if n == 0 {
+        return Interval{0, 1}
+    }
+    p := float64(successes) / float64(n)
+    nf := float64(n)
+    z2 := z95 * z95
+    center := (p + z2/(2*nf)) / (1 + z2/nf)
+    half := z95 * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / (1 + z2/nf)
+    return Interval{math.Max(0, center-half), math.Min(1, center+half)}
+}
+
+// DiffInterval is the 95% Wald interval for p1 - p2 of two independent
+// proportions.
+func DiffInterval(p1 float64, n1 int, p2 float64, n2 int) Interval {
+    d := p1 - p2
+    if n1 == 0 || n2 == 0 {
+        return Interval{-1, 1}
+    }
+    se := math.Sqrt(p1*(1-p1)/float64(n1) + p2*(1-p2)/float64(n2))
+    return Interval{math.Max(-1, d-z95*se), math.Min(1, d+z95*se)}
+}
+
+// RatioInterval is the 95% interval for p1 / p2 using the log method
+// (Katz). It is undefined when either proportion is zero.
+func RatioInterval(p1 float64, n1 int, p2 float64, n2 int) (Interval, bool) {
+    if p1 == 0 || p2 == 0 || n1 == 0 || n2 == 0 {
+        return Interval{}, false
+    }
+    r := math.Log(p1 / p2)
+    se := math.Sqrt((1-p1)/(float64(n1)*p1) + (1-p2)/(float64(n2)*p2))
+    return Interval{math.Exp(r - z95*se), math.Exp(r + z95*se)}, true
+}
+
+// NormalCDF is the standard normal cumulative distribution function.
+func NormalCDF(x float64) float64 {
+    return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
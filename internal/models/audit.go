@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AuditEntry is one record of the append-only audit log. Hash covers every
+// other field and the hash of the previous entry, so entries cannot be
+// changed, removed or reordered without breaking the chain.
+type AuditEntry struct {
+    Seq        int64     `json:"seq"`
+    Actor      string    `json:"actor"`
+    RemoteAddr string    `json:"remote_addr"`
+    Method     string    `json:"method"`
+    Route      string    `json:"route"`
+    ResourceID string    `json:"resource_id,omitempty"`
+    Status     int       `json:"status"`
+    Outcome    string    `json:"outcome"`
+    CreatedAt  time.Time `json:"created_at"`
+    PrevHash   string    `json:"prev_hash"`
+    Hash       string    `json:"hash"`
+}
+
+// AuditQuery pages through the audit log in sequence order. Empty Actor and
+// ResourceID match every entry.
+type AuditQuery struct {
+    AfterSeq   int64
+    Actor      string
+    ResourceID string
+    Limit      int
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Erasure modes: delete removes a subject's inferences, their feedback and
+// LLM calls; anonymize clears every payload and free-text detail and keeps
+// the rows, so counts, latencies and scores still add up.
+const (
+    ErasureModeDelete    = "delete"
+    ErasureModeAnonymize = "anonymize"
+)
+
+// Erasure job statuses.
+const (
+    ErasureRunning   = "running"
+    ErasureCompleted = "completed"
+    ErasureFailed    = "failed"
+)
+
+// ErasureJob tracks the erasure of one subject's data. Only a hash of the
+// subject ID is kept.
+type ErasureJob struct {
+    ID          string     `json:"id"`
+    SubjectHash string     `json:"subject_hash"`
+    Mode        string     `json:"mode"`
+    Status      string     `json:"status"`
+    Batches     int        `json:"batches"`
+    Inferences  int        `json:"inferences"`
+    Feedback    int        `json:"feedback"`
+    LLMCalls    int        `json:"llm_calls"`
+    Error       string     `json:"error,omitempty"`
+    StartedAt   time.Time  `json:"started_at"`
+    FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// ErasureBatch counts the rows one erasure batch deleted or anonymized.
+type ErasureBatch struct {
+    Inferences int
+    Feedback   int
+    LLMCalls   int
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// EvaluationScore is the result of one evaluator on one inference.
+type EvaluationScore struct {
+    ID           string    `json:"id"`
+    InferenceID  string    `json:"inference_id"`
+    ModelName    string    `json:"model_name"`
+    ModelVersion string    `json:"model_version"`
+    Evaluator    string    `json:"evaluator"`
+    Value        float64   `json:"value"`
+    Label        string    `json:"label,omitempty"`
+    Detail       string    `json:"detail,omitempty"`
+    CreatedAt    time.Time `json:"created_at"`
+}
+
+// EvaluationAggregate summarises the scores of one evaluator in one time
+// bucket. Labels counts the categorical outcomes, e.g. detected languages.
+type EvaluationAggregate struct {
+    Evaluator   string           `json:"evaluator"`
+    BucketStart time.Time        `json:"bucket_start"`
+    Count       int64            `json:"count"`
+    Mean        float64          `json:"mean"`
+    Min         float64          `json:"min"`
+    Max         float64          `json:"max"`
+    Labels      map[string]int64 `json:"labels,omitempty"`
+}
@@ -8,6 +8,15 @@ type Inference struct {
     ModelVersion string   `json:"model_version"`
     InputData   string    `json:"input_data"`   
     OutputData  string    `json:"output_data"`  
+    Tags        string    `json:"tags"`
+    LatencyMs   *float64  `json:"latency_ms,omitempty"`
+    RequestID   string    `json:"request_id,omitempty"`
+    SubjectID   string    `json:"subject_id,omitempty"`
+    IsShadow    bool      `json:"is_shadow"`
+    TraceID      string     `json:"trace_id,omitempty"`
+    SpanID       string     `json:"span_id,omitempty"`
+    ParentSpanID string     `json:"parent_span_id,omitempty"`
+    StartedAt    *time.Time `json:"started_at,omitempty"`
     CreatedAt   time.Time `json:"created_at"`
     HasFeedback bool      `json:"has_feedback"`
 }
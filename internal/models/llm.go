@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// LLMCall is one request to a large language model.
+type LLMCall struct {
+    ID               string    `json:"id"`
+    Model            string    `json:"model"`
+    Provider         string    `json:"provider,omitempty"`
+    Prompt           string    `json:"prompt"`
+    Completion       string    `json:"completion"`
+    PromptTokens     int       `json:"prompt_tokens"`
+    CompletionTokens int       `json:"completion_tokens"`
+    CostUSD          *float64  `json:"cost_usd,omitempty"`
+    LatencyMs        *float64  `json:"latency_ms,omitempty"`
+    FinishReason     string    `json:"finish_reason,omitempty"`
+    Tags             string    `json:"tags"`
+    SubjectID        string    `json:"subject_id,omitempty"`
+    // TraceID, SpanID and ParentSpanID place the call in a trace next to
+    // the inferences of the same request.
+    TraceID      string     `json:"trace_id,omitempty"`
+    SpanID       string     `json:"span_id,omitempty"`
+    ParentSpanID string     `json:"parent_span_id,omitempty"`
+    StartedAt    *time.Time `json:"started_at,omitempty"`
+    CreatedAt    time.Time  `json:"created_at"`
+}
+
+// LLMUsage aggregates the calls of one model on one UTC day.
+type LLMUsage struct {
+    Model            string    `json:"model"`
+    Day              time.Time `json:"day"`
+    Calls            int64     `json:"calls"`
+    PromptTokens     int64     `json:"prompt_tokens"`
+    CompletionTokens int64     `json:"completion_tokens"`
+    CostUSD          float64   `json:"cost_usd"`
+    // UnpricedCalls had no known price; they are not included in CostUSD.
+    UnpricedCalls int64 `json:"unpriced_calls"`
+}
+
+// LLMLatency holds latency statistics of one model.
+type LLMLatency struct {
+    Model     string             `json:"model"`
+    Count     int64              `json:"count"`
+    Mean      float64            `json:"mean"`
+    Quantiles map[string]float64 `json:"quantiles"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PIIRedaction records that identifiers of one kind were found at one path of
+// an inference payload and what was done with them. The values themselves
+// are never stored.
+type PIIRedaction struct {
+    ID          string    `json:"id"`
+    InferenceID string    `json:"inference_id"`
+    ModelName   string    `json:"model_name"`
+    Payload     string    `json:"payload"`
+    Path        string    `json:"path"`
+    Kind        string    `json:"kind"`
+    Action      string    `json:"action"`
+    Count       int       `json:"count"`
+    CreatedAt   time.Time `json:"created_at"`
+}
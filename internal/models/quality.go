@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// QualityProfile is the learned (and optionally declared) shape of one
+// model's input_data, used to detect data quality problems.
+type QualityProfile struct {
+    ModelName string                   `json:"model_name"`
+    Samples   int64                    `json:"samples"`
+    Fields    map[string]*FieldProfile `json:"fields"`
+    UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// FieldProfile tracks one dotted input_data path. Counts keep running for the
+// lifetime of the profile; Min, Max and Categories are only accumulated during
+// the learning period and are frozen into Learned when it ends.
+type FieldProfile struct {
+    Present    int64            `json:"present"`
+    Nulls      int64            `json:"nulls"`
+    Types      map[string]int64 `json:"types"`
+    Min        *float64         `json:"min,omitempty"`
+    Max        *float64         `json:"max,omitempty"`
+    Categories map[string]int64 `json:"categories,omitempty"`
+    // HighCardinality is set once a string field shows too many distinct
+    // values to be treated as categorical.
+    HighCardinality bool         `json:"high_cardinality,omitempty"`
+    Learned         *FieldBounds `json:"learned,omitempty"`
+    Declared        *FieldBounds `json:"declared,omitempty"`
+}
+
+// FieldBounds are the expectations a field is checked against. When a field
+// has declared bounds they replace the learned ones entirely.
+type FieldBounds struct {
+    Type     string   `json:"type,omitempty"`
+    Min      *float64 `json:"min,omitempty"`
+    Max      *float64 `json:"max,omitempty"`
+    Allowed  []string `json:"allowed,omitempty"`
+    Required bool     `json:"required,omitempty"`
+    NotNull  bool     `json:"not_null,omitempty"`
+}
+
+// QualityViolation is one data quality problem found in an inference input.
+type QualityViolation struct {
+    ID          string    `json:"id"`
+    InferenceID string    `json:"inference_id"`
+    ModelName   string    `json:"model_name"`
+    Field       string    `json:"field"`
+    Kind        string    `json:"kind"`
+    Detail      string    `json:"detail"`
+    CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+    "cmp"
+    "slices"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/sketch"
+)
+
+// Rollup is the pre-aggregated traffic of one model version in one time
+// bucket. Rollups are additive: a delta computed from new rows is merged
+// into the stored rollup.
+type Rollup struct {
+    ModelName      string                    `json:"model_name"`
+    ModelVersion   string                    `json:"model_version"`
+    BucketStart    time.Time                 `json:"bucket_start"`
+    InferenceCount int64                     `json:"inference_count"`
+    FeedbackCount  int64                     `json:"feedback_count"`
+    Latency        *sketch.Sketch            `json:"latency"`
+    Features       map[string]*FeatureRollup `json:"features,omitempty"`
+}
+
+// FeatureRollup aggregates one top-level input_data field. Numeric values go
+// into a sketch, strings and booleans into category counts.
+type FeatureRollup struct {
+    Missing    int64            `json:"missing"`
+    Numeric    *sketch.Sketch   `json:"numeric,omitempty"`
+    Categories map[string]int64 `json:"categories,omitempty"`
+}
+
+// Watermark is the position of a rollup stream: the (created_at, id) of the
+// last row that has been folded into the rollups.
+type Watermark struct {
+    Stream    string
+    CreatedAt time.Time
+    ID        string
+}
+
+// FeedbackEvent is a feedback row together with the inference it belongs to,
+// as read by the rollup worker.
+type FeedbackEvent struct {
+    ID                 string
+    CreatedAt          time.Time
+    ModelName          string
+    ModelVersion       string
+    InferenceCreatedAt time.Time
+}
+
+// Merge adds other into r. Both must describe the same model version and
+// bucket.
+func (r *Rollup) Merge(other *Rollup) error {
+    r.InferenceCount += other.InferenceCount
+    r.FeedbackCount += other.FeedbackCount
+    if other.Latency != nil {
+        if r.Latency == nil {
+            r.Latency = sketch.New(other.Latency.Alpha)
+        }
+        if err := r.Latency.Merge(other.Latency); err != nil {
+            return err
+        }
+    }
+    for field, f := range other.Features {
+        if r.Features == nil {
+            r.Features = map[string]*FeatureRollup{}
+        }
+        cur, ok := r.Features[field]
+        if !ok {
+            cur = &FeatureRollup{}
+            r.Features[field] = cur
+        }
+        if err := cur.Merge(f); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// MaxCategories caps the distinct values kept per categorical feature.
+// Values that do not make the cut are counted under OtherCategory.
+const (
+    MaxCategories = 100
+    OtherCategory = "__other__"
+)
+
+// Merge adds other into f.
+func (f *FeatureRollup) Merge(other *FeatureRollup) error {
+    f.Missing += other.Missing
+    if other.Numeric != nil {
+        if f.Numeric == nil {
+            f.Numeric = sketch.New(other.Numeric.Alpha)
+        }
+        if err := f.Numeric.Merge(other.Numeric); err != nil {
+            return err
+        }
+    }
+    if len(other.Categories) == 0 {
+        return nil
+    }
+    if f.Categories == nil {
+        f.Categories = map[string]int64{}
+    }
+    for v, c := range other.Categories {
+        f.Categories[v] += c
+    }
+    f.trimCategories()
+    return nil
+}
+
+// AddCategory counts n occurrences of a categorical value. Once
+// MaxCategories values are kept, a new value takes the place of the least
+// frequent one and inherits its count (space-saving), so a value that turns
+// frequent later still makes the top; kept counts can then overstate a value
+// by at most the count it inherited.
+func (f *FeatureRollup) AddCategory(v string, n int64) {
+    if f.Categories == nil {
+        f.Categories = map[string]int64{}
+    }
+    if _, ok := f.Categories[v]; ok || v == OtherCategory || f.named() < MaxCategories {
+        f.Categories[v] += n
+        return
+    }
+    least, leastCount := "", int64(0)
+    for k, c := range f.Categories {
+        if k != OtherCategory && (least == "" || c < leastCount || (c == leastCount && k < least)) {
+            least, leastCount = k, c
+        }
+    }
+    delete(f.Categories, least)
+    f.Categories[v] = leastCount + n
+}
+
+// named is the number of kept values other than OtherCategory.
+func (f *FeatureRollup) named() int {
+    if _, ok := f.Categories[OtherCategory]; ok {
+        return len(f.Categories) - 1
+    }
+    return len(f.Categories)
+}
+
+// trimCategories keeps the MaxCategories most frequent values and folds the
+// rest into OtherCategory.
+func (f *FeatureRollup) trimCategories() {
+    if f.named() <= MaxCategories {
+        return
+    }
+    values := make([]string, 0, len(f.Categories))
+    for v := range f.Categories {
+        if v != OtherCategory {
+            values = append(values, v)
+        }
+    }
+    slices.SortFunc(values, func(a, b string) int {
+        if c := cmp.Compare(f.Categories[b], f.Categories[a]); c != 0 {
+            return c
+        }
+        return cmp.Compare(a, b)
+    })
+    for _, v := range values[MaxCategories:] {
+        f.Categories[OtherCategory] += f.Categories[v]
+        delete(f.Categories, v)
+    }
+}
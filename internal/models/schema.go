@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Schema is the structure of one payload (input_data or output_data) of a
+// model version, inferred from observed traffic.
+type Schema struct {
+    ModelName    string                  `json:"model_name"`
+    ModelVersion string                  `json:"model_version"`
+    Payload      string                  `json:"payload"`
+    Observations int64                   `json:"observations"`
+    Fields       map[string]*SchemaField `json:"fields"`
+    UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// SchemaField is one path in a schema. Object members are joined with "."
+// and array elements are addressed with "[]", e.g. "items[].id".
+type SchemaField struct {
+    Types map[string]int64 `json:"types"`
+    // Count is the number of occurrences; for paths below an array it counts
+    // elements, not observations.
+    Count     int64     `json:"count"`
+    FirstSeen time.Time `json:"first_seen"`
+    LastSeen  time.Time `json:"last_seen"`
+    // LastObservation is the value of Schema.Observations when the field was
+    // last present.
+    LastObservation int64 `json:"last_observation"`
+    Removed         bool  `json:"removed,omitempty"`
+}
+
+// SchemaEvent records a change to an inferred schema.
+type SchemaEvent struct {
+    ID           string    `json:"id"`
+    ModelName    string    `json:"model_name"`
+    ModelVersion string    `json:"model_version"`
+    Payload      string    `json:"payload"`
+    Field        string    `json:"field"`
+    Kind         string    `json:"kind"`
+    Detail       string    `json:"detail"`
+    CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// WindowQuery selects the inferences of one model (and optionally one
+// version) that were created inside [From, To).
+type WindowQuery struct {
+    ModelName    string
+    ModelVersion string
+    From         time.Time
+    To           time.Time
+    Limit        int
+    // LabeledOnly restricts the result to inferences that have feedback.
+    LabeledOnly  bool
+}
+
+// LabeledInference is an inference joined with its most recent feedback.
+// FeedbackData is empty when the inference has no feedback yet.
+type LabeledInference struct {
+    Inference
+    FeedbackData string `json:"feedback_data"`
+}
+
+// ShadowPair links a primary inference and the shadow inference logged for
+// the same request.
+type ShadowPair struct {
+    RequestID     string    `json:"request_id"`
+    CreatedAt     time.Time `json:"created_at"`
+    InputData     string    `json:"input_data"`
+    PrimaryID     string    `json:"primary_id"`
+    PrimaryOutput string    `json:"primary_output"`
+    ShadowID      string    `json:"shadow_id"`
+    ShadowOutput  string    `json:"shadow_output"`
+}
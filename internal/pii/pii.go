@@ -0,0 +1,426 @@
+// Package pii finds personal identifiers (emails, phone numbers, payment
+// cards, IBANs, IP addresses and custom patterns) in JSON payloads and
+// redacts them before they are stored.
+package pii
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// Action is what happens to a payload that contains PII.
+type Action string
+
+const (
+    // ActionMask replaces each identifier with "[REDACTED:<kind>]".
+    ActionMask Action = "mask"
+    // ActionHash replaces each identifier with a keyed HMAC of its
+    // normalised value, so equal identifiers still join.
+    ActionHash Action = "hash"
+    // ActionDrop removes every field that contains an identifier.
+    ActionDrop Action = "drop"
+    // ActionReject refuses the whole request.
+    ActionReject Action = "reject"
+)
+
+// ParseAction validates an action name.
+func ParseAction(s string) (Action, error) {
+    switch a := Action(strings.ToLower(strings.TrimSpace(s))); a {
+    case ActionMask, ActionHash, ActionDrop, ActionReject:
+        return a, nil
+    }
+    return "", fmt.Errorf("unknown PII action %q (want mask, hash, drop or reject)", s)
+}
+
+// Built-in kinds. Custom patterns are reported under their configured name.
+const (
+    KindEmail      = "email"
+    KindPhone      = "phone"
+    KindCreditCard = "credit_card"
+    KindIBAN       = "iban"
+    KindIP         = "ip"
+)
+
+// hashLength is the number of hex characters of the HMAC kept in hashed
+// values.
+const hashLength = 16
+
+// ErrHashKeyRequired is returned when hashing without an HMAC key.
+var ErrHashKeyRequired = errors.New("PII hashing requires an HMAC key")
+
+// Finding counts the identifiers of one kind found at one path. Values are
+// never recorded.
+type Finding struct {
+    Path  string `json:"path"`
+    Kind  string `json:"kind"`
+    Count int    `json:"count"`
+}
+
+type detector struct {
+    kind string
+    re   *regexp.Regexp
+    // valid filters regex candidates, e.g. with a checksum.
+    valid func(match string) bool
+    // normalize canonicalises a value before hashing.
+    normalize func(match string) string
+    // standalone rejects matches that continue a longer number, such as
+    // the digits around a decimal point.
+    standalone bool
+}
+
+// Scanner detects and redacts PII. It is safe for concurrent use once
+// configured.
+type Scanner struct {
+    // ScanNumbers also checks JSON numbers for payment cards. It is off by
+    // default, since a long number is more often an ID or an amount.
+    ScanNumbers bool
+
+    detectors []detector
+    card      *detector
+    key       []byte
+}
+
+var (
+    emailRe = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b`)
+    ibanRe  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`)
+    cardRe  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+    ipv4Re  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+    ipv6Re  = regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}(?:%[0-9a-z]+)?`)
+    dateRe  = regexp.MustCompile(`^(?:\d{4}[-./]\d{1,2}[-./]\d{1,2}|\d{1,2}[-./]\d{1,2}[-./]\d{2,4})$`)
+    phoneRe = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}(?:[\s.-]\d{2,4}){1,4}\b|\+\d{7,15}\b`)
+)
+
+// NewScanner returns a scanner with the built-in detectors and custom
+// patterns (kind name -> regular expression). Custom patterns take
+// precedence over built-in ones. key is the HMAC key for ActionHash.
+func NewScanner(custom map[string]string, key []byte) (*Scanner, error) {
+    s := &Scanner{key: key}
+
+    names := make([]string, 0, len(custom))
+    for name := range custom {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        re, err := regexp.Compile(custom[name])
+        if err != nil {
+            return nil, fmt.Errorf("invalid PII pattern %q: %w", name, err)
+        }
+        s.detectors = append(s.detectors, detector{kind: name, re: re})
+    }
+
+    s.detectors = append(s.detectors,
+        detector{kind: KindEmail, re: emailRe, normalize: strings.ToLower},
+        detector{kind: KindIBAN, re: ibanRe, valid: validIBAN, normalize: stripSpaces},
+        detector{kind: KindCreditCard, re: cardRe, valid: Luhn, normalize: digitsOnly, standalone: true},
+        detector{kind: KindIP, re: ipv4Re, valid: validIP, standalone: true},
+        detector{kind: KindIP, re: ipv6Re, valid: validIPv6, normalize: strings.ToLower},
+        detector{kind: KindPhone, re: phoneRe, valid: validPhone, normalize: digitsOnly, standalone: true},
+    )
+    for i := range s.detectors {
+        if s.detectors[i].kind == KindCreditCard {
+            s.card = &s.detectors[i]
+        }
+    }
+    return s, nil
+}
+
+type match struct {
+    start, end int
+    det        *detector
+}
+
+// find returns the non-overlapping identifiers in text, earlier detectors
+// winning over later ones.
+func (s *Scanner) find(text string) []match {
+    var out []match
+    for i := range s.detectors {
+        d := &s.detectors[i]
+        for _, loc := range d.re.FindAllStringIndex(text, -1) {
+            if d.valid != nil && !d.valid(text[loc[0]:loc[1]]) {
+                continue
+            }
+            if d.standalone && partOfNumber(text, loc[0], loc[1]) {
+                continue
+            }
+            overlaps := false
+            for _, m := range out {
+                if loc[0] < m.end && m.start < loc[1] {
+                    overlaps = true
+                    break
+                }
+            }
+            if !overlaps {
+                out = append(out, match{start: loc[0], end: loc[1], det: d})
+            }
+        }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+    return out
+}
+
+// Redact applies action to every identifier in a JSON payload and returns
+// the new payload with what was found. With ActionReject the payload is
+// returned unchanged; callers refuse it when there are findings. Numbers
+// are only checked for payment cards, and only with ScanNumbers.
+func (s *Scanner) Redact(raw string, action Action) (string, []Finding, error) {
+    if raw == "" {
+        return raw, nil, nil
+    }
+    if action == ActionHash && len(s.key) == 0 {
+        return "", nil, ErrHashKeyRequired
+    }
+    dec := json.NewDecoder(strings.NewReader(raw))
+    dec.UseNumber()
+    var doc interface{}
+    if err := dec.Decode(&doc); err != nil {
+        return "", nil, err
+    }
+
+    var findings []Finding
+    doc, drop := s.walk(doc, "", action, &findings)
+    if drop {
+        doc = nil
+    }
+    sort.Slice(findings, func(i, j int) bool {
+        if findings[i].Path != findings[j].Path {
+            return findings[i].Path < findings[j].Path
+        }
+        return findings[i].Kind < findings[j].Kind
+    })
+    if len(findings) == 0 || action == ActionReject {
+        return raw, findings, nil
+    }
+
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(doc); err != nil {
+        return "", nil, err
+    }
+    return strings.TrimSuffix(buf.String(), "\n"), findings, nil
+}
+
+// walk redacts v in place where possible and returns the replacement value;
+// drop reports that the value itself must be removed from its parent.
+func (s *Scanner) walk(v interface{}, path string, action Action, findings *[]Finding) (interface{}, bool) {
+    switch node := v.(type) {
+    case map[string]interface{}:
+        for k, child := range node {
+            nv, drop := s.walk(child, join(path, k), action, findings)
+            if drop {
+                delete(node, k)
+            } else {
+                node[k] = nv
+            }
+        }
+        return node, false
+    case []interface{}:
+        for i, child := range node {
+            nv, drop := s.walk(child, join(path, strconv.Itoa(i)), action, findings)
+            if drop {
+                nv = nil
+            }
+            node[i] = nv
+        }
+        return node, false
+    case string:
+        return s.redactString(node, path, action, findings)
+    case json.Number:
+        text := node.String()
+        if !s.ScanNumbers || !Luhn(text) || digitsOnly(text) != text {
+            return node, false
+        }
+        return s.apply(text, []match{{start: 0, end: len(text), det: s.card}}, path, action, findings)
+    }
+    return v, false
+}
+
+func (s *Scanner) redactString(text, path string, action Action, findings *[]Finding) (interface{}, bool) {
+    matches := s.find(text)
+    if len(matches) == 0 {
+        return text, false
+    }
+    return s.apply(text, matches, path, action, findings)
+}
+
+func (s *Scanner) apply(text string, matches []match, path string, action Action, findings *[]Finding) (interface{}, bool) {
+    counts := map[string]int{}
+    var b strings.Builder
+    last := 0
+    for _, m := range matches {
+        counts[m.det.kind]++
+        b.WriteString(text[last:m.start])
+        value := text[m.start:m.end]
+        if action == ActionHash {
+            b.WriteString(s.hash(m.det, value))
+        } else {
+            b.WriteString("[REDACTED:" + m.det.kind + "]")
+        }
+        last = m.end
+    }
+    b.WriteString(text[last:])
+
+    kinds := make([]string, 0, len(counts))
+    for k := range counts {
+        kinds = append(kinds, k)
+    }
+    sort.Strings(kinds)
+    for _, k := range kinds {
+        *findings = append(*findings, Finding{Path: pathOrRoot(path), Kind: k, Count: counts[k]})
+    }
+
+    switch action {
+    case ActionDrop:
+        return nil, true
+    case ActionReject:
+        return text, false
+    }
+    return b.String(), false
+}
+
+// hash renders an identifier as "[<kind>:<hmac>]" over its normalised form.
+func (s *Scanner) hash(d *detector, value string) string {
+    if d.normalize != nil {
+        value = d.normalize(value)
+    }
+    mac := hmac.New(sha256.New, s.key)
+    mac.Write([]byte(d.kind + ":" + value))
+    return "[" + d.kind + ":" + hex.EncodeToString(mac.Sum(nil))[:hashLength] + "]"
+}
+
+func join(path, seg string) string {
+    if path == "" {
+        return seg
+    }
+    return path + "." + seg
+}
+
+func pathOrRoot(path string) string {
+    if path == "" {
+        return "$"
+    }
+    return path
+}
+
+func digitsOnly(s string) string {
+    return strings.Map(func(r rune) rune {
+        if r >= '0' && r <= '9' {
+            return r
+        }
+        return -1
+    }, s)
+}
+
+func stripSpaces(s string) string {
+    return strings.Map(func(r rune) rune {
+        if unicode.IsSpace(r) {
+            return -1
+        }
+        return r
+    }, s)
+}
+
+// Luhn reports whether the digits of s (13-19 of them, separators ignored)
+// pass the Luhn checksum used by payment cards.
+func Luhn(s string) bool {
+    digits := digitsOnly(s)
+    if len(digits) < 13 || len(digits) > 19 {
+        return false
+    }
+    sum := 0
+    for i := len(digits) - 1; i >= 0; i-- {
+        d := int(digits[i] - '0')
+        if (len(digits)-1-i)%2 == 1 {
+            d *= 2
+            if d > 9 {
+                d -= 9
+            }
+        }
+        sum += d
+    }
+    return sum%10 == 0
+}
+
+// validIBAN checks the ISO 13616 mod-97 checksum.
+func validIBAN(s string) bool {
+    iban := stripSpaces(s)
+    if len(iban) < 15 || len(iban) > 34 {
+        return false
+    }
+    rearranged := iban[4:] + iban[:4]
+    rem := 0
+    for _, r := range rearranged {
+        switch {
+        case r >= '0' && r <= '9':
+            rem = (rem*10 + int(r-'0')) % 97
+        case r >= 'A' && r <= 'Z':
+            rem = (rem*100 + int(r-'A'+10)) % 97
+        default:
+            return false
+        }
+    }
+    return rem == 1
+}
+
+func validIP(s string) bool {
+    return net.ParseIP(s) != nil
+}
+
+// validIPv6 also requires a few hex digits so that "std::map" or "a::b" in
+// prose are not reported.
+func validIPv6(s string) bool {
+    if i := strings.IndexByte(s, '%'); i >= 0 {
+        s = s[:i]
+    }
+    groups, digits := 0, 0
+    for _, g := range strings.Split(s, ":") {
+        if g != "" {
+            groups++
+            digits += len(g)
+        }
+    }
+    return groups >= 2 && digits >= 4 && net.ParseIP(s) != nil
+}
+
+// validPhone accepts 7-15 digits; numbers without a leading "+" must be
+// written with separators so plain numeric IDs are not mistaken for phones.
+// Dates are excluded, and so are decimals: dots alone only separate a phone
+// number with at least two of them, as in 555.123.4567.
+func validPhone(s string) bool {
+    n := len(digitsOnly(s))
+    if n < 7 || n > 15 || dateRe.MatchString(s) {
+        return false
+    }
+    if strings.HasPrefix(s, "+") || strings.ContainsAny(s, " -()") {
+        return true
+    }
+    return strings.Count(s, ".") >= 2
+}
+
+// partOfNumber reports whether text[start:end] is directly preceded or
+// followed by a digit, or by a decimal point next to one.
+func partOfNumber(text string, start, end int) bool {
+    if start > 0 && (isDigit(text[start-1]) || text[start-1] == '.' && start > 1 && isDigit(text[start-2])) {
+        return true
+    }
+    if end < len(text) && (isDigit(text[end]) || text[end] == '.' && end+1 < len(text) && isDigit(text[end+1])) {
+        return true
+    }
+    return false
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
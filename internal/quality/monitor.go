@@ -0,0 +1,206 @@
+package quality
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/google/uuid"
+)
+
+// Monitor checks every new inference against its model's profile and
+// records the violations it finds. Profiles are cached in memory; changed
+// profiles are written back by Run every FlushInterval rather than on every
+// ingest, or after each observation when FlushInterval is zero. With several
+// replicas the last write wins, which only affects the running counts, not
+// the frozen expectations.
+type Monitor struct {
+    Repo            repository.QualityRepository
+    LearningSamples int64
+    FlushInterval   time.Duration
+
+    mu       sync.Mutex
+    profiles map[string]*models.QualityProfile
+    // dirty holds the models whose profile changed since the last flush.
+    dirty map[string]bool
+}
+
+// NewMonitor returns a monitor that learns expectations from the first
+// learningSamples inputs of each model.
+func NewMonitor(repo repository.QualityRepository, learningSamples int64) *Monitor {
+    if learningSamples <= 0 {
+        learningSamples = DefaultLearningSamples
+    }
+    return &Monitor{
+        Repo:            repo,
+        LearningSamples: learningSamples,
+        profiles:        map[string]*models.QualityProfile{},
+        dirty:           map[string]bool{},
+    }
+}
+
+// ObserveInference profiles the input of a newly stored inference.
+func (m *Monitor) ObserveInference(ctx context.Context, inf models.Inference) error {
+    doc, err := jsonpath.Decode(inf.InputData)
+    if err != nil {
+        return fmt.Errorf("decode input_data: %w", err)
+    }
+
+    m.mu.Lock()
+    p, err := m.profile(ctx, inf.ModelName)
+    if err != nil {
+        m.mu.Unlock()
+        return err
+    }
+    violations := Observe(p, Flatten(doc), m.LearningSamples)
+    if m.FlushInterval > 0 {
+        m.dirty[inf.ModelName] = true
+        m.mu.Unlock()
+    } else {
+        snapshot := copyProfile(p)
+        m.mu.Unlock()
+        if err := m.Repo.SaveProfile(ctx, snapshot); err != nil {
+            return err
+        }
+    }
+
+    canonical, _ := json.Marshal(doc)
+    sum := sha256.Sum256(canonical)
+    seen, err := m.Repo.RecordInputHash(ctx, inf.ModelName, hex.EncodeToString(sum[:]))
+    if err != nil {
+        return err
+    }
+    if seen > 1 {
+        violations = append(violations, models.QualityViolation{
+            Field:  WholeInput,
+            Kind:   KindDuplicate,
+            Detail: fmt.Sprintf("input seen %d times", seen),
+        })
+    }
+
+    for i := range violations {
+        violations[i].ID = uuid.New().String()
+        violations[i].InferenceID = inf.ID
+        violations[i].ModelName = inf.ModelName
+    }
+    return m.Repo.InsertViolations(ctx, violations)
+}
+
+// Profile returns a copy of the current profile for a model.
+func (m *Monitor) Profile(ctx context.Context, model string) (*models.QualityProfile, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    p, err := m.profile(ctx, model)
+    if err != nil {
+        return nil, err
+    }
+    return copyProfile(p), nil
+}
+
+// SetBounds replaces the declared bounds of the given fields. A nil entry
+// clears the declaration so the field falls back to its learned bounds.
+func (m *Monitor) SetBounds(ctx context.Context, model string, bounds map[string]*models.FieldBounds) (*models.QualityProfile, error) {
+    m.mu.Lock()
+    p, err := m.profile(ctx, model)
+    if err != nil {
+        m.mu.Unlock()
+        return nil, err
+    }
+    for name, b := range bounds {
+        f, ok := p.Fields[name]
+        if !ok {
+            f = &models.FieldProfile{}
+            p.Fields[name] = f
+        }
+        f.Declared = b
+    }
+    snapshot := copyProfile(p)
+    delete(m.dirty, model)
+    m.mu.Unlock()
+
+    if err := m.Repo.SaveProfile(ctx, snapshot); err != nil {
+        return nil, err
+    }
+    return snapshot, nil
+}
+
+// Run saves changed profiles every FlushInterval until ctx is cancelled,
+// then saves them one last time. Stop the callers of ObserveInference first.
+func (m *Monitor) Run(ctx context.Context) {
+    log.Printf("Starting data quality profile flusher (every %s)\n", m.FlushInterval)
+    ticker := time.NewTicker(m.FlushInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := m.Flush(context.Background()); err != nil {
+                log.Printf("Error saving data quality profiles: %v\n", err)
+            }
+            log.Println("Data quality profile flusher stopped")
+            return
+        case <-ticker.C:
+            if err := m.Flush(ctx); err != nil {
+                log.Printf("Error saving data quality profiles: %v\n", err)
+            }
+        }
+    }
+}
+
+// Flush saves the profiles changed since the last flush. Profiles that fail
+// to save are kept for the next flush.
+func (m *Monitor) Flush(ctx context.Context) error {
+    m.mu.Lock()
+    snapshots := make([]*models.QualityProfile, 0, len(m.dirty))
+    for model := range m.dirty {
+        snapshots = append(snapshots, copyProfile(m.profiles[model]))
+    }
+    m.dirty = map[string]bool{}
+    m.mu.Unlock()
+
+    var errs []error
+    for _, p := range snapshots {
+        if err := m.Repo.SaveProfile(ctx, p); err != nil {
+            m.mu.Lock()
+            m.dirty[p.ModelName] = true
+            m.mu.Unlock()
+            errs = append(errs, fmt.Errorf("%s: %w", p.ModelName, err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// profile returns the cached profile, loading it on first use. m.mu must be
+// held.
+func (m *Monitor) profile(ctx context.Context, model string) (*models.QualityProfile, error) {
+    if p, ok := m.profiles[model]; ok {
+        return p, nil
+    }
+    p, err := m.Repo.GetProfile(ctx, model)
+    if err != nil {
+        return nil, err
+    }
+    if p == nil {
+        p = NewProfile(model)
+    }
+    if p.Fields == nil {
+        p.Fields = map[string]*models.FieldProfile{}
+    }
+    m.profiles[model] = p
+    return p, nil
+}
+
+func copyProfile(p *models.QualityProfile) *models.QualityProfile {
+    raw, _ := json.Marshal(p)
+    var out models.QualityProfile
+    json.Unmarshal(raw, &out)
+    return &out
+}
@@ -0,0 +1,267 @@
+// Package quality profiles the input_data of each model and flags payloads
+// that do not look like the traffic the model was trained and observed on:
+// missing or null fields, type changes, out-of-range numbers, unseen
+// categories and duplicate inputs.
+package quality
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// Violation kinds.
+const (
+    KindMissing        = "missing"
+    KindNull           = "null"
+    KindTypeMismatch   = "type_mismatch"
+    KindOutOfRange     = "out_of_range"
+    KindUnseenCategory = "unseen_category"
+    KindDuplicate      = "duplicate"
+)
+
+// WholeInput is the field name used for violations that concern the whole
+// payload rather than one path, such as duplicates.
+const WholeInput = "$"
+
+const (
+    // DefaultLearningSamples is the number of inputs profiled before learned
+    // expectations are frozen and enforced.
+    DefaultLearningSamples = 1000
+
+    // MaxCategories is the number of distinct string values a field may show
+    // during learning and still be treated as categorical.
+    MaxCategories = 50
+
+    // RangeTolerance widens learned numeric bounds by this fraction of the
+    // observed span on each side, so values just past the extremes of the
+    // learning sample are not flagged.
+    RangeTolerance = 0.1
+)
+
+// Flatten returns the leaf values of a decoded JSON document keyed by dotted
+// path. Arrays are treated as leaves; a non-object document has no fields.
+func Flatten(doc interface{}) map[string]interface{} {
+    out := map[string]interface{}{}
+    obj, ok := doc.(map[string]interface{})
+    if !ok {
+        return out
+    }
+    flatten("", obj, out)
+    return out
+}
+
+func flatten(prefix string, obj map[string]interface{}, out map[string]interface{}) {
+    for k, v := range obj {
+        path := k
+        if prefix != "" {
+            path = prefix + "." + k
+        }
+        if child, ok := v.(map[string]interface{}); ok && len(child) > 0 {
+            flatten(path, child, out)
+            continue
+        }
+        out[path] = v
+    }
+}
+
+// NewProfile returns an empty profile for a model.
+func NewProfile(model string) *models.QualityProfile {
+    return &models.QualityProfile{
+        ModelName: model,
+        Fields:    map[string]*models.FieldProfile{},
+    }
+}
+
+// Learning reports whether the profile is still in its learning period.
+func Learning(p *models.QualityProfile, learningSamples int64) bool {
+    return p.Samples < learningSamples
+}
+
+// Expected returns the bounds a field is checked against: the declared bounds
+// if any, otherwise the learned ones. It returns nil when there is nothing
+// to check.
+func Expected(f *models.FieldProfile) *models.FieldBounds {
+    if f.Declared != nil {
+        return f.Declared
+    }
+    return f.Learned
+}
+
+// Observe checks a flattened input against the profile and then folds it
+// into the profile. Returned violations only carry Field, Kind and Detail.
+func Observe(p *models.QualityProfile, fields map[string]interface{}, learningSamples int64) []models.QualityViolation {
+    violations := Check(p, fields)
+    update(p, fields, learningSamples)
+    return violations
+}
+
+// Check returns the violations of a flattened input against the profile
+// without modifying it.
+func Check(p *models.QualityProfile, fields map[string]interface{}) []models.QualityViolation {
+    var out []models.QualityViolation
+    add := func(field, kind, detail string) {
+        out = append(out, models.QualityViolation{Field: field, Kind: kind, Detail: detail})
+    }
+
+    for _, name := range sortedFields(p) {
+        exp := Expected(p.Fields[name])
+        if exp == nil {
+            continue
+        }
+        v, present := fields[name]
+        if !present {
+            if exp.Required {
+                add(name, KindMissing, "field is missing")
+            }
+            continue
+        }
+        if v == nil {
+            if exp.NotNull {
+                add(name, KindNull, "field is null")
+            }
+            continue
+        }
+
+        actual := jsonpath.TypeOf(v)
+        if exp.Type != "" && !typeMatches(exp.Type, v) {
+            add(name, KindTypeMismatch, fmt.Sprintf("expected %s, got %s", exp.Type, actual))
+            continue
+        }
+        switch val := v.(type) {
+        case float64:
+            if exp.Min != nil && val < *exp.Min {
+                add(name, KindOutOfRange, fmt.Sprintf("%s is below minimum %s", formatFloat(val), formatFloat(*exp.Min)))
+            } else if exp.Max != nil && val > *exp.Max {
+                add(name, KindOutOfRange, fmt.Sprintf("%s is above maximum %s", formatFloat(val), formatFloat(*exp.Max)))
+            }
+        case string:
+            if len(exp.Allowed) > 0 && !contains(exp.Allowed, val) {
+                add(name, KindUnseenCategory, fmt.Sprintf("unseen value %q", val))
+            }
+        }
+    }
+    return out
+}
+
+// update folds one input into the running counts, and during the learning
+// period into the learned ranges and categories.
+func update(p *models.QualityProfile, fields map[string]interface{}, learningSamples int64) {
+    learning := Learning(p, learningSamples)
+    p.Samples++
+    for name, v := range fields {
+        f, ok := p.Fields[name]
+        if !ok {
+            f = &models.FieldProfile{}
+            p.Fields[name] = f
+        }
+        f.Present++
+        if f.Types == nil {
+            f.Types = map[string]int64{}
+        }
+        f.Types[jsonpath.TypeOf(v)]++
+        if v == nil {
+            f.Nulls++
+            continue
+        }
+        if !learning {
+            continue
+        }
+        switch val := v.(type) {
+        case float64:
+            if f.Min == nil || val < *f.Min {
+                f.Min = floatPtr(val)
+            }
+            if f.Max == nil || val > *f.Max {
+                f.Max = floatPtr(val)
+            }
+        case string:
+            if f.HighCardinality {
+                continue
+            }
+            if f.Categories == nil {
+                f.Categories = map[string]int64{}
+            }
+            f.Categories[val]++
+            if len(f.Categories) > MaxCategories {
+                f.HighCardinality = true
+                f.Categories = nil
+            }
+        }
+    }
+    if learning && !Learning(p, learningSamples) {
+        freeze(p)
+    }
+}
+
+// freeze derives learned expectations from the learning sample. A field is
+// required if it was present in every input and not-null if it was never
+// null; its type is the only non-null type it showed, if there was one.
+func freeze(p *models.QualityProfile) {
+    for _, f := range p.Fields {
+        b := &models.FieldBounds{
+            Required: f.Present == p.Samples,
+            NotNull:  f.Nulls == 0,
+        }
+        var types []string
+        for t := range f.Types {
+            if t != "null" {
+                types = append(types, t)
+            }
+        }
+        if len(types) == 1 {
+            b.Type = types[0]
+        }
+        if f.Min != nil && f.Max != nil {
+            margin := (*f.Max - *f.Min) * RangeTolerance
+            b.Min = floatPtr(*f.Min - margin)
+            b.Max = floatPtr(*f.Max + margin)
+        }
+        if !f.HighCardinality && len(f.Categories) > 0 {
+            for c := range f.Categories {
+                b.Allowed = append(b.Allowed, c)
+            }
+            sort.Strings(b.Allowed)
+        }
+        f.Learned = b
+    }
+}
+
+// typeMatches accepts the JSON type names from TypeOf plus "integer".
+func typeMatches(expected string, v interface{}) bool {
+    if expected == "integer" {
+        f, ok := v.(float64)
+        return ok && f == math.Trunc(f)
+    }
+    return jsonpath.TypeOf(v) == expected
+}
+
+func sortedFields(p *models.QualityProfile) []string {
+    names := make([]string, 0, len(p.Fields))
+    for name := range p.Fields {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func contains(list []string, s string) bool {
+    for _, v := range list {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
+
+func formatFloat(f float64) string {
+    return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func floatPtr(f float64) *float64 {
+    return &f
+}
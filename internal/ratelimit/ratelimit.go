@@ -0,0 +1,84 @@
+// Package ratelimit implements per-client token buckets.
+package ratelimit
+
+import (
+    "math"
+    "sync"
+    "time"
+)
+
+// sweepInterval is how often buckets that have refilled are dropped.
+const sweepInterval = time.Minute
+
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+// Limiter holds one token bucket per key. Each bucket holds up to Burst
+// tokens and refills at Rate tokens per second; a request takes one token.
+type Limiter struct {
+    Rate  float64
+    Burst int
+    // Now returns the current time; tests override it.
+    Now   func() time.Time
+
+    mu        sync.Mutex
+    buckets   map[string]*bucket
+    lastSweep time.Time
+}
+
+// New returns a limiter allowing rate requests per second per key with
+// bursts of up to burst requests.
+func New(rate float64, burst int) *Limiter {
+    if burst < 1 {
+        burst = 1
+    }
+    return &Limiter{Rate: rate, Burst: burst, Now: time.Now, buckets: map[string]*bucket{}}
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := l.Now()
+    l.sweep(now)
+
+    b, ok := l.buckets[key]
+    if !ok {
+        b = &bucket{tokens: float64(l.Burst), last: now}
+        l.buckets[key] = b
+    }
+    if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+        b.tokens = math.Min(float64(l.Burst), b.tokens+elapsed*l.Rate)
+    }
+    b.last = now
+    if b.tokens >= 1 {
+        b.tokens--
+        return true, 0
+    }
+    wait := time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+    return false, wait
+}
+
+// Len returns the number of tracked keys.
+func (l *Limiter) Len() int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return len(l.buckets)
+}
+
+// sweep drops buckets that would be full by now, which behave exactly like
+// new ones.
+func (l *Limiter) sweep(now time.Time) {
+    if now.Sub(l.lastSweep) < sweepInterval {
+        return
+    }
+    l.lastSweep = now
+    for key, b := range l.buckets {
+        if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+            delete(l.buckets, key)
+        }
+    }
+}
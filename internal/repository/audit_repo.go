@@ -0,0 +1,105 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/audit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// auditLockKey is the advisory lock serialising appends, so every entry is
+// chained to the one before it.
+const auditLockKey = 0x61756469
+
+// AuditRepository appends to and pages through the audit log. The table
+// rejects UPDATE, DELETE and TRUNCATE.
+type AuditRepository interface {
+    Append(ctx context.Context, entries ...models.AuditEntry) ([]models.AuditEntry, error)
+    ListEntries(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error)
+}
+
+type auditRepo struct {
+    db    *sql.DB
+    chain *audit.Chain
+}
+
+func NewAuditRepository(db *sql.DB, chain *audit.Chain) AuditRepository {
+    return &auditRepo{db: db, chain: chain}
+}
+
+// Append links the entries, in order, to the last entry and stores them in
+// one transaction, under one lock. It returns them with their sequence
+// numbers and hashes set.
+func (r *auditRepo) Append(ctx context.Context, entries ...models.AuditEntry) ([]models.AuditEntry, error) {
+    entries = append([]models.AuditEntry(nil), entries...)
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("Append: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+        return nil, fmt.Errorf("Append: %w", err)
+    }
+    prevSeq, prevHash := int64(0), audit.GenesisHash
+    err = tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`).
+        Scan(&prevSeq, &prevHash)
+    if err != nil && err != sql.ErrNoRows {
+        return nil, fmt.Errorf("Append: %w", err)
+    }
+
+    query := `
+        INSERT INTO audit_log (seq, actor, remote_addr, method, route, resource_id, status, outcome,
+            created_at, prev_hash, hash)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+    for i := range entries {
+        e := &entries[i]
+        r.chain.Link(e, prevSeq, prevHash)
+        if _, err := tx.ExecContext(ctx, query, e.Seq, e.Actor, e.RemoteAddr, e.Method, e.Route,
+            e.ResourceID, e.Status, e.Outcome, e.CreatedAt, e.PrevHash, e.Hash); err != nil {
+            return nil, fmt.Errorf("Append: %w", err)
+        }
+        prevSeq, prevHash = e.Seq, e.Hash
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("Append: %w", err)
+    }
+    return entries, nil
+}
+
+// ListEntries returns up to q.Limit entries after q.AfterSeq in sequence
+// order.
+func (r *auditRepo) ListEntries(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error) {
+    query := `
+        SELECT seq, actor, remote_addr, method, route, resource_id, status, outcome,
+            created_at, prev_hash, hash
+        FROM audit_log
+        WHERE seq > $1
+          AND ($2 = '' OR actor = $2)
+          AND ($3 = '' OR resource_id = $3)
+        ORDER BY seq
+        LIMIT $4
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.AfterSeq, q.Actor, q.ResourceID, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListEntries: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.AuditEntry
+    for rows.Next() {
+        var e models.AuditEntry
+        if err := rows.Scan(&e.Seq, &e.Actor, &e.RemoteAddr, &e.Method, &e.Route, &e.ResourceID,
+            &e.Status, &e.Outcome, &e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+            return nil, err
+        }
+        out = append(out, e)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListEntries: %w", err)
+    }
+    return out, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// encryptedInferenceRepo encrypts input_data and output_data on write and
+// decrypts them on read.
+type encryptedInferenceRepo struct {
+    InferenceRepository
+    codec *envelope.Codec
+}
+
+// NewEncryptedInferenceRepository wraps an InferenceRepository with payload
+// encryption.
+func NewEncryptedInferenceRepository(inner InferenceRepository, codec *envelope.Codec) InferenceRepository {
+    return &encryptedInferenceRepo{InferenceRepository: inner, codec: codec}
+}
+
+func (r *encryptedInferenceRepo) InsertInference(ctx context.Context, inf models.Inference) error {
+    var err error
+    if inf.InputData, err = r.codec.Encrypt("input_data", inf.ID, inf.InputData); err != nil {
+        return fmt.Errorf("InsertInference: encrypt input_data: %w", err)
+    }
+    if inf.OutputData, err = r.codec.Encrypt("output_data", inf.ID, inf.OutputData); err != nil {
+        return fmt.Errorf("InsertInference: encrypt output_data: %w", err)
+    }
+    return r.InferenceRepository.InsertInference(ctx, inf)
+}
+
+func (r *encryptedInferenceRepo) GetInferenceByID(ctx context.Context, inferenceID string) (*models.Inference, error) {
+    inf, err := r.InferenceRepository.GetInferenceByID(ctx, inferenceID)
+    if err != nil {
+        return nil, err
+    }
+    if err := r.decrypt(inf); err != nil {
+        return nil, fmt.Errorf("GetInferenceByID: %w", err)
+    }
+    return inf, nil
+}
+
+func (r *encryptedInferenceRepo) ListInferencesByTrace(ctx context.Context, traceID string) ([]models.Inference, error) {
+    infs, err := r.InferenceRepository.ListInferencesByTrace(ctx, traceID)
+    if err != nil {
+        return nil, err
+    }
+    for i := range infs {
+        if err := r.decrypt(&infs[i]); err != nil {
+            return nil, fmt.Errorf("ListInferencesByTrace: %w", err)
+        }
+    }
+    return infs, nil
+}
+
+func (r *encryptedInferenceRepo) decrypt(inf *models.Inference) error {
+    var err error
+    if inf.InputData, err = r.codec.Decrypt("input_data", inf.ID, inf.InputData); err != nil {
+        return fmt.Errorf("decrypt input_data of %s: %w", inf.ID, err)
+    }
+    if inf.OutputData, err = r.codec.Decrypt("output_data", inf.ID, inf.OutputData); err != nil {
+        return fmt.Errorf("decrypt output_data of %s: %w", inf.ID, err)
+    }
+    return nil
+}
+
+// encryptedFeedbackRepo encrypts feedback_data on write and decrypts it on
+// read.
+type encryptedFeedbackRepo struct {
+    FeedbackRepository
+    codec *envelope.Codec
+}
+
+// NewEncryptedFeedbackRepository wraps a FeedbackRepository with payload
+// encryption.
+func NewEncryptedFeedbackRepository(inner FeedbackRepository, codec *envelope.Codec) FeedbackRepository {
+    return &encryptedFeedbackRepo{FeedbackRepository: inner, codec: codec}
+}
+
+func (r *encryptedFeedbackRepo) InsertFeedback(ctx context.Context, fb models.Feedback) error {
+    var err error
+    if fb.FeedbackData, err = r.codec.Encrypt("feedback_data", fb.ID, fb.FeedbackData); err != nil {
+        return fmt.Errorf("InsertFeedback: encrypt feedback_data: %w", err)
+    }
+    return r.FeedbackRepository.InsertFeedback(ctx, fb)
+}
+
+func (r *encryptedFeedbackRepo) GetFeedbackByInferenceID(ctx context.Context, inferenceID string) ([]models.Feedback, error) {
+    fbs, err := r.FeedbackRepository.GetFeedbackByInferenceID(ctx, inferenceID)
+    if err != nil {
+        return nil, err
+    }
+    for i := range fbs {
+        if fbs[i].FeedbackData, err = r.codec.Decrypt("feedback_data", fbs[i].ID, fbs[i].FeedbackData); err != nil {
+            return nil, fmt.Errorf("GetFeedbackByInferenceID: decrypt feedback_data of %s: %w", fbs[i].ID, err)
+        }
+    }
+    return fbs, nil
+}
+
+// PayloadColumn identifies an encrypted JSONB column.
+type PayloadColumn struct {
+    Table  string
+    Column string
+}
+
+// PayloadColumns are the columns holding encrypted payloads.
+var PayloadColumns = []PayloadColumn{
+    {Table: "inferences", Column: "input_data"},
+    {Table: "inferences", Column: "output_data"},
+    {Table: "feedback", Column: "feedback_data"},
+}
+
+// StoredPayload is one stored payload as read for key rotation.
+type StoredPayload struct {
+    ID   string
+    Data string
+}
+
+// EnvelopeRepository finds and updates stored payloads that are not yet
+// encrypted under the primary master key.
+type EnvelopeRepository interface {
+    ListStale(ctx context.Context, col PayloadColumn, primaryKeyID, afterID string, limit int) ([]StoredPayload, error)
+    UpdatePayload(ctx context.Context, col PayloadColumn, id, data string) error
+}
+
+type envelopeRepo struct {
+    db *sql.DB
+}
+
+func NewEnvelopeRepository(db *sql.DB) EnvelopeRepository {
+    return &envelopeRepo{db: db}
+}
+
+// ListStale returns payloads that are plaintext or wrapped with another key,
+// ordered by ID and starting after afterID ("" starts at the beginning), so
+// callers can page past rows they failed to rewrap. col must be one of
+// PayloadColumns.
+func (r *envelopeRepo) ListStale(ctx context.Context, col PayloadColumn, primaryKeyID, afterID string, limit int) ([]StoredPayload, error) {
+    query := fmt.Sprintf(`
+        SELECT id, %[2]s::text
+        FROM %[1]s
+        WHERE %[2]s IS NOT NULL
+          AND (NOT %[2]s ? '%[3]s' OR %[2]s->'%[3]s'->>'kid' <> $1)
+          AND ($2::uuid IS NULL OR id > $2::uuid)
+        ORDER BY id
+        LIMIT $3
+    `, col.Table, col.Column, envelope.Field)
+    var after sql.NullString
+    if afterID != "" {
+        after = sql.NullString{String: afterID, Valid: true}
+    }
+    rows, err := r.db.QueryContext(ctx, query, primaryKeyID, after, limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListStale: %w", err)
+    }
+    defer rows.Close()
+
+    var out []StoredPayload
+    for rows.Next() {
+        var p StoredPayload
+        if err := rows.Scan(&p.ID, &p.Data); err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListStale: %w", err)
+    }
+    return out, nil
+}
+
+func (r *envelopeRepo) UpdatePayload(ctx context.Context, col PayloadColumn, id, data string) error {
+    query := fmt.Sprintf(`UPDATE %s SET %s = $1::jsonb WHERE id = $2`, col.Table, col.Column)
+    if _, err := r.db.ExecContext(ctx, query, data, id); err != nil {
+        return fmt.Errorf("UpdatePayload: %w", err)
+    }
+    return nil
+}
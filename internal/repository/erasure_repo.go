@@ -0,0 +1,137 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// ErasureRepository erases a subject's inferences and LLM calls in batches
+// and tracks the erasure jobs.
+type ErasureRepository interface {
+    // EraseBatch deletes or anonymizes up to limit inferences and limit LLM
+    // calls of the subject and counts the rows affected. It returns a zero
+    // batch once nothing is left.
+    EraseBatch(ctx context.Context, subjectID, mode string, limit int) (models.ErasureBatch, error)
+    InsertJob(ctx context.Context, job models.ErasureJob) error
+    UpdateJob(ctx context.Context, job models.ErasureJob) error
+    GetJob(ctx context.Context, id string) (*models.ErasureJob, error)
+}
+
+type erasureRepo struct {
+    db *sql.DB
+}
+
+func NewErasureRepository(db *sql.DB) ErasureRepository {
+    return &erasureRepo{db: db}
+}
+
+func (r *erasureRepo) EraseBatch(ctx context.Context, subjectID, mode string, limit int) (models.ErasureBatch, error) {
+    var query string
+    switch mode {
+    case models.ErasureModeDelete:
+        // Feedback also cascades, but is deleted explicitly to count it.
+        // Evaluation scores, PII redactions and data quality violations
+        // cascade with the inferences.
+        query = `
+            WITH batch AS (
+                SELECT id FROM inferences WHERE subject_id = $1 LIMIT $2
+            ), fb AS (
+                DELETE FROM feedback WHERE inference_id IN (SELECT id FROM batch) RETURNING id
+            ), inf AS (
+                DELETE FROM inferences WHERE id IN (SELECT id FROM batch) RETURNING id
+            ), llm AS (
+                DELETE FROM llm_calls
+                WHERE id IN (SELECT id FROM llm_calls WHERE subject_id = $1 LIMIT $2)
+                RETURNING id
+            )
+            SELECT (SELECT COUNT(*) FROM inf), (SELECT COUNT(*) FROM fb), (SELECT COUNT(*) FROM llm)
+        `
+    case models.ErasureModeAnonymize:
+        // Violation and evaluation details can quote payload values, so they
+        // are cleared with the payloads; their kinds, labels and scores stay.
+        query = `
+            WITH batch AS (
+                SELECT id FROM inferences WHERE subject_id = $1 LIMIT $2
+            ), fb AS (
+                UPDATE feedback SET feedback_data = '{}'::jsonb
+                WHERE inference_id IN (SELECT id FROM batch)
+                RETURNING id
+            ), dq AS (
+                UPDATE data_quality_violations SET detail = ''
+                WHERE inference_id IN (SELECT id FROM batch)
+            ), ev AS (
+                UPDATE evaluation_scores SET detail = NULL
+                WHERE inference_id IN (SELECT id FROM batch)
+            ), inf AS (
+                UPDATE inferences
+                SET input_data = '{}'::jsonb, output_data = '{}'::jsonb, tags = '{}'::jsonb,
+                    request_id = NULL, subject_id = NULL
+                WHERE id IN (SELECT id FROM batch)
+                RETURNING id
+            ), llm AS (
+                UPDATE llm_calls
+                SET prompt = '', completion = '', tags = '{}'::jsonb, subject_id = NULL
+                WHERE id IN (SELECT id FROM llm_calls WHERE subject_id = $1 LIMIT $2)
+                RETURNING id
+            )
+            SELECT (SELECT COUNT(*) FROM inf), (SELECT COUNT(*) FROM fb), (SELECT COUNT(*) FROM llm)
+        `
+    default:
+        return models.ErasureBatch{}, fmt.Errorf("EraseBatch: unknown mode %q", mode)
+    }
+
+    var b models.ErasureBatch
+    if err := r.db.QueryRowContext(ctx, query, subjectID, limit).Scan(&b.Inferences, &b.Feedback, &b.LLMCalls); err != nil {
+        return models.ErasureBatch{}, fmt.Errorf("EraseBatch: %w", err)
+    }
+    return b, nil
+}
+
+func (r *erasureRepo) InsertJob(ctx context.Context, job models.ErasureJob) error {
+    query := `
+        INSERT INTO erasure_jobs (id, subject_hash, mode, status, started_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+    if _, err := r.db.ExecContext(ctx, query, job.ID, job.SubjectHash, job.Mode, job.Status, job.StartedAt); err != nil {
+        return fmt.Errorf("InsertJob: %w", err)
+    }
+    return nil
+}
+
+// UpdateJob stores the progress and status of a job.
+func (r *erasureRepo) UpdateJob(ctx context.Context, job models.ErasureJob) error {
+    query := `
+        UPDATE erasure_jobs
+        SET status = $2, batches = $3, inferences = $4, feedback = $5, llm_calls = $6,
+            error = NULLIF($7, ''), finished_at = $8
+        WHERE id = $1
+    `
+    if _, err := r.db.ExecContext(ctx, query, job.ID, job.Status, job.Batches, job.Inferences,
+        job.Feedback, job.LLMCalls, job.Error, job.FinishedAt); err != nil {
+        return fmt.Errorf("UpdateJob: %w", err)
+    }
+    return nil
+}
+
+func (r *erasureRepo) GetJob(ctx context.Context, id string) (*models.ErasureJob, error) {
+    query := `
+        SELECT id, subject_hash, mode, status, batches, inferences, feedback, llm_calls,
+            COALESCE(error, ''), started_at, finished_at
+        FROM erasure_jobs
+        WHERE id = $1
+    `
+    var job models.ErasureJob
+    var finished sql.NullTime
+    err := r.db.QueryRowContext(ctx, query, id).Scan(&job.ID, &job.SubjectHash, &job.Mode, &job.Status,
+        &job.Batches, &job.Inferences, &job.Feedback, &job.LLMCalls, &job.Error, &job.StartedAt, &finished)
+    if err != nil {
+        return nil, fmt.Errorf("GetJob: %w", err)
+    }
+    if finished.Valid {
+        job.FinishedAt = &finished.Time
+    }
+    return &job, nil
+}
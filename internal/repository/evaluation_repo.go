@@ -0,0 +1,145 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// EvaluationRepository stores evaluator scores. A score replaces the earlier
+// one of the same evaluator for the same inference, so re-evaluating after
+// new feedback keeps one current value.
+type EvaluationRepository interface {
+    UpsertScores(ctx context.Context, scores []models.EvaluationScore) error
+    ListScoresByInference(ctx context.Context, inferenceID string) ([]models.EvaluationScore, error)
+    Aggregate(ctx context.Context, q models.WindowQuery, evaluator, bucket string) ([]models.EvaluationAggregate, error)
+}
+
+type evaluationRepo struct {
+    db *sql.DB
+}
+
+func NewEvaluationRepository(db *sql.DB) EvaluationRepository {
+    return &evaluationRepo{db: db}
+}
+
+func (r *evaluationRepo) UpsertScores(ctx context.Context, scores []models.EvaluationScore) error {
+    if len(scores) == 0 {
+        return nil
+    }
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("UpsertScores: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO evaluation_scores (id, inference_id, model_name, model_version, evaluator,
+            value, label, detail)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))
+        ON CONFLICT (inference_id, evaluator) DO UPDATE
+        SET value = EXCLUDED.value, label = EXCLUDED.label, detail = EXCLUDED.detail,
+            created_at = NOW()
+    `
+    for _, s := range scores {
+        if _, err := tx.ExecContext(ctx, query, s.ID, s.InferenceID, s.ModelName, s.ModelVersion,
+            s.Evaluator, s.Value, s.Label, s.Detail); err != nil {
+            return fmt.Errorf("UpsertScores: %w", err)
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("UpsertScores: %w", err)
+    }
+    return nil
+}
+
+func (r *evaluationRepo) ListScoresByInference(ctx context.Context, inferenceID string) ([]models.EvaluationScore, error) {
+    query := `
+        SELECT id, inference_id, model_name, model_version, evaluator, value,
+               COALESCE(label, ''), COALESCE(detail, ''), created_at
+        FROM evaluation_scores
+        WHERE inference_id = $1
+        ORDER BY evaluator
+    `
+    rows, err := r.db.QueryContext(ctx, query, inferenceID)
+    if err != nil {
+        return nil, fmt.Errorf("ListScoresByInference: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.EvaluationScore
+    for rows.Next() {
+        var s models.EvaluationScore
+        if err := rows.Scan(&s.ID, &s.InferenceID, &s.ModelName, &s.ModelVersion, &s.Evaluator,
+            &s.Value, &s.Label, &s.Detail, &s.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+// Aggregate summarises scores per evaluator and time bucket ("hour", "day"
+// or "week"). An empty evaluator matches all of them.
+func (r *evaluationRepo) Aggregate(ctx context.Context, q models.WindowQuery, evaluator, bucket string) ([]models.EvaluationAggregate, error) {
+    query := `
+        SELECT evaluator,
+               date_trunc($6, created_at AT TIME ZONE 'UTC') AS bucket,
+               COALESCE(label, ''),
+               COUNT(*), AVG(value), MIN(value), MAX(value)
+        FROM evaluation_scores
+        WHERE model_name = $1
+          AND ($2 = '' OR model_version = $2)
+          AND ($3 = '' OR evaluator = $3)
+          AND created_at >= $4 AND created_at < $5
+        GROUP BY evaluator, bucket, COALESCE(label, '')
+        ORDER BY evaluator, bucket
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.ModelVersion, evaluator, q.From, q.To, bucket)
+    if err != nil {
+        return nil, fmt.Errorf("Aggregate: %w", err)
+    }
+    defer rows.Close()
+
+    // Rows come per label; fold them into one aggregate per bucket.
+    var out []models.EvaluationAggregate
+    for rows.Next() {
+        var a models.EvaluationAggregate
+        var label string
+        if err := rows.Scan(&a.Evaluator, &a.BucketStart, &label, &a.Count, &a.Mean, &a.Min, &a.Max); err != nil {
+            return nil, err
+        }
+        a.BucketStart = a.BucketStart.UTC()
+        if label != "" {
+            a.Labels = map[string]int64{label: a.Count}
+        }
+        n := len(out)
+        if n > 0 && out[n-1].Evaluator == a.Evaluator && out[n-1].BucketStart.Equal(a.BucketStart) {
+            out[n-1] = mergeAggregates(out[n-1], a)
+            continue
+        }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+func mergeAggregates(a, b models.EvaluationAggregate) models.EvaluationAggregate {
+    total := a.Count + b.Count
+    a.Mean = (a.Mean*float64(a.Count) + b.Mean*float64(b.Count)) / float64(total)
+    a.Count = total
+    if b.Min < a.Min {
+        a.Min = b.Min
+    }
+    if b.Max > a.Max {
+        a.Max = b.Max
+    }
+    for label, n := range b.Labels {
+        if a.Labels == nil {
+            a.Labels = map[string]int64{}
+        }
+        a.Labels[label] += n
+    }
+    return a
+}
@@ -12,6 +12,7 @@ type InferenceRepository interface {
     InsertInference(ctx context.Context, inf models.Inference) error
     UpdateHasFeedback(ctx context.Context, inferenceID string, hasFeedback bool) error
     GetInferenceByID(ctx context.Context, inferenceID string) (*models.Inference, error)
+    ListInferencesByTrace(ctx context.Context, traceID string) ([]models.Inference, error)
 }
 
 type inferenceRepo struct {
@@ -24,11 +25,19 @@ func NewInferenceRepository(db *sql.DB) InferenceRepository {
 
 func (r *inferenceRepo) InsertInference(ctx context.Context, inf models.Inference) error {
     query := `
-        INSERT INTO inferences (id, model_name, model_version, input_data, output_data, has_feedback)
-        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6)
+        INSERT INTO inferences (id, model_name, model_version, input_data, output_data, tags, latency_ms,
+            request_id, is_shadow, has_feedback, trace_id, span_id, parent_span_id, started_at, subject_id)
+        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6::jsonb, $7, NULLIF($8, ''), $9, $10,
+            NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), $14, NULLIF($15, ''))
     `
+    tags := inf.Tags
+    if tags == "" {
+        tags = "{}"
+    }
     _, err := r.db.ExecContext(ctx, query,
-        inf.ID, inf.ModelName, inf.ModelVersion, inf.InputData, inf.OutputData, inf.HasFeedback)
+        inf.ID, inf.ModelName, inf.ModelVersion, inf.InputData, inf.OutputData, tags, inf.LatencyMs,
+        inf.RequestID, inf.IsShadow, inf.HasFeedback, inf.TraceID, inf.SpanID, inf.ParentSpanID, inf.StartedAt,
+        inf.SubjectID)
     return err
 }
 
@@ -54,18 +63,66 @@ func (r *inferenceRepo) UpdateHasFeedback(ctx context.Context, inferenceID strin
 }
 
 
+// inferenceColumns is the select list read by scanInference.
+const inferenceColumns = `id, model_name, model_version, input_data, output_data, tags, latency_ms,
+               COALESCE(request_id, ''), is_shadow, created_at, has_feedback,
+               COALESCE(trace_id, ''), COALESCE(span_id, ''), COALESCE(parent_span_id, ''), started_at,
+               COALESCE(subject_id, '')`
+
+func scanInference(row interface{ Scan(...interface{}) error }) (*models.Inference, error) {
+    var inf models.Inference
+    var latency sql.NullFloat64
+    var startedAt sql.NullTime
+    err := row.Scan(&inf.ID, &inf.ModelName, &inf.ModelVersion, &inf.InputData,
+        &inf.OutputData, &inf.Tags, &latency, &inf.RequestID, &inf.IsShadow, &inf.CreatedAt, &inf.HasFeedback,
+        &inf.TraceID, &inf.SpanID, &inf.ParentSpanID, &startedAt, &inf.SubjectID)
+    if err != nil {
+        return nil, err
+    }
+    if latency.Valid {
+        inf.LatencyMs = &latency.Float64
+    }
+    if startedAt.Valid {
+        inf.StartedAt = &startedAt.Time
+    }
+    return &inf, nil
+}
+
 func (r *inferenceRepo) GetInferenceByID(ctx context.Context, inferenceID string) (*models.Inference, error) {
     query := `
-        SELECT id, model_name, model_version, input_data, output_data, created_at, has_feedback
+        SELECT ` + inferenceColumns + `
         FROM inferences
         WHERE id = $1
     `
-    row := r.db.QueryRowContext(ctx, query, inferenceID)
-    var inf models.Inference
-    err := row.Scan(&inf.ID, &inf.ModelName, &inf.ModelVersion, &inf.InputData,
-        &inf.OutputData, &inf.CreatedAt, &inf.HasFeedback)
+    inf, err := scanInference(r.db.QueryRowContext(ctx, query, inferenceID))
     if err != nil {
         return nil, fmt.Errorf("GetInferenceByID: %w", err)
     }
-    return &inf, nil
+    return inf, nil
+}
+
+// ListInferencesByTrace returns every inference logged under a trace ID,
+// ordered by start time.
+func (r *inferenceRepo) ListInferencesByTrace(ctx context.Context, traceID string) ([]models.Inference, error) {
+    query := `
+        SELECT ` + inferenceColumns + `
+        FROM inferences
+        WHERE trace_id = $1
+        ORDER BY COALESCE(started_at, created_at), id
+    `
+    rows, err := r.db.QueryContext(ctx, query, traceID)
+    if err != nil {
+        return nil, fmt.Errorf("ListInferencesByTrace: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.Inference
+    for rows.Next() {
+        inf, err := scanInference(rows)
+        if err != nil {
+            return nil, fmt.Errorf("ListInferencesByTrace: %w", err)
+        }
+        out = append(out, *inf)
+    }
+    return out, rows.Err()
 }
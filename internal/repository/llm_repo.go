@@ -0,0 +1,204 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// LLMRepository stores LLM calls and aggregates their usage and latency.
+// An empty q.ModelName matches every model.
+type LLMRepository interface {
+    InsertCall(ctx context.Context, c models.LLMCall) error
+    GetCallByID(ctx context.Context, id string) (*models.LLMCall, error)
+    ListCallsByTrace(ctx context.Context, traceID string) ([]models.LLMCall, error)
+    Usage(ctx context.Context, q models.WindowQuery) ([]models.LLMUsage, error)
+    LatencyPercentiles(ctx context.Context, q models.WindowQuery, quantiles []float64) ([]models.LLMLatency, error)
+}
+
+type llmRepo struct {
+    db *sql.DB
+}
+
+func NewLLMRepository(db *sql.DB) LLMRepository {
+    return &llmRepo{db: db}
+}
+
+func (r *llmRepo) InsertCall(ctx context.Context, c models.LLMCall) error {
+    if c.Tags == "" {
+        c.Tags = "{}"
+    }
+    query := `
+        INSERT INTO llm_calls (id, model, provider, prompt, completion, prompt_tokens,
+            completion_tokens, cost_usd, latency_ms, finish_reason, tags, subject_id,
+            trace_id, span_id, parent_span_id, started_at)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11::jsonb, NULLIF($12, ''),
+            NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, ''), $16)
+    `
+    _, err := r.db.ExecContext(ctx, query, c.ID, c.Model, c.Provider, c.Prompt, c.Completion,
+        c.PromptTokens, c.CompletionTokens, c.CostUSD, c.LatencyMs, c.FinishReason, c.Tags, c.SubjectID,
+        c.TraceID, c.SpanID, c.ParentSpanID, c.StartedAt)
+    if err != nil {
+        return fmt.Errorf("InsertCall: %w", err)
+    }
+    return nil
+}
+
+// llmCallColumns is the select list read by scanLLMCall.
+const llmCallColumns = `id, model, COALESCE(provider, ''), prompt, completion, prompt_tokens,
+               completion_tokens, cost_usd, latency_ms, COALESCE(finish_reason, ''), tags,
+               COALESCE(subject_id, ''), COALESCE(trace_id, ''), COALESCE(span_id, ''),
+               COALESCE(parent_span_id, ''), started_at, created_at`
+
+func scanLLMCall(row interface{ Scan(...interface{}) error }) (models.LLMCall, error) {
+    var c models.LLMCall
+    err := row.Scan(&c.ID, &c.Model, &c.Provider, &c.Prompt, &c.Completion, &c.PromptTokens,
+        &c.CompletionTokens, &c.CostUSD, &c.LatencyMs, &c.FinishReason, &c.Tags, &c.SubjectID,
+        &c.TraceID, &c.SpanID, &c.ParentSpanID, &c.StartedAt, &c.CreatedAt)
+    return c, err
+}
+
+func (r *llmRepo) GetCallByID(ctx context.Context, id string) (*models.LLMCall, error) {
+    query := `
+        SELECT ` + llmCallColumns + `
+        FROM llm_calls
+        WHERE id = $1
+    `
+    c, err := scanLLMCall(r.db.QueryRowContext(ctx, query, id))
+    if err != nil {
+        return nil, fmt.Errorf("GetCallByID: %w", err)
+    }
+    return &c, nil
+}
+
+// ListCallsByTrace returns every call logged under a trace ID, ordered by
+// start time.
+func (r *llmRepo) ListCallsByTrace(ctx context.Context, traceID string) ([]models.LLMCall, error) {
+    query := `
+        SELECT ` + llmCallColumns + `
+        FROM llm_calls
+        WHERE trace_id = $1
+        ORDER BY COALESCE(started_at, created_at), id
+    `
+    rows, err := r.db.QueryContext(ctx, query, traceID)
+    if err != nil {
+        return nil, fmt.Errorf("ListCallsByTrace: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.LLMCall
+    for rows.Next() {
+        c, err := scanLLMCall(rows)
+        if err != nil {
+            return nil, fmt.Errorf("ListCallsByTrace: %w", err)
+        }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+// Usage returns call, token and cost totals per model and UTC day.
+func (r *llmRepo) Usage(ctx context.Context, q models.WindowQuery) ([]models.LLMUsage, error) {
+    query := `
+        SELECT model,
+               date_trunc('day', created_at AT TIME ZONE 'UTC') AS day,
+               COUNT(*),
+               COALESCE(SUM(prompt_tokens), 0),
+               COALESCE(SUM(completion_tokens), 0),
+               COALESCE(SUM(cost_usd), 0),
+               COUNT(*) FILTER (WHERE cost_usd IS NULL)
+        FROM llm_calls
+        WHERE ($1 = '' OR model = $1)
+          AND created_at >= $2 AND created_at < $3
+        GROUP BY model, day
+        ORDER BY day, model
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.From, q.To)
+    if err != nil {
+        return nil, fmt.Errorf("Usage: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.LLMUsage
+    for rows.Next() {
+        var u models.LLMUsage
+        if err := rows.Scan(&u.Model, &u.Day, &u.Calls, &u.PromptTokens, &u.CompletionTokens,
+            &u.CostUSD, &u.UnpricedCalls); err != nil {
+            return nil, err
+        }
+        u.Day = u.Day.UTC()
+        out = append(out, u)
+    }
+    return out, rows.Err()
+}
+
+// LatencyPercentiles returns continuous latency percentiles per model,
+// computed in the database.
+func (r *llmRepo) LatencyPercentiles(ctx context.Context, q models.WindowQuery, quantiles []float64) ([]models.LLMLatency, error) {
+    query := `
+        SELECT model,
+               COUNT(*),
+               AVG(latency_ms),
+               percentile_cont($4::float8[]) WITHIN GROUP (ORDER BY latency_ms)
+        FROM llm_calls
+        WHERE ($1 = '' OR model = $1)
+          AND created_at >= $2 AND created_at < $3
+          AND latency_ms IS NOT NULL
+        GROUP BY model
+        ORDER BY model
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.From, q.To, floatArrayLiteral(quantiles))
+    if err != nil {
+        return nil, fmt.Errorf("LatencyPercentiles: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.LLMLatency
+    for rows.Next() {
+        var l models.LLMLatency
+        var raw string
+        if err := rows.Scan(&l.Model, &l.Count, &l.Mean, &raw); err != nil {
+            return nil, err
+        }
+        values, err := parseFloatArray(raw)
+        if err != nil || len(values) != len(quantiles) {
+            return nil, fmt.Errorf("LatencyPercentiles: unexpected percentiles %q", raw)
+        }
+        l.Quantiles = map[string]float64{}
+        for i, qv := range quantiles {
+            l.Quantiles[strconv.FormatFloat(qv, 'f', -1, 64)] = values[i]
+        }
+        out = append(out, l)
+    }
+    return out, rows.Err()
+}
+
+// floatArrayLiteral renders a Postgres array literal such as {0.5,0.99}.
+func floatArrayLiteral(values []float64) string {
+    parts := make([]string, len(values))
+    for i, v := range values {
+        parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+    }
+    return "{" + strings.Join(parts, ",") + "}"
+}
+
+// parseFloatArray parses a Postgres float8[] in text form.
+func parseFloatArray(s string) ([]float64, error) {
+    s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+    if s == "" {
+        return nil, nil
+    }
+    var out []float64
+    for _, part := range strings.Split(s, ",") {
+        f, err := strconv.ParseFloat(part, 64)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, f)
+    }
+    return out, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "slices"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// MetricsRepository reads inferences together with their feedback so that
+// performance metrics can be computed over a time window.
+type MetricsRepository interface {
+    ListLabeledInferences(ctx context.Context, q models.WindowQuery) ([]models.LabeledInference, error)
+    ListShadowPairs(ctx context.Context, q models.WindowQuery, shadowVersion string) ([]models.ShadowPair, error)
+    SampleInputValues(ctx context.Context, q models.WindowQuery, path string) ([]string, error)
+}
+
+type metricsRepo struct {
+    db *sql.DB
+}
+
+func NewMetricsRepository(db *sql.DB) MetricsRepository {
+    return &metricsRepo{db: db}
+}
+
+// ListLabeledInferences returns the inferences in the window, each joined with
+// its most recent feedback row, oldest first. When more than q.Limit match,
+// the newest q.Limit are returned.
+func (r *metricsRepo) ListLabeledInferences(ctx context.Context, q models.WindowQuery) ([]models.LabeledInference, error) {
+    query := `
+        SELECT i.id, i.model_name, i.model_version, i.input_data, i.output_data, i.tags,
+               i.created_at, i.has_feedback, f.feedback_data
+        FROM inferences i
+        LEFT JOIN LATERAL (
+            SELECT feedback_data
+            FROM feedback
+            WHERE inference_id = i.id
+            ORDER BY created_at DESC
+            LIMIT 1
+        ) f ON TRUE
+        WHERE i.model_name = $1
+          AND ($2 = '' OR i.model_version = $2)
+          AND i.created_at >= $3 AND i.created_at < $4
+          AND ($5 = FALSE OR f.feedback_data IS NOT NULL)
+        ORDER BY i.created_at DESC
+        LIMIT $6
+    `
+    rows, err := r.db.QueryContext(ctx, query,
+        q.ModelName, q.ModelVersion, q.From, q.To, q.LabeledOnly, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListLabeledInferences: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.LabeledInference
+    for rows.Next() {
+        var li models.LabeledInference
+        var feedback sql.NullString
+        if err := rows.Scan(&li.ID, &li.ModelName, &li.ModelVersion, &li.InputData, &li.OutputData,
+            &li.Tags, &li.CreatedAt, &li.HasFeedback, &feedback); err != nil {
+            return nil, err
+        }
+        li.FeedbackData = feedback.String
+        out = append(out, li)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    slices.Reverse(out)
+    return out, nil
+}
+
+// ListShadowPairs joins primary inferences of q.ModelVersion with the shadow
+// inferences of shadowVersion that share their request_id, oldest first.
+// When more than q.Limit match, the newest q.Limit are returned.
+func (r *metricsRepo) ListShadowPairs(ctx context.Context, q models.WindowQuery, shadowVersion string) ([]models.ShadowPair, error) {
+    query := `
+        SELECT p.request_id, p.created_at, p.input_data, p.id, p.output_data, s.id, s.output_data
+        FROM inferences p
+        JOIN inferences s
+          ON s.request_id = p.request_id
+         AND s.model_name = p.model_name
+         AND s.is_shadow
+         AND s.model_version = $3
+        WHERE p.model_name = $1
+          AND p.model_version = $2
+          AND NOT p.is_shadow
+          AND p.request_id IS NOT NULL
+          AND p.created_at >= $4 AND p.created_at < $5
+        ORDER BY p.created_at DESC
+        LIMIT $6
+    `
+    rows, err := r.db.QueryContext(ctx, query,
+        q.ModelName, q.ModelVersion, shadowVersion, q.From, q.To, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListShadowPairs: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.ShadowPair
+    for rows.Next() {
+        var p models.ShadowPair
+        if err := rows.Scan(&p.RequestID, &p.CreatedAt, &p.InputData, &p.PrimaryID, &p.PrimaryOutput,
+            &p.ShadowID, &p.ShadowOutput); err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    slices.Reverse(out)
+    return out, nil
+}
+
+// SampleInputValues returns the JSON value at a dotted input_data path for up
+// to q.Limit inferences in the window. Rows are picked in a pseudo-random but
+// stable order (by hashed id) so the sample spans the whole window; rows
+// without the path are skipped.
+func (r *metricsRepo) SampleInputValues(ctx context.Context, q models.WindowQuery, path string) ([]string, error) {
+    query := `
+        SELECT input_data #> string_to_array($5, '.')
+        FROM inferences
+        WHERE model_name = $1
+          AND ($2 = '' OR model_version = $2)
+          AND created_at >= $3 AND created_at < $4
+          AND input_data #> string_to_array($5, '.') IS NOT NULL
+        ORDER BY md5(id::text)
+        LIMIT $6
+    `
+    rows, err := r.db.QueryContext(ctx, query,
+        q.ModelName, q.ModelVersion, q.From, q.To, path, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("SampleInputValues: %w", err)
+    }
+    defer rows.Close()
+
+    var out []string
+    for rows.Next() {
+        var v string
+        if err := rows.Scan(&v); err != nil {
+            return nil, err
+        }
+        out = append(out, v)
+    }
+    return out, rows.Err()
+}
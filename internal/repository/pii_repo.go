@@ -0,0 +1,82 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// PIIRepository stores the record of PII redacted from inference payloads.
+type PIIRepository interface {
+    InsertRedactions(ctx context.Context, rs []models.PIIRedaction) error
+    ListRedactions(ctx context.Context, q models.WindowQuery, inferenceID string) ([]models.PIIRedaction, error)
+}
+
+type piiRepo struct {
+    db *sql.DB
+}
+
+func NewPIIRepository(db *sql.DB) PIIRepository {
+    return &piiRepo{db: db}
+}
+
+func (r *piiRepo) InsertRedactions(ctx context.Context, rs []models.PIIRedaction) error {
+    if len(rs) == 0 {
+        return nil
+    }
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("InsertRedactions: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO pii_redactions (id, inference_id, model_name, payload, path, kind, action, count)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+    for _, red := range rs {
+        if _, err := tx.ExecContext(ctx, query, red.ID, red.InferenceID, red.ModelName,
+            red.Payload, red.Path, red.Kind, red.Action, red.Count); err != nil {
+            return fmt.Errorf("InsertRedactions: %w", err)
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("InsertRedactions: %w", err)
+    }
+    return nil
+}
+
+// ListRedactions returns the most recent redactions in the window. Empty
+// model and inference ID match everything.
+func (r *piiRepo) ListRedactions(ctx context.Context, q models.WindowQuery, inferenceID string) ([]models.PIIRedaction, error) {
+    query := `
+        SELECT id, inference_id, model_name, payload, path, kind, action, count, created_at
+        FROM pii_redactions
+        WHERE ($1 = '' OR model_name = $1)
+          AND ($2 = '' OR inference_id::text = $2)
+          AND created_at >= $3 AND created_at < $4
+        ORDER BY created_at DESC, path, kind
+        LIMIT $5
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, inferenceID, q.From, q.To, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListRedactions: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.PIIRedaction
+    for rows.Next() {
+        var red models.PIIRedaction
+        if err := rows.Scan(&red.ID, &red.InferenceID, &red.ModelName, &red.Payload, &red.Path,
+            &red.Kind, &red.Action, &red.Count, &red.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, red)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListRedactions: %w", err)
+    }
+    return out, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// QualityRepository stores data quality profiles, input hashes used for
+// duplicate detection, and the violations found in incoming inputs.
+type QualityRepository interface {
+    GetProfile(ctx context.Context, model string) (*models.QualityProfile, error)
+    SaveProfile(ctx context.Context, p *models.QualityProfile) error
+    RecordInputHash(ctx context.Context, model, hash string) (int64, error)
+    InsertViolations(ctx context.Context, vs []models.QualityViolation) error
+    ListViolations(ctx context.Context, q models.WindowQuery) ([]models.QualityViolation, error)
+    CountViolations(ctx context.Context, q models.WindowQuery) (map[string]map[string]int64, error)
+}
+
+type qualityRepo struct {
+    db *sql.DB
+}
+
+func NewQualityRepository(db *sql.DB) QualityRepository {
+    return &qualityRepo{db: db}
+}
+
+// GetProfile returns the stored profile for a model, or nil if none exists.
+func (r *qualityRepo) GetProfile(ctx context.Context, model string) (*models.QualityProfile, error) {
+    query := `
+        SELECT profile, updated_at
+        FROM data_quality_profiles
+        WHERE model_name = $1
+    `
+    var raw []byte
+    var updatedAt time.Time
+    err := r.db.QueryRowContext(ctx, query, model).Scan(&raw, &updatedAt)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("GetProfile: %w", err)
+    }
+    var p models.QualityProfile
+    if err := json.Unmarshal(raw, &p); err != nil {
+        return nil, fmt.Errorf("GetProfile: decode profile: %w", err)
+    }
+    p.ModelName = model
+    p.UpdatedAt = updatedAt
+    return &p, nil
+}
+
+func (r *qualityRepo) SaveProfile(ctx context.Context, p *models.QualityProfile) error {
+    raw, err := json.Marshal(p)
+    if err != nil {
+        return fmt.Errorf("SaveProfile: encode profile: %w", err)
+    }
+    query := `
+        INSERT INTO data_quality_profiles (model_name, profile, updated_at)
+        VALUES ($1, $2::jsonb, NOW())
+        ON CONFLICT (model_name) DO UPDATE
+        SET profile = EXCLUDED.profile, updated_at = EXCLUDED.updated_at
+    `
+    if _, err := r.db.ExecContext(ctx, query, p.ModelName, string(raw)); err != nil {
+        return fmt.Errorf("SaveProfile: %w", err)
+    }
+    return nil
+}
+
+// RecordInputHash counts one more occurrence of an input hash and returns the
+// total number of times it has been seen for the model, including this one.
+func (r *qualityRepo) RecordInputHash(ctx context.Context, model, hash string) (int64, error) {
+    query := `
+        INSERT INTO data_quality_input_hashes (model_name, input_hash)
+        VALUES ($1, $2)
+        ON CONFLICT (model_name, input_hash) DO UPDATE
+        SET seen_count = data_quality_input_hashes.seen_count + 1
+        RETURNING seen_count
+    `
+    var count int64
+    if err := r.db.QueryRowContext(ctx, query, model, hash).Scan(&count); err != nil {
+        return 0, fmt.Errorf("RecordInputHash: %w", err)
+    }
+    return count, nil
+}
+
+func (r *qualityRepo) InsertViolations(ctx context.Context, vs []models.QualityViolation) error {
+    if len(vs) == 0 {
+        return nil
+    }
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("InsertViolations: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO data_quality_violations (id, inference_id, model_name, field, kind, detail)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+    for _, v := range vs {
+        if _, err := tx.ExecContext(ctx, query,
+            v.ID, v.InferenceID, v.ModelName, v.Field, v.Kind, v.Detail); err != nil {
+            return fmt.Errorf("InsertViolations: %w", err)
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("InsertViolations: %w", err)
+    }
+    return nil
+}
+
+// ListViolations returns the most recent violations in the window.
+func (r *qualityRepo) ListViolations(ctx context.Context, q models.WindowQuery) ([]models.QualityViolation, error) {
+    query := `
+        SELECT id, inference_id, model_name, field, kind, detail, created_at
+        FROM data_quality_violations
+        WHERE model_name = $1
+          AND created_at >= $2 AND created_at < $3
+        ORDER BY created_at DESC
+        LIMIT $4
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.From, q.To, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListViolations: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.QualityViolation
+    for rows.Next() {
+        var v models.QualityViolation
+        if err := rows.Scan(&v.ID, &v.InferenceID, &v.ModelName, &v.Field, &v.Kind,
+            &v.Detail, &v.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, v)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListViolations: %w", err)
+    }
+    return out, nil
+}
+
+// CountViolations returns violation counts in the window keyed by field and
+// then by kind.
+func (r *qualityRepo) CountViolations(ctx context.Context, q models.WindowQuery) (map[string]map[string]int64, error) {
+    query := `
+        SELECT field, kind, COUNT(*)
+        FROM data_quality_violations
+        WHERE model_name = $1
+          AND created_at >= $2 AND created_at < $3
+        GROUP BY field, kind
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.From, q.To)
+    if err != nil {
+        return nil, fmt.Errorf("CountViolations: %w", err)
+    }
+    defer rows.Close()
+
+    out := map[string]map[string]int64{}
+    for rows.Next() {
+        var field, kind string
+        var n int64
+        if err := rows.Scan(&field, &kind, &n); err != nil {
+            return nil, err
+        }
+        if out[field] == nil {
+            out[field] = map[string]int64{}
+        }
+        out[field][kind] = n
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("CountViolations: %w", err)
+    }
+    return out, nil
+}
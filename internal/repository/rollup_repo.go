@@ -0,0 +1,346 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/sketch"
+)
+
+// Rollup stream names.
+const (
+    StreamInferences = "inferences"
+    StreamFeedback   = "feedback"
+)
+
+// ErrWatermarkMoved is returned by ApplyRollups when another worker advanced
+// the stream since the batch was read. The batch must be discarded.
+var ErrWatermarkMoved = errors.New("rollup watermark moved")
+
+// ZeroWatermark is the position before the first row of a stream.
+func ZeroWatermark(stream string) models.Watermark {
+    return models.Watermark{
+        Stream:    stream,
+        CreatedAt: time.Unix(0, 0).UTC(),
+        ID:        "00000000-0000-0000-0000-000000000000",
+    }
+}
+
+type RollupRepository interface {
+    GetWatermark(ctx context.Context, stream string) (models.Watermark, error)
+    FetchInferencesAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.Inference, error)
+    FetchFeedbackAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.FeedbackEvent, error)
+    ApplyRollups(ctx context.Context, from, to models.Watermark, deltas []models.Rollup) error
+    ListRollups(ctx context.Context, q models.WindowQuery) ([]models.Rollup, error)
+}
+
+type rollupRepo struct {
+    db *sql.DB
+}
+
+func NewRollupRepository(db *sql.DB) RollupRepository {
+    return &rollupRepo{db: db}
+}
+
+func (r *rollupRepo) GetWatermark(ctx context.Context, stream string) (models.Watermark, error) {
+    query := `
+        SELECT last_created_at, last_id
+        FROM rollup_watermarks
+        WHERE stream = $1
+    `
+    wm := models.Watermark{Stream: stream}
+    err := r.db.QueryRowContext(ctx, query, stream).Scan(&wm.CreatedAt, &wm.ID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return ZeroWatermark(stream), nil
+    }
+    if err != nil {
+        return wm, fmt.Errorf("GetWatermark: %w", err)
+    }
+    return wm, nil
+}
+
+// FetchInferencesAfter returns inferences strictly after the watermark and
+// created before until, in (created_at, id) order.
+func (r *rollupRepo) FetchInferencesAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.Inference, error) {
+    query := `
+        SELECT id, model_name, model_version, input_data, latency_ms, created_at
+        FROM inferences
+        WHERE (created_at, id) > ($1, $2) AND created_at < $3
+        ORDER BY created_at, id
+        LIMIT $4
+    `
+    rows, err := r.db.QueryContext(ctx, query, wm.CreatedAt, wm.ID, until, limit)
+    if err != nil {
+        return nil, fmt.Errorf("FetchInferencesAfter: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.Inference
+    for rows.Next() {
+        var inf models.Inference
+        var latency sql.NullFloat64
+        if err := rows.Scan(&inf.ID, &inf.ModelName, &inf.ModelVersion, &inf.InputData, &latency, &inf.CreatedAt); err != nil {
+            return nil, err
+        }
+        if latency.Valid {
+            inf.LatencyMs = &latency.Float64
+        }
+        out = append(out, inf)
+    }
+    return out, rows.Err()
+}
+
+// FetchFeedbackAfter returns feedback rows strictly after the watermark and
+// created before until, joined with their inference.
+func (r *rollupRepo) FetchFeedbackAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.FeedbackEvent, error) {
+    query := `
+        SELECT f.id, f.created_at, i.model_name, i.model_version, i.created_at
+        FROM feedback f
+        JOIN inferences i ON i.id = f.inference_id
+        WHERE (f.created_at, f.id) > ($1, $2) AND f.created_at < $3
+        ORDER BY f.created_at, f.id
+        LIMIT $4
+    `
+    rows, err := r.db.QueryContext(ctx, query, wm.CreatedAt, wm.ID, until, limit)
+    if err != nil {
+        return nil, fmt.Errorf("FetchFeedbackAfter: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.FeedbackEvent
+    for rows.Next() {
+        var ev models.FeedbackEvent
+        if err := rows.Scan(&ev.ID, &ev.CreatedAt, &ev.ModelName, &ev.ModelVersion, &ev.InferenceCreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, ev)
+    }
+    return out, rows.Err()
+}
+
+// ApplyRollups merges the deltas into the stored rollups and moves the
+// stream watermark from `from` to `to`, all in one transaction. If the
+// stored watermark is no longer `from` nothing is written and
+// ErrWatermarkMoved is returned.
+func (r *rollupRepo) ApplyRollups(ctx context.Context, from, to models.Watermark, deltas []models.Rollup) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("ApplyRollups: %w", err)
+    }
+    defer tx.Rollback()
+
+    _, err = tx.ExecContext(ctx, `
+        INSERT INTO rollup_watermarks (stream, last_created_at, last_id)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (stream) DO NOTHING
+    `, from.Stream, from.CreatedAt, from.ID)
+    if err != nil {
+        return fmt.Errorf("ApplyRollups: %w", err)
+    }
+
+    var current models.Watermark
+    err = tx.QueryRowContext(ctx, `
+        SELECT last_created_at, last_id
+        FROM rollup_watermarks
+        WHERE stream = $1
+        FOR UPDATE
+    `, from.Stream).Scan(&current.CreatedAt, &current.ID)
+    if err != nil {
+        return fmt.Errorf("ApplyRollups: %w", err)
+    }
+    if !current.CreatedAt.Equal(from.CreatedAt) || current.ID != from.ID {
+        return ErrWatermarkMoved
+    }
+
+    for i := range deltas {
+        if err := mergeRollup(ctx, tx, &deltas[i]); err != nil {
+            return fmt.Errorf("ApplyRollups: %w", err)
+        }
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE rollup_watermarks
+        SET last_created_at = $2, last_id = $3
+        WHERE stream = $1
+    `, to.Stream, to.CreatedAt, to.ID)
+    if err != nil {
+        return fmt.Errorf("ApplyRollups: %w", err)
+    }
+    return tx.Commit()
+}
+
+func mergeRollup(ctx context.Context, tx *sql.Tx, delta *models.Rollup) error {
+    stored := models.Rollup{
+        ModelName:    delta.ModelName,
+        ModelVersion: delta.ModelVersion,
+        BucketStart:  delta.BucketStart,
+    }
+    var latency []byte
+    err := tx.QueryRowContext(ctx, `
+        SELECT inference_count, feedback_count, latency_sketch
+        FROM rollups
+        WHERE model_name = $1 AND model_version = $2 AND bucket_start = $3
+        FOR UPDATE
+    `, delta.ModelName, delta.ModelVersion, delta.BucketStart).Scan(&stored.InferenceCount, &stored.FeedbackCount, &latency)
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    if len(latency) > 0 {
+        stored.Latency = &sketch.Sketch{}
+        if err := json.Unmarshal(latency, stored.Latency); err != nil {
+            return err
+        }
+    }
+
+    features, err := loadFeatures(ctx, tx, `
+        SELECT model_name, model_version, bucket_start, field, aggregate
+        FROM rollup_features
+        WHERE model_name = $1 AND model_version = $2 AND bucket_start = $3
+        FOR UPDATE
+    `, delta.ModelName, delta.ModelVersion, delta.BucketStart)
+    if err != nil {
+        return err
+    }
+    stored.Features = features[rollupKey{delta.ModelName, delta.ModelVersion, delta.BucketStart.Unix()}]
+
+    if err := stored.Merge(delta); err != nil {
+        return err
+    }
+
+    var latencyJSON interface{}
+    if stored.Latency != nil {
+        b, err := json.Marshal(stored.Latency)
+        if err != nil {
+            return err
+        }
+        latencyJSON = string(b)
+    }
+    _, err = tx.ExecContext(ctx, `
+        INSERT INTO rollups (model_name, model_version, bucket_start, inference_count, feedback_count, latency_sketch, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6::jsonb, NOW())
+        ON CONFLICT (model_name, model_version, bucket_start) DO UPDATE
+        SET inference_count = EXCLUDED.inference_count,
+            feedback_count = EXCLUDED.feedback_count,
+            latency_sketch = EXCLUDED.latency_sketch,
+            updated_at = NOW()
+    `, stored.ModelName, stored.ModelVersion, stored.BucketStart,
+        stored.InferenceCount, stored.FeedbackCount, latencyJSON)
+    if err != nil {
+        return err
+    }
+
+    for field := range delta.Features {
+        b, err := json.Marshal(stored.Features[field])
+        if err != nil {
+            return err
+        }
+        _, err = tx.ExecContext(ctx, `
+            INSERT INTO rollup_features (model_name, model_version, bucket_start, field, aggregate)
+            VALUES ($1, $2, $3, $4, $5::jsonb)
+            ON CONFLICT (model_name, model_version, bucket_start, field) DO UPDATE
+            SET aggregate = EXCLUDED.aggregate
+        `, stored.ModelName, stored.ModelVersion, stored.BucketStart, field, string(b))
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+type rollupKey struct {
+    model, version string
+    bucket         int64
+}
+
+type querier interface {
+    QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadFeatures reads (model_name, model_version, bucket_start, field,
+// aggregate) rows grouped by rollup.
+func loadFeatures(ctx context.Context, q querier, query string, args ...interface{}) (map[rollupKey]map[string]*models.FeatureRollup, error) {
+    rows, err := q.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    out := map[rollupKey]map[string]*models.FeatureRollup{}
+    for rows.Next() {
+        var k rollupKey
+        var bucket time.Time
+        var field string
+        var raw []byte
+        if err := rows.Scan(&k.model, &k.version, &bucket, &field, &raw); err != nil {
+            return nil, err
+        }
+        k.bucket = bucket.Unix()
+        var f models.FeatureRollup
+        if err := json.Unmarshal(raw, &f); err != nil {
+            return nil, err
+        }
+        if out[k] == nil {
+            out[k] = map[string]*models.FeatureRollup{}
+        }
+        out[k][field] = &f
+    }
+    return out, rows.Err()
+}
+
+// ListRollups returns the stored rollups whose bucket starts inside the
+// window, ordered by version and bucket.
+func (r *rollupRepo) ListRollups(ctx context.Context, q models.WindowQuery) ([]models.Rollup, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT model_name, model_version, bucket_start, inference_count, feedback_count, latency_sketch
+        FROM rollups
+        WHERE model_name = $1
+          AND ($2 = '' OR model_version = $2)
+          AND bucket_start >= $3 AND bucket_start < $4
+        ORDER BY model_version, bucket_start
+    `, q.ModelName, q.ModelVersion, q.From, q.To)
+    if err != nil {
+        return nil, fmt.Errorf("ListRollups: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.Rollup
+    for rows.Next() {
+        var ro models.Rollup
+        var latency []byte
+        if err := rows.Scan(&ro.ModelName, &ro.ModelVersion, &ro.BucketStart,
+            &ro.InferenceCount, &ro.FeedbackCount, &latency); err != nil {
+            return nil, err
+        }
+        if len(latency) > 0 {
+            ro.Latency = &sketch.Sketch{}
+            if err := json.Unmarshal(latency, ro.Latency); err != nil {
+                return nil, err
+            }
+        }
+        out = append(out, ro)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    features, err := loadFeatures(ctx, r.db, `
+        SELECT model_name, model_version, bucket_start, field, aggregate
+        FROM rollup_features
+        WHERE model_name = $1
+          AND ($2 = '' OR model_version = $2)
+          AND bucket_start >= $3 AND bucket_start < $4
+    `, q.ModelName, q.ModelVersion, q.From, q.To)
+    if err != nil {
+        return nil, fmt.Errorf("ListRollups: %w", err)
+    }
+    for i := range out {
+        k := rollupKey{out[i].ModelName, out[i].ModelVersion, out[i].BucketStart.Unix()}
+        out[i].Features = features[k]
+    }
+    return out, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// SchemaRepository stores inferred payload schemas and their change events.
+type SchemaRepository interface {
+    GetSchema(ctx context.Context, model, version, payload string) (*models.Schema, error)
+    SaveSchema(ctx context.Context, s *models.Schema) error
+    ListSchemas(ctx context.Context, model, version string) ([]models.Schema, error)
+    InsertSchemaEvents(ctx context.Context, events []models.SchemaEvent) error
+    ListSchemaEvents(ctx context.Context, q models.WindowQuery) ([]models.SchemaEvent, error)
+}
+
+type schemaRepo struct {
+    db *sql.DB
+}
+
+func NewSchemaRepository(db *sql.DB) SchemaRepository {
+    return &schemaRepo{db: db}
+}
+
+// GetSchema returns the stored schema, or nil if none exists.
+func (r *schemaRepo) GetSchema(ctx context.Context, model, version, payload string) (*models.Schema, error) {
+    query := `
+        SELECT schema, updated_at
+        FROM inference_schemas
+        WHERE model_name = $1 AND model_version = $2 AND payload = $3
+    `
+    var s models.Schema
+    var raw []byte
+    err := r.db.QueryRowContext(ctx, query, model, version, payload).Scan(&raw, &s.UpdatedAt)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("GetSchema: %w", err)
+    }
+    if err := json.Unmarshal(raw, &s); err != nil {
+        return nil, fmt.Errorf("GetSchema: decode schema: %w", err)
+    }
+    s.ModelName, s.ModelVersion, s.Payload = model, version, payload
+    return &s, nil
+}
+
+func (r *schemaRepo) SaveSchema(ctx context.Context, s *models.Schema) error {
+    raw, err := json.Marshal(s)
+    if err != nil {
+        return fmt.Errorf("SaveSchema: encode schema: %w", err)
+    }
+    query := `
+        INSERT INTO inference_schemas (model_name, model_version, payload, schema, updated_at)
+        VALUES ($1, $2, $3, $4::jsonb, NOW())
+        ON CONFLICT (model_name, model_version, payload) DO UPDATE
+        SET schema = EXCLUDED.schema, updated_at = EXCLUDED.updated_at
+    `
+    if _, err := r.db.ExecContext(ctx, query, s.ModelName, s.ModelVersion, s.Payload, string(raw)); err != nil {
+        return fmt.Errorf("SaveSchema: %w", err)
+    }
+    return nil
+}
+
+// ListSchemas returns the schemas of a model, optionally restricted to one
+// version.
+func (r *schemaRepo) ListSchemas(ctx context.Context, model, version string) ([]models.Schema, error) {
+    query := `
+        SELECT model_name, model_version, payload, schema, updated_at
+        FROM inference_schemas
+        WHERE model_name = $1 AND ($2 = '' OR model_version = $2)
+        ORDER BY model_version, payload
+    `
+    rows, err := r.db.QueryContext(ctx, query, model, version)
+    if err != nil {
+        return nil, fmt.Errorf("ListSchemas: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.Schema
+    for rows.Next() {
+        var s models.Schema
+        var name, ver, payload string
+        var raw []byte
+        if err := rows.Scan(&name, &ver, &payload, &raw, &s.UpdatedAt); err != nil {
+            return nil, err
+        }
+        if err := json.Unmarshal(raw, &s); err != nil {
+            return nil, fmt.Errorf("ListSchemas: decode schema: %w", err)
+        }
+        s.ModelName, s.ModelVersion, s.Payload = name, ver, payload
+        out = append(out, s)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListSchemas: %w", err)
+    }
+    return out, nil
+}
+
+func (r *schemaRepo) InsertSchemaEvents(ctx context.Context, events []models.SchemaEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("InsertSchemaEvents: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO schema_events (id, model_name, model_version, payload, field, kind, detail)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+    for _, e := range events {
+        if _, err := tx.ExecContext(ctx, query,
+            e.ID, e.ModelName, e.ModelVersion, e.Payload, e.Field, e.Kind, e.Detail); err != nil {
+            return fmt.Errorf("InsertSchemaEvents: %w", err)
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("InsertSchemaEvents: %w", err)
+    }
+    return nil
+}
+
+// ListSchemaEvents returns the most recent schema events in the window.
+func (r *schemaRepo) ListSchemaEvents(ctx context.Context, q models.WindowQuery) ([]models.SchemaEvent, error) {
+    query := `
+        SELECT id, model_name, model_version, payload, field, kind, detail, created_at
+        FROM schema_events
+        WHERE model_name = $1
+          AND ($2 = '' OR model_version = $2)
+          AND created_at >= $3 AND created_at < $4
+        ORDER BY created_at DESC
+        LIMIT $5
+    `
+    rows, err := r.db.QueryContext(ctx, query, q.ModelName, q.ModelVersion, q.From, q.To, q.Limit)
+    if err != nil {
+        return nil, fmt.Errorf("ListSchemaEvents: %w", err)
+    }
+    defer rows.Close()
+
+    var out []models.SchemaEvent
+    for rows.Next() {
+        var e models.SchemaEvent
+        if err := rows.Scan(&e.ID, &e.ModelName, &e.ModelVersion, &e.Payload, &e.Field,
+            &e.Kind, &e.Detail, &e.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, e)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("ListSchemaEvents: %w", err)
+    }
+    return out, nil
+}
@@ -0,0 +1,226 @@
+// Package rollup maintains pre-aggregated, time-bucketed traffic statistics
+// per model version so that dashboards do not have to rescan the raw JSONB
+// payloads in the inferences table.
+//
+// Rollups back /rollups only, which is narrower than moving every drift and
+// alert query onto them. Inference latency percentiles and per-field
+// distributions are only served there; there are no alert queries; and the
+// metric and embedding drift endpoints need per-inference predictions,
+// labels and vectors, which no aggregate keeps, so they still read the rows
+// of their window. LLM latency comes from llm_calls, which is not rolled up.
+package rollup
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/sketch"
+)
+
+// Worker folds new inferences and feedback into the rollup tables. Each
+// stream keeps a high-water mark; rows committed later than Lag after their
+// created_at may be missed, so Lag should exceed the longest ingest
+// transaction.
+type Worker struct {
+    Repo      repository.RollupRepository
+    Bucket    time.Duration
+    Interval  time.Duration
+    Lag       time.Duration
+    BatchSize int
+}
+
+// NewWorker returns a worker with the given bucket size and poll interval.
+func NewWorker(repo repository.RollupRepository, bucket, interval, lag time.Duration, batchSize int) *Worker {
+    return &Worker{
+        Repo:      repo,
+        Bucket:    bucket,
+        Interval:  interval,
+        Lag:       lag,
+        BatchSize: batchSize,
+    }
+}
+
+// Run processes both streams every Interval until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+    log.Printf("Starting rollup worker (bucket %s, interval %s)\n", w.Bucket, w.Interval)
+    ticker := time.NewTicker(w.Interval)
+    defer ticker.Stop()
+    for {
+        if err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+            log.Printf("Rollup worker error: %v\n", err)
+        }
+        select {
+        case <-ctx.Done():
+            log.Println("Rollup worker stopped")
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// RunOnce drains both streams up to now - Lag.
+func (w *Worker) RunOnce(ctx context.Context) error {
+    until := time.Now().Add(-w.Lag)
+    for {
+        n, err := w.inferenceBatch(ctx, until)
+        if err != nil {
+            return err
+        }
+        if n < w.BatchSize {
+            break
+        }
+    }
+    for {
+        n, err := w.feedbackBatch(ctx, until)
+        if err != nil {
+            return err
+        }
+        if n < w.BatchSize {
+            break
+        }
+    }
+    return nil
+}
+
+func (w *Worker) inferenceBatch(ctx context.Context, until time.Time) (int, error) {
+    from, err := w.Repo.GetWatermark(ctx, repository.StreamInferences)
+    if err != nil {
+        return 0, err
+    }
+    infs, err := w.Repo.FetchInferencesAfter(ctx, from, until, w.BatchSize)
+    if err != nil || len(infs) == 0 {
+        return 0, err
+    }
+
+    agg := NewAggregator(w.Bucket)
+    for _, inf := range infs {
+        agg.AddInference(inf)
+    }
+    last := infs[len(infs)-1]
+    to := models.Watermark{Stream: from.Stream, CreatedAt: last.CreatedAt, ID: last.ID}
+    return len(infs), w.apply(ctx, from, to, agg)
+}
+
+func (w *Worker) feedbackBatch(ctx context.Context, until time.Time) (int, error) {
+    from, err := w.Repo.GetWatermark(ctx, repository.StreamFeedback)
+    if err != nil {
+        return 0, err
+    }
+    events, err := w.Repo.FetchFeedbackAfter(ctx, from, until, w.BatchSize)
+    if err != nil || len(events) == 0 {
+        return 0, err
+    }
+
+    agg := NewAggregator(w.Bucket)
+    for _, ev := range events {
+        agg.AddFeedback(ev)
+    }
+    last := events[len(events)-1]
+    to := models.Watermark{Stream: from.Stream, CreatedAt: last.CreatedAt, ID: last.ID}
+    return len(events), w.apply(ctx, from, to, agg)
+}
+
+func (w *Worker) apply(ctx context.Context, from, to models.Watermark, agg *Aggregator) error {
+    err := w.Repo.ApplyRollups(ctx, from, to, agg.Rollups())
+    if errors.Is(err, repository.ErrWatermarkMoved) {
+        // Another instance processed this batch; pick up from its watermark
+        // on the next round.
+        return nil
+    }
+    return err
+}
+
+// Aggregator accumulates rollup deltas in memory.
+type Aggregator struct {
+    bucket  time.Duration
+    rollups map[key]*models.Rollup
+    order   []key
+}
+
+type key struct {
+    model, version string
+    start          time.Time
+}
+
+// NewAggregator returns an empty aggregator for the given bucket size.
+func NewAggregator(bucket time.Duration) *Aggregator {
+    return &Aggregator{bucket: bucket, rollups: map[key]*models.Rollup{}}
+}
+
+func (a *Aggregator) get(model, version string, t time.Time) *models.Rollup {
+    k := key{model, version, t.UTC().Truncate(a.bucket)}
+    r, ok := a.rollups[k]
+    if !ok {
+        r = &models.Rollup{ModelName: model, ModelVersion: version, BucketStart: k.start}
+        a.rollups[k] = r
+        a.order = append(a.order, k)
+    }
+    return r
+}
+
+// AddInference counts one inference, its latency and its top-level
+// input_data fields. Of an encrypted payload only the fields kept in clear
+// are counted; the envelope itself is skipped.
+func (a *Aggregator) AddInference(inf models.Inference) {
+    r := a.get(inf.ModelName, inf.ModelVersion, inf.CreatedAt)
+    r.InferenceCount++
+    if inf.LatencyMs != nil {
+        if r.Latency == nil {
+            r.Latency = sketch.New(sketch.DefaultRelativeAccuracy)
+        }
+        r.Latency.Add(*inf.LatencyMs)
+    }
+
+    doc, err := jsonpath.Decode(inf.InputData)
+    if err != nil {
+        return
+    }
+    obj, ok := doc.(map[string]interface{})
+    if !ok {
+        return
+    }
+    if r.Features == nil {
+        r.Features = map[string]*models.FeatureRollup{}
+    }
+    for field, v := range obj {
+        if field == envelope.Field {
+            continue
+        }
+        f, ok := r.Features[field]
+        if !ok {
+            f = &models.FeatureRollup{}
+            r.Features[field] = f
+        }
+        switch val := v.(type) {
+        case nil:
+            f.Missing++
+        case float64:
+            if f.Numeric == nil {
+                f.Numeric = sketch.New(sketch.DefaultRelativeAccuracy)
+            }
+            f.Numeric.Add(val)
+        case string, bool:
+            f.AddCategory(jsonpath.Normalize(val), 1)
+        }
+    }
+}
+
+// AddFeedback counts one feedback row in the bucket of its inference.
+func (a *Aggregator) AddFeedback(ev models.FeedbackEvent) {
+    a.get(ev.ModelName, ev.ModelVersion, ev.InferenceCreatedAt).FeedbackCount++
+}
+
+// Rollups returns the accumulated deltas in first-seen order.
+func (a *Aggregator) Rollups() []models.Rollup {
+    out := make([]models.Rollup, 0, len(a.order))
+    for _, k := range a.order {
+        out = append(out, *a.rollups[k])
+    }
+    return out
+}
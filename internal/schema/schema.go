@@ -0,0 +1,260 @@
+// Package schema infers the JSON structure of inference payloads per model
+// version from observed traffic and reports when it changes: a field
+// appears, disappears, or starts carrying a different type.
+package schema
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// Payload names.
+const (
+    PayloadInput  = "input"
+    PayloadOutput = "output"
+)
+
+// Event kinds.
+const (
+    EventFieldAdded   = "field_added"
+    EventFieldRemoved = "field_removed"
+    EventTypeChanged  = "type_changed"
+)
+
+const (
+    // DefaultRemovalWindow is the number of consecutive observations a field
+    // must be absent from before it is reported as removed.
+    DefaultRemovalWindow = 1000
+
+    // MaxArrayItems caps how many elements of each array are inspected, so
+    // that embedding vectors and long lists stay cheap to walk.
+    MaxArrayItems = 20
+)
+
+// occurrence is what one observation contributed to a path.
+type occurrence struct {
+    types map[string]int64
+    count int64
+}
+
+// Paths walks a decoded JSON document and returns, per path, the types seen
+// and how often. The root itself is not included.
+func Paths(doc interface{}) map[string]*occurrence {
+    out := map[string]*occurrence{}
+    walk("", doc, out)
+    return out
+}
+
+func walk(path string, v interface{}, out map[string]*occurrence) {
+    if path != "" {
+        o, ok := out[path]
+        if !ok {
+            o = &occurrence{types: map[string]int64{}}
+            out[path] = o
+        }
+        o.types[jsonpath.TypeOf(v)]++
+        o.count++
+    }
+    switch val := v.(type) {
+    case map[string]interface{}:
+        for k, child := range val {
+            childPath := k
+            if path != "" {
+                childPath = path + "." + k
+            }
+            walk(childPath, child, out)
+        }
+    case []interface{}:
+        for i, elem := range val {
+            if i >= MaxArrayItems {
+                break
+            }
+            walk(path+"[]", elem, out)
+        }
+    }
+}
+
+// NewSchema returns an empty schema.
+func NewSchema(model, version, payload string) *models.Schema {
+    return &models.Schema{
+        ModelName:    model,
+        ModelVersion: version,
+        Payload:      payload,
+        Fields:       map[string]*models.SchemaField{},
+    }
+}
+
+// Observe folds one decoded payload into the schema and returns the changes
+// it caused. The first observation defines the initial schema and produces
+// no events. Returned events only carry Field, Kind and Detail.
+func Observe(s *models.Schema, doc interface{}, now time.Time, removalWindow int64) []models.SchemaEvent {
+    var events []models.SchemaEvent
+    add := func(field, kind, detail string) {
+        events = append(events, models.SchemaEvent{Field: field, Kind: kind, Detail: detail})
+    }
+
+    initial := s.Observations == 0
+    s.Observations++
+    seen := Paths(doc)
+
+    for _, path := range sortedKeys(seen) {
+        o := seen[path]
+        f, ok := s.Fields[path]
+        switch {
+        case !ok:
+            f = &models.SchemaField{Types: map[string]int64{}, FirstSeen: now}
+            s.Fields[path] = f
+            if !initial {
+                add(path, EventFieldAdded, "new field of type "+strings.Join(typeNames(o.types), "|"))
+            }
+        case f.Removed:
+            f.Removed = false
+            add(path, EventFieldAdded, fmt.Sprintf("field reappeared after %d observations",
+                s.Observations-f.LastObservation-1))
+        }
+
+        before := typeNames(f.Types)
+        var changed []string
+        for t := range o.types {
+            if t != "null" && f.Types[t] == 0 && len(before) > 0 {
+                changed = append(changed, t)
+            }
+        }
+        if len(changed) > 0 {
+            sort.Strings(changed)
+            add(path, EventTypeChanged, strings.Join(before, "|")+" -> "+strings.Join(changed, "|"))
+        }
+
+        for t, n := range o.types {
+            f.Types[t] += n
+        }
+        f.Count += o.count
+        f.LastSeen = now
+        f.LastObservation = s.Observations
+    }
+
+    for _, path := range sortedKeys(s.Fields) {
+        f := s.Fields[path]
+        if _, ok := seen[path]; ok || f.Removed {
+            continue
+        }
+        if s.Observations-f.LastObservation >= removalWindow {
+            f.Removed = true
+            add(path, EventFieldRemoved, fmt.Sprintf("field absent from the last %d observations",
+                s.Observations-f.LastObservation))
+        }
+    }
+    return events
+}
+
+// typeNames returns the sorted non-null types in a type histogram.
+func typeNames(types map[string]int64) []string {
+    var out []string
+    for t, n := range types {
+        if t != "null" && n > 0 {
+            out = append(out, t)
+        }
+    }
+    sort.Strings(out)
+    return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+// node is one level of the nested view of a schema.
+type node struct {
+    field    *models.SchemaField
+    children map[string]*node
+    items    *node
+}
+
+// JSONSchema renders the active fields of a schema as a JSON Schema
+// document. A property is required when it was present every time its
+// parent object was; "null" in a type list means the field was seen as null.
+func JSONSchema(s *models.Schema) map[string]interface{} {
+    root := &node{children: map[string]*node{}}
+    for _, path := range sortedKeys(s.Fields) {
+        f := s.Fields[path]
+        if f.Removed {
+            continue
+        }
+        cur := root
+        for _, seg := range strings.Split(path, ".") {
+            name := seg
+            depth := 0
+            for strings.HasSuffix(name, "[]") {
+                name = strings.TrimSuffix(name, "[]")
+                depth++
+            }
+            cur = cur.child(name)
+            for i := 0; i < depth; i++ {
+                if cur.items == nil {
+                    cur.items = &node{children: map[string]*node{}}
+                }
+                cur = cur.items
+            }
+        }
+        cur.field = f
+    }
+
+    out := render(root, s.Observations)
+    out["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+    out["type"] = "object"
+    return out
+}
+
+func (n *node) child(name string) *node {
+    c, ok := n.children[name]
+    if !ok {
+        c = &node{children: map[string]*node{}}
+        n.children[name] = c
+    }
+    return c
+}
+
+func render(n *node, objectCount int64) map[string]interface{} {
+    out := map[string]interface{}{}
+    if n.field != nil {
+        types := typeNames(n.field.Types)
+        if n.field.Types["null"] > 0 {
+            types = append(types, "null")
+        }
+        if len(types) == 1 {
+            out["type"] = types[0]
+        } else if len(types) > 1 {
+            out["type"] = types
+        }
+        objectCount = n.field.Types["object"]
+    }
+    if len(n.children) > 0 {
+        props := map[string]interface{}{}
+        var required []string
+        for _, name := range sortedKeys(n.children) {
+            c := n.children[name]
+            props[name] = render(c, 0)
+            if c.field != nil && objectCount > 0 && c.field.Count >= objectCount {
+                required = append(required, name)
+            }
+        }
+        out["properties"] = props
+        if len(required) > 0 {
+            out["required"] = required
+        }
+    }
+    if n.items != nil {
+        out["items"] = render(n.items, 0)
+    }
+    return out
+}
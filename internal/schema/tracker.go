@@ -0,0 +1,171 @@
+package schema
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/google/uuid"
+)
+
+// Tracker infers the input and output schema of every model version from
+// new inferences. Schemas are cached in memory; changed schemas are written
+// back by Run every FlushInterval rather than on every ingest, or after each
+// observation when FlushInterval is zero. Schema change events are recorded
+// right away. With several replicas the last write wins.
+type Tracker struct {
+    Repo          repository.SchemaRepository
+    RemovalWindow int64
+    FlushInterval time.Duration
+
+    mu      sync.Mutex
+    schemas map[string]*models.Schema
+    // dirty holds the keys of the schemas changed since the last flush.
+    dirty map[string]bool
+}
+
+// NewTracker returns a tracker that reports a field as removed once it has
+// been absent from removalWindow consecutive observations.
+func NewTracker(repo repository.SchemaRepository, removalWindow int64) *Tracker {
+    if removalWindow <= 0 {
+        removalWindow = DefaultRemovalWindow
+    }
+    return &Tracker{
+        Repo:          repo,
+        RemovalWindow: removalWindow,
+        schemas:       map[string]*models.Schema{},
+        dirty:         map[string]bool{},
+    }
+}
+
+// ObserveInference folds the input and output of a new inference into the
+// schemas of its model version and records any changes.
+func (t *Tracker) ObserveInference(ctx context.Context, inf models.Inference) error {
+    payloads := []struct {
+        name string
+        raw  string
+    }{
+        {PayloadInput, inf.InputData},
+        {PayloadOutput, inf.OutputData},
+    }
+
+    var events []models.SchemaEvent
+    for _, p := range payloads {
+        doc, err := jsonpath.Decode(p.raw)
+        if err != nil {
+            return fmt.Errorf("decode %s_data: %w", p.name, err)
+        }
+
+        t.mu.Lock()
+        s, err := t.schema(ctx, inf.ModelName, inf.ModelVersion, p.name)
+        if err != nil {
+            t.mu.Unlock()
+            return err
+        }
+        changes := Observe(s, doc, time.Now().UTC(), t.RemovalWindow)
+        if t.FlushInterval > 0 {
+            t.dirty[schemaKey(inf.ModelName, inf.ModelVersion, p.name)] = true
+            t.mu.Unlock()
+        } else {
+            snapshot := copySchema(s)
+            t.mu.Unlock()
+            if err := t.Repo.SaveSchema(ctx, snapshot); err != nil {
+                return err
+            }
+        }
+        for _, e := range changes {
+            e.ID = uuid.New().String()
+            e.ModelName = inf.ModelName
+            e.ModelVersion = inf.ModelVersion
+            e.Payload = p.name
+            log.Printf("Schema change for %s/%s %s: %s %s (%s)\n",
+                e.ModelName, e.ModelVersion, e.Payload, e.Kind, e.Field, e.Detail)
+            events = append(events, e)
+        }
+    }
+    return t.Repo.InsertSchemaEvents(ctx, events)
+}
+
+// Run saves changed schemas every FlushInterval until ctx is cancelled, then
+// saves them one last time. Stop the callers of ObserveInference first.
+func (t *Tracker) Run(ctx context.Context) {
+    log.Printf("Starting schema flusher (every %s)\n", t.FlushInterval)
+    ticker := time.NewTicker(t.FlushInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := t.Flush(context.Background()); err != nil {
+                log.Printf("Error saving schemas: %v\n", err)
+            }
+            log.Println("Schema flusher stopped")
+            return
+        case <-ticker.C:
+            if err := t.Flush(ctx); err != nil {
+                log.Printf("Error saving schemas: %v\n", err)
+            }
+        }
+    }
+}
+
+// Flush saves the schemas changed since the last flush. Schemas that fail
+// to save are kept for the next flush.
+func (t *Tracker) Flush(ctx context.Context) error {
+    t.mu.Lock()
+    snapshots := make([]*models.Schema, 0, len(t.dirty))
+    for key := range t.dirty {
+        snapshots = append(snapshots, copySchema(t.schemas[key]))
+    }
+    t.dirty = map[string]bool{}
+    t.mu.Unlock()
+
+    var errs []error
+    for _, s := range snapshots {
+        if err := t.Repo.SaveSchema(ctx, s); err != nil {
+            t.mu.Lock()
+            t.dirty[schemaKey(s.ModelName, s.ModelVersion, s.Payload)] = true
+            t.mu.Unlock()
+            errs = append(errs, fmt.Errorf("%s/%s %s: %w", s.ModelName, s.ModelVersion, s.Payload, err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+func schemaKey(model, version, payload string) string {
+    return model + "\x00" + version + "\x00" + payload
+}
+
+// schema returns the cached schema, loading it on first use. t.mu must be
+// held.
+func (t *Tracker) schema(ctx context.Context, model, version, payload string) (*models.Schema, error) {
+    key := schemaKey(model, version, payload)
+    if s, ok := t.schemas[key]; ok {
+        return s, nil
+    }
+    s, err := t.Repo.GetSchema(ctx, model, version, payload)
+    if err != nil {
+        return nil, err
+    }
+    if s == nil {
+        s = NewSchema(model, version, payload)
+    }
+    if s.Fields == nil {
+        s.Fields = map[string]*models.SchemaField{}
+    }
+    t.schemas[key] = s
+    return s, nil
+}
+
+func copySchema(s *models.Schema) *models.Schema {
+    raw, _ := json.Marshal(s)
+    var out models.Schema
+    json.Unmarshal(raw, &out)
+    return &out
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "net"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/audit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/gorilla/mux"
+)
+
+const (
+    maxAuditLimit    = 1000
+    auditVerifyBatch = 1000
+)
+
+// auditedReads are the GET routes that return raw payloads or values
+// quoted from them: those that require PermPayloads or check
+// canReadPayloads. Every other audited route is a mutating one.
+var auditedReads = map[string]bool{
+    "/inferences/{id}":             true,
+    "/inferences/{id}/feedback":    true,
+    "/inferences/{id}/evaluations": true,
+    "/traces/{id}":                 true,
+    "/llm/calls/{id}":              true,
+    "/shadow":                      true,
+    "/data-quality/violations":     true,
+}
+
+type auditResourceKey struct{}
+
+// auditRecorder captures the status code written by a handler.
+type auditRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (r *auditRecorder) WriteHeader(code int) {
+    r.status = code
+    r.ResponseWriter.WriteHeader(code)
+}
+
+// setAuditResource names the resource a request created, for handlers whose
+// route carries no ID.
+func setAuditResource(r *http.Request, id string) {
+    if res, ok := r.Context().Value(auditResourceKey{}).(*string); ok {
+        *res = id
+    }
+}
+
+// requestActor identifies the caller for the audit log: the authenticated
+// identity, or the X-Actor header when access control is disabled.
+func (s *Server) requestActor(r *http.Request) string {
+    if id := auth.FromContext(r.Context()); id != nil {
+        return id.Name
+    }
+    if actor := r.Header.Get("X-Actor"); actor != "" && s.Auth == nil {
+        return actor
+    }
+    return "anonymous"
+}
+
+// auditMiddleware appends an audit entry for every mutating request and
+// every read of raw payloads once the handler has finished, through
+// AuditWriter when one is set. Failing to record an entry is logged; the
+// response has already been sent.
+func (s *Server) auditMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        route, _ := mux.CurrentRoute(r).GetPathTemplate()
+        if s.AuditRepo == nil || (r.Method == http.MethodGet && !auditedReads[route]) {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        vars := mux.Vars(r)
+        resource := vars["id"]
+        if resource == "" {
+            resource = vars["model"]
+        }
+        rec := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+        next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), auditResourceKey{}, &resource)))
+
+        remote, _, err := net.SplitHostPort(r.RemoteAddr)
+        if err != nil {
+            remote = r.RemoteAddr
+        }
+        entry := models.AuditEntry{
+            Actor:      s.requestActor(r),
+            RemoteAddr: remote,
+            Method:     r.Method,
+            Route:      route,
+            ResourceID: resource,
+            Status:     rec.status,
+            Outcome:    audit.Outcome(rec.status),
+            CreatedAt:  time.Now(),
+        }
+        if s.AuditWriter != nil {
+            s.AuditWriter.Write(entry)
+            return
+        }
+        // The client may be gone already; the entry is still recorded.
+        if _, err := s.AuditRepo.Append(context.Background(), entry); err != nil {
+            log.Printf("Error appending audit entry for %s %s: %v\n", r.Method, route, err)
+        }
+    })
+}
+
+// handleAudit pages through the audit log in sequence order. Pass the
+// returned next_after as after to fetch the following page.
+//   GET /audit?after=0&limit=100&actor=...&resource_id=...
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+    q := models.AuditQuery{
+        Actor:      r.URL.Query().Get("actor"),
+        ResourceID: r.URL.Query().Get("resource_id"),
+    }
+    if v := r.URL.Query().Get("after"); v != "" {
+        after, err := strconv.ParseInt(v, 10, 64)
+        if err != nil || after < 0 {
+            http.Error(w, "invalid after", http.StatusBadRequest)
+            return
+        }
+        q.AfterSeq = after
+    }
+    limit, err := queryInt(r, "limit", defaultViolationLimit)
+    if err != nil || limit <= 0 || limit > maxAuditLimit {
+        http.Error(w, "invalid limit", http.StatusBadRequest)
+        return
+    }
+    q.Limit = limit
+
+    entries, err := s.AuditRepo.ListEntries(r.Context(), q)
+    if err != nil {
+        log.Printf("Error listing audit entries: %v\n", err)
+        http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+        return
+    }
+    if entries == nil {
+        entries = []models.AuditEntry{}
+    }
+    next := q.AfterSeq
+    if len(entries) > 0 {
+        next = entries[len(entries)-1].Seq
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "entries":    entries,
+        "next_after": next,
+        "more":       len(entries) == limit,
+    })
+}
+
+// handleVerifyAudit walks the whole audit log and checks the hash chain.
+//   GET /audit/verify
+func (s *Server) handleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+    prevSeq, prevHash := int64(0), audit.GenesisHash
+    for {
+        entries, err := s.AuditRepo.ListEntries(r.Context(), models.AuditQuery{AfterSeq: prevSeq, Limit: auditVerifyBatch})
+        if err != nil {
+            log.Printf("Error listing audit entries: %v\n", err)
+            http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+            return
+        }
+        if err := s.Audit.Verify(entries, prevSeq, prevHash); err != nil {
+            chainErr := err.(*audit.ChainError)
+            w.WriteHeader(http.StatusOK)
+            json.NewEncoder(w).Encode(map[string]interface{}{
+                "valid":      false,
+                "broken_seq": chainErr.Seq,
+                "reason":     chainErr.Reason,
+            })
+            return
+        }
+        if len(entries) > 0 {
+            prevSeq, prevHash = entries[len(entries)-1].Seq, entries[len(entries)-1].Hash
+        }
+        if len(entries) < auditVerifyBatch {
+            break
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "valid":     true,
+        "entries":   prevSeq,
+        "head_hash": prevHash,
+    })
+}
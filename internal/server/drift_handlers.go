@@ -0,0 +1,120 @@
+package server
+
+import (
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/drift"
+)
+
+// Bounds on the work of one embedding drift request.
+const (
+    maxDriftSamples      = 2000
+    maxDriftPermutations = 10000
+    maxDriftFolds        = 20
+)
+
+// handleEmbeddingDrift compares an embedding vector stored in input_data
+// between a reference and a current window, e.g.
+//   GET /drift/embeddings?model=search&field=query.embedding&from=...&to=...
+// The reference window defaults to the window of equal length just before
+// from; ref_from, ref_to and ref_version override it.
+func (s *Server) handleEmbeddingDrift(w http.ResponseWriter, r *http.Request) {
+    cur, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    q := r.URL.Query()
+    field := q.Get("field")
+    if field == "" {
+        http.Error(w, "field is required", http.StatusBadRequest)
+        return
+    }
+
+    ref := cur
+    ref.To = cur.From
+    ref.From = cur.From.Add(-cur.To.Sub(cur.From))
+    if v := q.Get("ref_version"); v != "" {
+        ref.ModelVersion = v
+    }
+    for key, dst := range map[string]*time.Time{"ref_from": &ref.From, "ref_to": &ref.To} {
+        if v := q.Get(key); v != "" {
+            t, err := time.Parse(time.RFC3339, v)
+            if err != nil {
+                http.Error(w, "invalid "+key+": "+err.Error(), http.StatusBadRequest)
+                return
+            }
+            *dst = t
+        }
+    }
+    if !ref.From.Before(ref.To) {
+        http.Error(w, "ref_from must be before ref_to", http.StatusBadRequest)
+        return
+    }
+
+    opts := drift.DefaultEmbeddingOptions
+    opts.MaxSamples, err = queryBoundedInt(r, "max_samples", opts.MaxSamples, 2, maxDriftSamples)
+    if err == nil {
+        opts.Permutations, err = queryBoundedInt(r, "permutations", opts.Permutations, 0, maxDriftPermutations)
+    }
+    if err == nil {
+        opts.Folds, err = queryBoundedInt(r, "folds", opts.Folds, 2, maxDriftFolds)
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if v := q.Get("alpha"); v != "" {
+        a, err := strconv.ParseFloat(v, 64)
+        if err != nil || a <= 0 || a >= 1 {
+            http.Error(w, "invalid alpha", http.StatusBadRequest)
+            return
+        }
+        opts.Alpha = a
+    }
+    ref.Limit = opts.MaxSamples
+    cur.Limit = opts.MaxSamples
+
+    refRaw, err := s.MetricsRepo.SampleInputValues(r.Context(), ref, field)
+    if err != nil {
+        log.Printf("Error sampling reference vectors: %v\n", err)
+        http.Error(w, "Failed to load reference window", http.StatusInternalServerError)
+        return
+    }
+    curRaw, err := s.MetricsRepo.SampleInputValues(r.Context(), cur, field)
+    if err != nil {
+        log.Printf("Error sampling current vectors: %v\n", err)
+        http.Error(w, "Failed to load current window", http.StatusInternalServerError)
+        return
+    }
+    refVecs, refSkipped := drift.ParseVectors(refRaw)
+    curVecs, curSkipped := drift.ParseVectors(curRaw)
+
+    report, err := drift.Embeddings(refVecs, curVecs, opts)
+    if errors.Is(err, drift.ErrTooFewSamples) || errors.Is(err, drift.ErrDimensionMismatch) {
+        http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+        return
+    }
+    if err != nil {
+        log.Printf("Error computing embedding drift: %v\n", err)
+        http.Error(w, "Failed to compute embedding drift", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "field": field,
+        "reference": map[string]interface{}{
+            "version": ref.ModelVersion, "from": ref.From, "to": ref.To, "skipped": refSkipped,
+        },
+        "current": map[string]interface{}{
+            "version": cur.ModelVersion, "from": cur.From, "to": cur.To, "skipped": curSkipped,
+        },
+        "report": report,
+    })
+}
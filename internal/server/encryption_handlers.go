@@ -0,0 +1,165 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/google/uuid"
+)
+
+const defaultRewrapLimit = 500
+
+// safeViewObserver hands observers only the payload fields stored in clear,
+// for observers that persist payload values (e.g. learned categories).
+type safeViewObserver struct {
+    InferenceObserver
+    codec *envelope.Codec
+}
+
+func (o safeViewObserver) ObserveInference(ctx context.Context, inf models.Inference) error {
+    inf.InputData = o.codec.SafeView("input_data", inf.InputData)
+    inf.OutputData = o.codec.SafeView("output_data", inf.OutputData)
+    return o.InferenceObserver.ObserveInference(ctx, inf)
+}
+
+// safeObserver wraps o in a safeViewObserver when encryption is enabled.
+func (s *Server) safeObserver(o InferenceObserver) InferenceObserver {
+    if s.Encryption == nil {
+        return o
+    }
+    return safeViewObserver{InferenceObserver: o, codec: s.Encryption}
+}
+
+type rewrapResult struct {
+    Table   string `json:"table"`
+    Column  string `json:"column"`
+    Updated int    `json:"updated"`
+    Failed  int    `json:"failed"`
+}
+
+// handleRewrap brings up to limit payloads under the primary master key,
+// column by column: plaintext payloads are encrypted and data keys wrapped
+// with an older key are rewrapped. Rows are visited in ID order and the
+// response carries a cursor past the last row visited, so rows that fail are
+// skipped on the next call instead of being retried forever. After adding a
+// new primary key, call it with the returned "next" as "after" until
+// "remaining" is false; a fresh pass without "after" retries failed rows.
+//   POST /encryption/rewrap?limit=500&after=inferences.input_data:<id>
+func (s *Server) handleRewrap(w http.ResponseWriter, r *http.Request) {
+    if s.Encryption == nil {
+        http.Error(w, "encryption is disabled", http.StatusNotFound)
+        return
+    }
+    limit, err := queryInt(r, "limit", defaultRewrapLimit)
+    if err != nil || limit <= 0 {
+        http.Error(w, "invalid limit", http.StatusBadRequest)
+        return
+    }
+    colIdx, afterID, err := parseRewrapCursor(r.URL.Query().Get("after"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    primary := s.Encryption.Keys.PrimaryID()
+    results := make([]rewrapResult, len(repository.PayloadColumns))
+    for i, col := range repository.PayloadColumns {
+        results[i] = rewrapResult{Table: col.Table, Column: col.Column}
+    }
+    budget := limit
+    for budget > 0 && colIdx < len(repository.PayloadColumns) {
+        col := repository.PayloadColumns[colIdx]
+        stale, err := s.EnvelopeRepo.ListStale(r.Context(), col, primary, afterID, budget)
+        if err != nil {
+            log.Printf("Error listing payloads to rewrap: %v\n", err)
+            http.Error(w, "Failed to rewrap payloads", http.StatusInternalServerError)
+            return
+        }
+        res := &results[colIdx]
+        for _, p := range stale {
+            afterID = p.ID
+            data, changed, err := s.Encryption.Rotate(col.Column, p.ID, p.Data)
+            if err != nil {
+                log.Printf("Error rewrapping %s.%s of %s: %v\n", col.Table, col.Column, p.ID, err)
+                res.Failed++
+                continue
+            }
+            if !changed {
+                continue
+            }
+            if err := s.EnvelopeRepo.UpdatePayload(r.Context(), col, p.ID, data); err != nil {
+                log.Printf("Error storing rewrapped payload: %v\n", err)
+                res.Failed++
+                continue
+            }
+            res.Updated++
+        }
+        if len(stale) == budget {
+            break
+        }
+        budget -= len(stale)
+        colIdx, afterID = colIdx+1, ""
+    }
+
+    // The batch may have ended exactly at the last stale row, so look for
+    // one more row past the cursor before reporting that work remains.
+    remaining, err := s.rewrapRemaining(r.Context(), primary, colIdx, afterID)
+    if err != nil {
+        log.Printf("Error listing payloads to rewrap: %v\n", err)
+        http.Error(w, "Failed to rewrap payloads", http.StatusInternalServerError)
+        return
+    }
+    resp := map[string]interface{}{
+        "primary_key": primary,
+        "columns":     results,
+        "remaining":   remaining,
+    }
+    if remaining {
+        col := repository.PayloadColumns[colIdx]
+        resp["next"] = col.Table + "." + col.Column + ":" + afterID
+    }
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+// rewrapRemaining reports whether a stale payload exists past afterID in
+// column colIdx or in any later column. When it does, colIdx and afterID
+// are the cursor to resume from.
+func (s *Server) rewrapRemaining(ctx context.Context, primary string, colIdx int, afterID string) (bool, error) {
+    for ; colIdx < len(repository.PayloadColumns); colIdx, afterID = colIdx+1, "" {
+        next, err := s.EnvelopeRepo.ListStale(ctx, repository.PayloadColumns[colIdx], primary, afterID, 1)
+        if err != nil {
+            return false, err
+        }
+        if len(next) > 0 {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+// parseRewrapCursor splits a "table.column:id" cursor into the index of the
+// column in repository.PayloadColumns and the row ID. An empty cursor starts
+// at the first column.
+func parseRewrapCursor(after string) (int, string, error) {
+    if after == "" {
+        return 0, "", nil
+    }
+    name, id, ok := strings.Cut(after, ":")
+    if _, err := uuid.Parse(id); !ok || err != nil {
+        return 0, "", fmt.Errorf("invalid after: %q", after)
+    }
+    for i, col := range repository.PayloadColumns {
+        if col.Table+"."+col.Column == name {
+            return i, id, nil
+        }
+    }
+    return 0, "", fmt.Errorf("invalid after: %q", after)
+}
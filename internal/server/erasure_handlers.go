@@ -0,0 +1,138 @@
+package server
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/google/uuid"
+    "github.com/gorilla/mux"
+)
+
+const defaultErasureBatch = 500
+
+// subjectHash identifies a subject in erasure jobs without keeping its ID.
+func subjectHash(subjectID string) string {
+    sum := sha256.Sum256([]byte(subjectID))
+    return hex.EncodeToString(sum[:])
+}
+
+// handleEraseSubject starts a job that deletes or anonymizes every
+// inference and LLM call tagged with the subject ID, in batches, and returns
+// it with 202 while it runs. Poll GET /erasure-jobs/{id} for the report. A
+// failed job can be retried with the same request; it resumes with what is
+// left.
+//   DELETE /subjects/{id}?mode=delete&batch_size=500
+func (s *Server) handleEraseSubject(w http.ResponseWriter, r *http.Request) {
+    subjectID := mux.Vars(r)["id"]
+    mode := r.URL.Query().Get("mode")
+    if mode == "" {
+        mode = models.ErasureModeDelete
+    }
+    if mode != models.ErasureModeDelete && mode != models.ErasureModeAnonymize {
+        http.Error(w, "mode must be delete or anonymize", http.StatusBadRequest)
+        return
+    }
+    batchSize, err := queryInt(r, "batch_size", defaultErasureBatch)
+    if err != nil || batchSize <= 0 {
+        http.Error(w, "invalid batch_size", http.StatusBadRequest)
+        return
+    }
+
+    job := models.ErasureJob{
+        ID:          uuid.New().String(),
+        SubjectHash: subjectHash(subjectID),
+        Mode:        mode,
+        Status:      models.ErasureRunning,
+        StartedAt:   time.Now().UTC(),
+    }
+    if err := s.ErasureRepo.InsertJob(r.Context(), job); err != nil {
+        log.Printf("Error creating erasure job: %v\n", err)
+        http.Error(w, "Failed to start erasure", http.StatusInternalServerError)
+        return
+    }
+    // Audit the job rather than the subject ID being erased.
+    setAuditResource(r, job.ID)
+
+    s.erasures.Add(1)
+    go func(job models.ErasureJob) {
+        defer s.erasures.Done()
+        s.runErasure(subjectID, batchSize, job)
+    }(job)
+
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(job)
+}
+
+// runErasure erases the subject's rows batch by batch, recording progress on
+// the job. It runs to completion even if the client goes away.
+func (s *Server) runErasure(subjectID string, batchSize int, job models.ErasureJob) {
+    ctx := context.Background()
+    for {
+        batch, err := s.ErasureRepo.EraseBatch(ctx, subjectID, job.Mode, batchSize)
+        if err != nil {
+            log.Printf("Error erasing subject data in job %s: %v\n", job.ID, err)
+            job.Status = models.ErasureFailed
+            job.Error = "erasure batch failed"
+            break
+        }
+        if batch.Inferences == 0 && batch.LLMCalls == 0 {
+            job.Status = models.ErasureCompleted
+            break
+        }
+        job.Batches++
+        job.Inferences += batch.Inferences
+        job.Feedback += batch.Feedback
+        job.LLMCalls += batch.LLMCalls
+        if err := s.ErasureRepo.UpdateJob(ctx, job); err != nil {
+            log.Printf("Error recording erasure progress of job %s: %v\n", job.ID, err)
+        }
+    }
+
+    finished := time.Now().UTC()
+    job.FinishedAt = &finished
+    if err := s.ErasureRepo.UpdateJob(ctx, job); err != nil {
+        log.Printf("Error completing erasure job %s: %v\n", job.ID, err)
+    }
+}
+
+// WaitErasures blocks until every running erasure job has finished.
+func (s *Server) WaitErasures() {
+    s.erasures.Wait()
+}
+
+// DrainErasures waits like WaitErasures, but gives up when ctx is done and
+// returns its error. Jobs cut off by exiting stay running in the store; the
+// same request resumes them. Stop accepting requests first.
+func (s *Server) DrainErasures(ctx context.Context) error {
+    done := make(chan struct{})
+    go func() {
+        s.erasures.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+// handleGetErasureJob returns the progress or report of an erasure job.
+//   GET /erasure-jobs/{id}
+func (s *Server) handleGetErasureJob(w http.ResponseWriter, r *http.Request) {
+    job, err := s.ErasureRepo.GetJob(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        log.Printf("Error getting erasure job: %v\n", err)
+        http.Error(w, "Erasure job not found", http.StatusNotFound)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(job)
+}
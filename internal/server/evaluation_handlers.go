@@ -0,0 +1,76 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/gorilla/mux"
+)
+
+// handleEvaluations aggregates evaluator scores of a model over time.
+//   GET /evaluations?model=chat&evaluator=refusal&bucket=day&from=...&to=...
+// evaluator is optional; bucket is hour, day (default) or week.
+func (s *Server) handleEvaluations(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    bucket := r.URL.Query().Get("bucket")
+    switch bucket {
+    case "":
+        bucket = "day"
+    case "hour", "day", "week":
+    default:
+        http.Error(w, "bucket must be hour, day or week", http.StatusBadRequest)
+        return
+    }
+    evaluator := r.URL.Query().Get("evaluator")
+
+    aggs, err := s.EvaluationRepo.Aggregate(r.Context(), win, evaluator, bucket)
+    if err != nil {
+        log.Printf("Error aggregating evaluations: %v\n", err)
+        http.Error(w, "Failed to load evaluations", http.StatusInternalServerError)
+        return
+    }
+    if aggs == nil {
+        aggs = []models.EvaluationAggregate{}
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "model":   win.ModelName,
+        "version": win.ModelVersion,
+        "from":    win.From,
+        "to":      win.To,
+        "bucket":  bucket,
+        "buckets": aggs,
+    })
+}
+
+// handleGetInferenceEvaluations lists the current evaluator scores of one
+// inference.
+func (s *Server) handleGetInferenceEvaluations(w http.ResponseWriter, r *http.Request) {
+    scores, err := s.EvaluationRepo.ListScoresByInference(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        log.Printf("Error listing evaluation scores: %v\n", err)
+        http.Error(w, "Failed to load evaluations", http.StatusInternalServerError)
+        return
+    }
+    if scores == nil {
+        scores = []models.EvaluationScore{}
+    }
+    if !s.canReadPayloads(r) {
+        // Details quote matched output text.
+        for i := range scores {
+            if scores[i].Detail != "" {
+                scores[i].Detail = redactedDetail
+            }
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(scores)
+}
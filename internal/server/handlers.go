@@ -3,10 +3,14 @@ package server
 import (
     "context"
     "encoding/json"
+    "errors"
     "log"
     "net/http"
+    "strings"
+    "time"
 
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/tracing"
     "github.com/gorilla/mux"
     "github.com/google/uuid"
 )
@@ -21,7 +25,16 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 //   "model_name": "string",
 //   "model_version": "string",
 //   "input_data": {"some":"input"},
-//   "output_data": {"some":"output"}
+//   "output_data": {"some":"output"},
+//   "tags": {"segment":"enterprise"},     (optional)
+//   "latency_ms": 12.5,                   (optional)
+//   "request_id": "req-123",              (optional, defaults to X-Request-ID)
+//   "subject_id": "customer-42",          (optional, the person the data is about)
+//   "shadow": false,                      (optional)
+//   "trace_id": "4bf92f35...",            (optional, defaults to the traceparent header)
+//   "span_id": "00f067aa0ba902b7",        (optional, generated for traced inferences)
+//   "parent_span_id": "...",              (optional)
+//   "started_at": "2025-04-10T12:00:00Z"  (optional, defaults to the insert time)
 // }
 func (s *Server) handleCreateInference(w http.ResponseWriter, r *http.Request) {
     var req struct {
@@ -29,10 +42,38 @@ func (s *Server) handleCreateInference(w http.ResponseWriter, r *http.Request) {
         ModelVersion string      `json:"model_version"`
         InputData    interface{} `json:"input_data"`
         OutputData   interface{} `json:"output_data"`
+        Tags         map[string]interface{} `json:"tags"`
+        LatencyMs    *float64    `json:"latency_ms"`
+        RequestID    string      `json:"request_id"`
+        SubjectID    string      `json:"subject_id"`
+        Shadow       bool        `json:"shadow"`
+        TraceID      string      `json:"trace_id"`
+        SpanID       string      `json:"span_id"`
+        ParentSpanID string      `json:"parent_span_id"`
+        StartedAt    *time.Time  `json:"started_at"`
     }
 
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+    if !s.limitBody(w, r) {
+        return
+    }
+    // Keep numbers exact so long identifiers survive PII scanning.
+    dec := json.NewDecoder(r.Body)
+    dec.UseNumber()
+    if err := dec.Decode(&req); err != nil {
+        decodeError(w, err)
+        return
+    }
+
+    if req.RequestID == "" {
+        req.RequestID = r.Header.Get("X-Request-ID")
+    }
+    if req.Shadow && req.RequestID == "" {
+        http.Error(w, "shadow inferences require a request_id", http.StatusBadRequest)
+        return
+    }
+
+    if err := resolveTrace(r, &req.TraceID, &req.SpanID, &req.ParentSpanID); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
         return
     }
 
@@ -42,6 +83,10 @@ func (s *Server) handleCreateInference(w http.ResponseWriter, r *http.Request) {
     // Convert input/output_data to raw JSON string
     inputBytes, _ := json.Marshal(req.InputData)
     outputBytes, _ := json.Marshal(req.OutputData)
+    if req.Tags == nil {
+        req.Tags = map[string]interface{}{}
+    }
+    tagBytes, _ := json.Marshal(req.Tags)
 
     inf := models.Inference{
         ID:           infID,
@@ -49,19 +94,47 @@ func (s *Server) handleCreateInference(w http.ResponseWriter, r *http.Request) {
         ModelVersion: req.ModelVersion,
         InputData:    string(inputBytes),  // store as JSON string
         OutputData:   string(outputBytes), // store as JSON string
+        Tags:         string(tagBytes),
+        LatencyMs:    req.LatencyMs,
+        RequestID:    req.RequestID,
+        SubjectID:    req.SubjectID,
+        IsShadow:     req.Shadow,
+        TraceID:      req.TraceID,
+        SpanID:       req.SpanID,
+        ParentSpanID: req.ParentSpanID,
+        StartedAt:    req.StartedAt,
         HasFeedback:  false,
     }
 
+    // Redact PII before anything is persisted or observed.
+    redactions, rejected, err := s.redactPII(&inf)
+    if err != nil {
+        log.Printf("Error scanning inference for PII: %v\n", err)
+        http.Error(w, "Failed to scan inference for PII", http.StatusInternalServerError)
+        return
+    }
+    if len(rejected) > 0 {
+        http.Error(w, "Request contains PII: "+strings.Join(rejected, ", "), http.StatusUnprocessableEntity)
+        return
+    }
+
     ctx := context.Background()
     if err := s.InferenceRepo.InsertInference(ctx, inf); err != nil {
         log.Printf("Error inserting inference: %v\n", err)
         http.Error(w, "Failed to insert inference", http.StatusInternalServerError)
         return
     }
+    setAuditResource(r, infID)
+    s.recordRedactions(ctx, redactions)
+    s.notifyObservers(ctx, inf)
 
     // Return the new inference ID
+    resp := map[string]string{"inference_id": infID}
+    if inf.SpanID != "" {
+        resp["span_id"] = inf.SpanID
+    }
     w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(map[string]string{"inference_id": infID})
+    json.NewEncoder(w).Encode(resp)
 }
 
 // handleGetInference retrieves a single inference by ID
@@ -76,6 +149,9 @@ func (s *Server) handleGetInference(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Inference not found", http.StatusNotFound)
         return
     }
+    if !s.canReadPayloads(r) {
+        inf.InputData, inf.OutputData = redactedPayload, redactedPayload
+    }
 
     w.WriteHeader(http.StatusOK)
     json.NewEncoder(w).Encode(inf)
@@ -93,8 +169,11 @@ func (s *Server) handleCreateFeedback(w http.ResponseWriter, r *http.Request) {
     var body struct {
         FeedbackData interface{} `json:"feedback_data"`
     }
+    if !s.limitBody(w, r) {
+        return
+    }
     if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+        decodeError(w, err)
         return
     }
 
@@ -121,6 +200,7 @@ func (s *Server) handleCreateFeedback(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Failed to update inference feedback status", http.StatusInternalServerError)
         return
     }
+    s.notifyFeedbackObservers(ctx, fb)
 
     w.WriteHeader(http.StatusCreated)
     json.NewEncoder(w).Encode(map[string]string{"feedback_id": fbID})
@@ -142,3 +222,65 @@ func (s *Server) handleGetFeedback(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusOK)
     json.NewEncoder(w).Encode(feedbacks)
 }
+
+// resolveTrace takes the trace ID and parent span from the traceparent
+// header when the body names no trace, and generates a span ID for traced
+// records that have none.
+func resolveTrace(r *http.Request, traceID, spanID, parentSpanID *string) error {
+    if *traceID == "" {
+        if h := r.Header.Get("traceparent"); h != "" {
+            tid, parentID, err := tracing.ParseTraceparent(h)
+            if err != nil {
+                return err
+            }
+            *traceID = tid
+            if *parentSpanID == "" {
+                *parentSpanID = parentID
+            }
+        }
+    }
+    if *traceID == "" && (*spanID != "" || *parentSpanID != "") {
+        return errors.New("span_id and parent_span_id require a trace_id")
+    }
+    if *traceID != "" && *spanID == "" {
+        *spanID = tracing.NewSpanID()
+    }
+    return nil
+}
+
+// handleGetTrace returns the inferences and LLM calls of a trace assembled
+// into a span tree with per-step timing.
+func (s *Server) handleGetTrace(w http.ResponseWriter, r *http.Request) {
+    traceID := mux.Vars(r)["id"]
+
+    infs, err := s.InferenceRepo.ListInferencesByTrace(r.Context(), traceID)
+    if err != nil {
+        log.Printf("Error listing inferences by trace: %v\n", err)
+        http.Error(w, "Failed to load trace", http.StatusInternalServerError)
+        return
+    }
+    var calls []models.LLMCall
+    if s.LLMRepo != nil {
+        calls, err = s.LLMRepo.ListCallsByTrace(r.Context(), traceID)
+        if err != nil {
+            log.Printf("Error listing LLM calls by trace: %v\n", err)
+            http.Error(w, "Failed to load trace", http.StatusInternalServerError)
+            return
+        }
+    }
+    if len(infs) == 0 && len(calls) == 0 {
+        http.Error(w, "Trace not found", http.StatusNotFound)
+        return
+    }
+    if !s.canReadPayloads(r) {
+        for i := range infs {
+            infs[i].InputData, infs[i].OutputData = redactedPayload, redactedPayload
+        }
+        for i := range calls {
+            calls[i].Prompt, calls[i].Completion = redactedDetail, redactedDetail
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(tracing.Build(traceID, infs, calls))
+}
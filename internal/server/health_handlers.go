@@ -0,0 +1,144 @@
+package server
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+)
+
+// defaultReadyTimeout bounds /readyz when ReadyTimeout is unset.
+const defaultReadyTimeout = 2 * time.Second
+
+// probeRoutes are open to every caller and exempt from rate limits, so
+// orchestrators can always reach them.
+var probeRoutes = map[string]bool{
+    "/health": true,
+    "/livez":  true,
+    "/readyz": true,
+}
+
+// HealthCheck is one dependency check of /readyz. Check returns an error
+// when the dependency cannot serve traffic.
+type HealthCheck struct {
+    Name  string
+    Check func(ctx context.Context) error
+}
+
+// DatabaseChecks checks that the database answers, that its migrations
+// are at least at latest and not dirty, and that the pool has a free
+// connection.
+func DatabaseChecks(database *sql.DB, latest uint) []HealthCheck {
+    return []HealthCheck{
+        {Name: "database", Check: database.PingContext},
+        {Name: "migrations", Check: func(ctx context.Context) error {
+            version, dirty, err := db.MigrationVersion(ctx, database)
+            if err != nil {
+                return err
+            }
+            if dirty {
+                return fmt.Errorf("migration %d is dirty", version)
+            }
+            // A newer schema is fine: during a rolling deploy the new pods
+            // migrate while the old ones still serve.
+            if version < latest {
+                return fmt.Errorf("at version %d, want %d", version, latest)
+            }
+            return nil
+        }},
+        {Name: "connection_pool", Check: func(context.Context) error {
+            stats := database.Stats()
+            if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+                return fmt.Errorf("all %d connections in use", stats.MaxOpenConnections)
+            }
+            return nil
+        }},
+    }
+}
+
+// newReadyChecks returns DatabaseChecks against the latest migration
+// embedded in the binary. If it cannot be read, the migrations check fails.
+func newReadyChecks(database *sql.DB) []HealthCheck {
+    latest, err := db.LatestMigration()
+    checks := DatabaseChecks(database, latest)
+    if err != nil {
+        log.Printf("Error reading migrations: %v\n", err)
+        for i := range checks {
+            if checks[i].Name == "migrations" {
+                checks[i].Check = func(context.Context) error { return err }
+            }
+        }
+    }
+    return checks
+}
+
+type checkResult struct {
+    Status    string  `json:"status"`
+    LatencyMs float64 `json:"latency_ms"`
+    Error     string  `json:"error,omitempty"`
+}
+
+// handleLivez reports that the process is up; it checks no dependencies.
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleReadyz runs the ReadyChecks concurrently under ReadyTimeout and
+// answers 503 if any fails:
+//   {"status": "unavailable", "checks": {"database": {"status": "ok", "latency_ms": 0.8}, ...}}
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+    timeout := s.ReadyTimeout
+    if timeout <= 0 {
+        timeout = defaultReadyTimeout
+    }
+    ctx, cancel := context.WithTimeout(r.Context(), timeout)
+    defer cancel()
+
+    results := make([]checkResult, len(s.ReadyChecks))
+    var wg sync.WaitGroup
+    for i, c := range s.ReadyChecks {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            start := time.Now()
+            err := runCheck(ctx, c.Check)
+            results[i] = checkResult{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+            if err != nil {
+                results[i].Status, results[i].Error = "fail", err.Error()
+            }
+        }()
+    }
+    wg.Wait()
+
+    status, code := "ok", http.StatusOK
+    checks := map[string]checkResult{}
+    for i, c := range s.ReadyChecks {
+        checks[c.Name] = results[i]
+        if results[i].Status != "ok" {
+            status, code = "unavailable", http.StatusServiceUnavailable
+        }
+    }
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": checks})
+}
+
+// runCheck returns when check does or ctx expires, whichever is first, so
+// a check that ignores ctx cannot hold up the probe.
+func runCheck(ctx context.Context, check func(context.Context) error) error {
+    done := make(chan error, 1)
+    go func() { done <- check(ctx) }()
+    select {
+    case err := <-done:
+        return err
+    case <-ctx.Done():
+        return errors.New("timed out")
+    }
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/gorilla/mux"
+    "github.com/google/uuid"
+)
+
+// handleCreateLLMCall expects a JSON body like:
+// {
+//   "model": "gpt-4o",
+//   "provider": "openai",                 (optional)
+//   "prompt": "...",
+//   "completion": "...",
+//   "prompt_tokens": 812,
+//   "completion_tokens": 120,
+//   "latency_ms": 1430,                   (optional)
+//   "finish_reason": "stop",              (optional)
+//   "cost_usd": 0.0032,                   (optional, used when the model has no price)
+//   "tags": {"feature": "summaries"},     (optional)
+//   "subject_id": "customer-42",          (optional, the person the call is about)
+//   "trace_id": "4bf92f35...",            (optional, defaults to the traceparent header)
+//   "span_id": "00f067aa0ba902b7",        (optional, generated for traced calls)
+//   "parent_span_id": "...",              (optional)
+//   "started_at": "2025-04-10T12:00:00Z"  (optional, defaults to the insert time)
+// }
+// Cost is computed from the configured price table when the model is priced.
+// Traced calls appear in GET /traces/{id} next to the trace's inferences.
+func (s *Server) handleCreateLLMCall(w http.ResponseWriter, r *http.Request) {
+    var req struct {
+        Model            string                 `json:"model"`
+        Provider         string                 `json:"provider"`
+        Prompt           string                 `json:"prompt"`
+        Completion       string                 `json:"completion"`
+        PromptTokens     int                    `json:"prompt_tokens"`
+        CompletionTokens int                    `json:"completion_tokens"`
+        LatencyMs        *float64               `json:"latency_ms"`
+        FinishReason     string                 `json:"finish_reason"`
+        CostUSD          *float64               `json:"cost_usd"`
+        Tags             map[string]interface{} `json:"tags"`
+        SubjectID        string                 `json:"subject_id"`
+        TraceID          string                 `json:"trace_id"`
+        SpanID           string                 `json:"span_id"`
+        ParentSpanID     string                 `json:"parent_span_id"`
+        StartedAt        *time.Time             `json:"started_at"`
+    }
+    if !s.limitBody(w, r) {
+        return
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        decodeError(w, err)
+        return
+    }
+    if req.Model == "" {
+        http.Error(w, "model is required", http.StatusBadRequest)
+        return
+    }
+    if req.PromptTokens < 0 || req.CompletionTokens < 0 {
+        http.Error(w, "token counts must not be negative", http.StatusBadRequest)
+        return
+    }
+    if req.LatencyMs != nil && *req.LatencyMs < 0 {
+        http.Error(w, "latency_ms must not be negative", http.StatusBadRequest)
+        return
+    }
+    if err := resolveTrace(r, &req.TraceID, &req.SpanID, &req.ParentSpanID); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cost := req.CostUSD
+    if s.Config != nil {
+        if price, ok := s.Config.LLMPrice(req.Model); ok {
+            c := price.Cost(req.PromptTokens, req.CompletionTokens)
+            cost = &c
+        }
+    }
+    if req.Tags == nil {
+        req.Tags = map[string]interface{}{}
+    }
+    tagBytes, _ := json.Marshal(req.Tags)
+
+    call := models.LLMCall{
+        ID:               uuid.New().String(),
+        Model:            req.Model,
+        Provider:         req.Provider,
+        Prompt:           req.Prompt,
+        Completion:       req.Completion,
+        PromptTokens:     req.PromptTokens,
+        CompletionTokens: req.CompletionTokens,
+        CostUSD:          cost,
+        LatencyMs:        req.LatencyMs,
+        FinishReason:     req.FinishReason,
+        Tags:             string(tagBytes),
+        SubjectID:        req.SubjectID,
+        TraceID:          req.TraceID,
+        SpanID:           req.SpanID,
+        ParentSpanID:     req.ParentSpanID,
+        StartedAt:        req.StartedAt,
+    }
+    if err := s.LLMRepo.InsertCall(r.Context(), call); err != nil {
+        log.Printf("Error inserting LLM call: %v\n", err)
+        http.Error(w, "Failed to insert LLM call", http.StatusInternalServerError)
+        return
+    }
+    setAuditResource(r, call.ID)
+
+    w.WriteHeader(http.StatusCreated)
+    resp := map[string]interface{}{"call_id": call.ID, "cost_usd": call.CostUSD}
+    if call.SpanID != "" {
+        resp["span_id"] = call.SpanID
+    }
+    json.NewEncoder(w).Encode(resp)
+}
+
+// handleGetLLMCall retrieves a single LLM call by ID
+func (s *Server) handleGetLLMCall(w http.ResponseWriter, r *http.Request) {
+    call, err := s.LLMRepo.GetCallByID(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        log.Printf("Error getting LLM call by ID: %v\n", err)
+        http.Error(w, "LLM call not found", http.StatusNotFound)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(call)
+}
+
+// handleLLMUsage reports calls, tokens and cost per model and UTC day.
+//   GET /llm/usage?model=gpt-4o&from=...&to=...
+func (s *Server) handleLLMUsage(w http.ResponseWriter, r *http.Request) {
+    win, err := parseTimeRange(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    usage, err := s.LLMRepo.Usage(r.Context(), win)
+    if err != nil {
+        log.Printf("Error loading LLM usage: %v\n", err)
+        http.Error(w, "Failed to load LLM usage", http.StatusInternalServerError)
+        return
+    }
+    if usage == nil {
+        usage = []models.LLMUsage{}
+    }
+    total := models.LLMUsage{Model: win.ModelName}
+    for _, u := range usage {
+        total.Calls += u.Calls
+        total.PromptTokens += u.PromptTokens
+        total.CompletionTokens += u.CompletionTokens
+        total.CostUSD += u.CostUSD
+        total.UnpricedCalls += u.UnpricedCalls
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "from":  win.From,
+        "to":    win.To,
+        "days":  usage,
+        "total": total,
+    })
+}
+
+// handleLLMLatency reports latency percentiles per model.
+//   GET /llm/latency?model=gpt-4o&quantiles=0.5,0.9,0.99
+func (s *Server) handleLLMLatency(w http.ResponseWriter, r *http.Request) {
+    win, err := parseTimeRange(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    quantiles, err := parseQuantiles(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    latency, err := s.LLMRepo.LatencyPercentiles(r.Context(), win, quantiles)
+    if err != nil {
+        log.Printf("Error loading LLM latency: %v\n", err)
+        http.Error(w, "Failed to load LLM latency", http.StatusInternalServerError)
+        return
+    }
+    if latency == nil {
+        latency = []models.LLMLatency{}
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "from":    win.From,
+        "to":      win.To,
+        "latency": latency,
+    })
+}
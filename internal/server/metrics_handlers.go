@@ -0,0 +1,531 @@
+package server
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+const (
+    defaultWindow      = 7 * 24 * time.Hour
+    defaultWindowLimit = 100000
+    // maxBins bounds the histogram bins a request can allocate.
+    maxBins = 1000
+    // maxResamples bounds the bootstrap work of one comparison.
+    maxResamples = 10000
+    // maxCurvePoints bounds the ROC and PR points one ranking returns.
+    maxCurvePoints = 10000
+    // maxWorstSlices bounds the slices one search returns.
+    maxWorstSlices = 1000
+)
+
+// parseWindow reads the model, version, from and to query parameters shared
+// by the metric endpoints. from/to are RFC3339 timestamps; the window
+// defaults to the last seven days.
+func parseWindow(r *http.Request) (models.WindowQuery, error) {
+    w, err := parseTimeRange(r)
+    if err != nil {
+        return w, err
+    }
+    if w.ModelName == "" {
+        return w, errors.New("model is required")
+    }
+    return w, nil
+}
+
+// parseTimeRange is parseWindow for endpoints where model is an optional
+// filter.
+func parseTimeRange(r *http.Request) (models.WindowQuery, error) {
+    q := r.URL.Query()
+    w := models.WindowQuery{
+        ModelName:    q.Get("model"),
+        ModelVersion: q.Get("version"),
+        To:           time.Now().UTC(),
+        Limit:        defaultWindowLimit,
+    }
+    if v := q.Get("to"); v != "" {
+        t, err := time.Parse(time.RFC3339, v)
+        if err != nil {
+            return w, fmt.Errorf("invalid to: %w", err)
+        }
+        w.To = t
+    }
+    w.From = w.To.Add(-defaultWindow)
+    if v := q.Get("from"); v != "" {
+        t, err := time.Parse(time.RFC3339, v)
+        if err != nil {
+            return w, fmt.Errorf("invalid from: %w", err)
+        }
+        w.From = t
+    }
+    if !w.From.Before(w.To) {
+        return w, errors.New("from must be before to")
+    }
+    return w, nil
+}
+
+// parseExtractor lets callers override where predictions, scores and labels
+// are read from via prediction_field, score_field and label_field.
+func parseExtractor(r *http.Request) metrics.Extractor {
+    q := r.URL.Query()
+    e := metrics.DefaultExtractor
+    if v := q.Get("prediction_field"); v != "" {
+        e.PredictionPath = v
+    }
+    if v := q.Get("score_field"); v != "" {
+        e.ScorePath = v
+    }
+    if v := q.Get("label_field"); v != "" {
+        e.LabelPath = v
+    }
+    return e
+}
+
+func parseMetricOptions(r *http.Request) metrics.Options {
+    opts := metrics.DefaultOptions
+    if v := r.URL.Query().Get("positive"); v != "" {
+        opts.Positive = v
+    }
+    return opts
+}
+
+// parseQuantiles reads a comma-separated quantiles parameter, defaulting to
+// the median, p90 and p99.
+func parseQuantiles(r *http.Request) ([]float64, error) {
+    v := r.URL.Query().Get("quantiles")
+    if v == "" {
+        return []float64{0.5, 0.9, 0.99}, nil
+    }
+    var out []float64
+    for _, part := range strings.Split(v, ",") {
+        f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+        if err != nil || f < 0 || f > 1 {
+            return nil, errors.New("invalid quantiles")
+        }
+        out = append(out, f)
+    }
+    return out, nil
+}
+
+func queryInt(r *http.Request, key string, def int) (int, error) {
+    v := r.URL.Query().Get(key)
+    if v == "" {
+        return def, nil
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        return 0, fmt.Errorf("invalid %s: %w", key, err)
+    }
+    return n, nil
+}
+
+// queryBoundedInt is queryInt restricted to [min, max].
+func queryBoundedInt(r *http.Request, key string, def, min, max int) (int, error) {
+    n, err := queryInt(r, key, def)
+    if err == nil && (n < min || n > max) {
+        err = fmt.Errorf("%s must be between %d and %d", key, min, max)
+    }
+    return n, err
+}
+
+// windowLimit is the most inferences a metric endpoint reads from its
+// window.
+func (s *Server) windowLimit() int {
+    if s.Config != nil && s.Config.MetricsWindowLimit > 0 {
+        return s.Config.MetricsWindowLimit
+    }
+    return defaultWindowLimit
+}
+
+// loadRecords fetches the window and converts it into metric records. When
+// the window holds more than windowLimit inferences, only the newest are
+// used and truncated is set.
+func (s *Server) loadRecords(r *http.Request, w models.WindowQuery) ([]metrics.Record, bool, error) {
+    // Ask for one row more than the limit to tell whether the window was
+    // cut short.
+    limit := s.windowLimit()
+    w.Limit = limit + 1
+    rows, err := s.MetricsRepo.ListLabeledInferences(r.Context(), w)
+    if err != nil {
+        return nil, false, err
+    }
+    truncated := len(rows) > limit
+    if truncated {
+        rows = rows[len(rows)-limit:]
+    }
+    return parseExtractor(r).Extract(rows), truncated, nil
+}
+
+// handleSliceMetrics computes a metric for each slice given as a repeated
+// "slice" query parameter, e.g.
+//   GET /slices?model=m&metric=accuracy&slice=country%20%3D%20'DE'
+func (s *Server) handleSliceMetrics(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    win.LabeledOnly = true
+    m, err := metrics.Lookup(r.URL.Query().Get("metric"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var slices []*metrics.Slice
+    for _, expr := range r.URL.Query()["slice"] {
+        sl, err := metrics.ParseSlice(expr)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        slices = append(slices, sl)
+    }
+    if len(slices) == 0 {
+        http.Error(w, "at least one slice is required", http.StatusBadRequest)
+        return
+    }
+
+    recs, truncated, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    overall, results := metrics.EvaluateSlices(m, recs, slices, parseMetricOptions(r))
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "metric":    m.Name,
+        "from":      win.From,
+        "to":        win.To,
+        "truncated": truncated,
+        "overall":   overall,
+        "slices":    results,
+    })
+}
+
+// handleWorstSlices searches single-field slices and returns the ones where
+// the metric is worst, e.g.
+//   GET /slices/worst?model=m&metric=accuracy&fields=country,age&min_count=30
+func (s *Server) handleWorstSlices(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    win.LabeledOnly = true
+    m, err := metrics.Lookup(r.URL.Query().Get("metric"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    wo := metrics.WorstSliceOptions{MaxValues: 50}
+    // No slice holds more records than the window.
+    limit := s.windowLimit()
+    if wo.MinCount, err = queryBoundedInt(r, "min_count", min(30, limit), 1, limit); err == nil {
+        if wo.Bins, err = queryBoundedInt(r, "bins", 4, 1, maxBins); err == nil {
+            wo.Limit, err = queryBoundedInt(r, "limit", 10, 1, maxWorstSlices)
+        }
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if v := r.URL.Query().Get("fields"); v != "" {
+        wo.Fields = strings.Split(v, ",")
+    }
+
+    recs, truncated, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    overall, worst := metrics.FindWorstSlices(m, recs, parseMetricOptions(r), wo)
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "metric":    m.Name,
+        "from":      win.From,
+        "to":        win.To,
+        "truncated": truncated,
+        "overall":   overall,
+        "slices":    worst,
+    })
+}
+
+// handleFairness reports group fairness metrics for the protected attribute
+// configured for the model (or given as attribute=), e.g.
+//   GET /fairness?model=credit&interval=week&min_count=50
+func (s *Server) handleFairness(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    fo := metrics.FairnessOptions{Attribute: r.URL.Query().Get("attribute")}
+    if fo.Attribute == "" && s.Config != nil {
+        fo.Attribute = s.Config.ProtectedAttribute(win.ModelName)
+    }
+    if fo.Attribute == "" {
+        http.Error(w, "no protected attribute configured for model", http.StatusBadRequest)
+        return
+    }
+    if fo.Interval, err = metrics.ParseInterval(r.URL.Query().Get("interval")); err == nil {
+        fo.MinCount, err = queryInt(r, "min_count", 30)
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    recs, truncated, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "attribute": fo.Attribute,
+        "from":      win.From,
+        "to":        win.To,
+        "truncated": truncated,
+        "reports":   metrics.Fairness(recs, parseMetricOptions(r), fo),
+    })
+}
+
+// handleCalibration reports reliability diagrams, ECE, Brier score and log
+// loss per model version, e.g.
+//   GET /calibration?model=fraud&bins=10&interval=day
+// Reports whose ECE exceeds the configured threshold (or threshold=) are
+// flagged with "alert": true and listed under "alerts".
+func (s *Server) handleCalibration(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    win.LabeledOnly = true
+
+    var co metrics.CalibrationOptions
+    if s.Config != nil {
+        co.ECEThreshold = s.Config.CalibrationECEThreshold
+    }
+    if v := r.URL.Query().Get("threshold"); v != "" {
+        if co.ECEThreshold, err = strconv.ParseFloat(v, 64); err != nil {
+            http.Error(w, "invalid threshold", http.StatusBadRequest)
+            return
+        }
+    }
+    if co.Interval, err = metrics.ParseInterval(r.URL.Query().Get("interval")); err == nil {
+        co.Bins, err = queryBoundedInt(r, "bins", 10, 1, maxBins)
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    recs, truncated, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    reports := metrics.Calibration(recs, parseMetricOptions(r), co)
+    alerts := []metrics.CalibrationReport{}
+    for _, rep := range reports {
+        if rep.Alert {
+            log.Printf("Calibration alert: model=%s version=%s ece=%.4f threshold=%.4f\n",
+                win.ModelName, rep.ModelVersion, rep.ECE, co.ECEThreshold)
+            alerts = append(alerts, rep)
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "from":          win.From,
+        "to":            win.To,
+        "truncated":     truncated,
+        "ece_threshold": co.ECEThreshold,
+        "reports":       reports,
+        "alerts":        alerts,
+    })
+}
+
+// handleRanking returns ROC AUC, PR AUC, KS, precision@k and the ROC, PR and
+// gain/lift curves per model version, e.g.
+//   GET /ranking?model=fraud&k=10,100&bins=10&max_points=200
+func (s *Server) handleRanking(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    win.LabeledOnly = true
+
+    var ro metrics.RankingOptions
+    for _, v := range strings.Split(r.URL.Query().Get("k"), ",") {
+        if v == "" {
+            continue
+        }
+        k, err := strconv.Atoi(v)
+        if err != nil || k <= 0 {
+            http.Error(w, "invalid k", http.StatusBadRequest)
+            return
+        }
+        ro.K = append(ro.K, k)
+    }
+    if ro.Bins, err = queryBoundedInt(r, "bins", 10, 1, maxBins); err == nil {
+        ro.MaxPoints, err = queryBoundedInt(r, "max_points", 200, 2, maxCurvePoints)
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    recs, truncated, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "from":      win.From,
+        "to":        win.To,
+        "truncated": truncated,
+        "reports":   metrics.Ranking(recs, parseMetricOptions(r), ro),
+    })
+}
+
+// handleCompare compares two versions of a model on labeled traffic, e.g.
+//   GET /compare?model=fraud&a=v1&b=v2&metric=accuracy&from=...&to=...
+// The delta is b - a, with a confidence interval and p-value from a test
+// chosen by metric type.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    win.LabeledOnly = true
+    q := r.URL.Query()
+    versionA, versionB := q.Get("a"), q.Get("b")
+    if versionA == "" || versionB == "" {
+        http.Error(w, "a and b versions are required", http.StatusBadRequest)
+        return
+    }
+    m, err := metrics.Lookup(q.Get("metric"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    co := metrics.CompareOptions{Alpha: 0.05}
+    if v := q.Get("alpha"); v != "" {
+        if co.Alpha, err = strconv.ParseFloat(v, 64); err != nil || co.Alpha <= 0 || co.Alpha >= 1 {
+            http.Error(w, "invalid alpha", http.StatusBadRequest)
+            return
+        }
+    }
+    if co.Resamples, err = queryBoundedInt(r, "resamples", 1000, 1, maxResamples); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    win.ModelVersion = versionA
+    recsA, truncatedA, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+    win.ModelVersion = versionB
+    recsB, truncatedB, err := s.loadRecords(r, win)
+    if err != nil {
+        log.Printf("Error loading labeled inferences: %v\n", err)
+        http.Error(w, "Failed to load inferences", http.StatusInternalServerError)
+        return
+    }
+
+    cmp, err := metrics.Compare(m, recsA, recsB, versionA, versionB, parseMetricOptions(r), co)
+    if err != nil {
+        http.Error(w, "not enough labeled data for both versions: "+err.Error(), http.StatusUnprocessableEntity)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(struct {
+        *metrics.Comparison
+        Truncated bool `json:"truncated"`
+    }{cmp, truncatedA || truncatedB})
+}
+
+// handleShadow compares a primary version with a shadow version on the
+// requests both served, without needing feedback, e.g.
+//   GET /shadow?model=fraud&primary=v1&shadow=v2&limit=20
+func (s *Server) handleShadow(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    q := r.URL.Query()
+    win.ModelVersion = q.Get("primary")
+    shadowVersion := q.Get("shadow")
+    if win.ModelVersion == "" || shadowVersion == "" {
+        http.Error(w, "primary and shadow versions are required", http.StatusBadRequest)
+        return
+    }
+    limit, err := queryInt(r, "limit", 20)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    pairLimit := s.windowLimit()
+    win.Limit = pairLimit + 1
+    pairs, err := s.MetricsRepo.ListShadowPairs(r.Context(), win, shadowVersion)
+    if err != nil {
+        log.Printf("Error loading shadow pairs: %v\n", err)
+        http.Error(w, "Failed to load shadow pairs", http.StatusInternalServerError)
+        return
+    }
+    truncated := len(pairs) > pairLimit
+    if truncated {
+        pairs = pairs[len(pairs)-pairLimit:]
+    }
+
+    report := metrics.AnalyzeShadow(pairs, parseExtractor(r), limit)
+    if !s.canReadPayloads(r) {
+        for i := range report.Disagreements {
+            report.Disagreements[i].Input = redactedDetail
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "primary":   win.ModelVersion,
+        "shadow":    shadowVersion,
+        "from":      win.From,
+        "to":        win.To,
+        "truncated": truncated,
+        "report":    report,
+    })
+}
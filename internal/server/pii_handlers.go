@@ -0,0 +1,97 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/pii"
+    "github.com/google/uuid"
+)
+
+// redactPII applies the model's PII action to the payloads of inf in place.
+// It returns the redaction records to store once the inference exists, or,
+// for models configured to reject, a description of what was found.
+func (s *Server) redactPII(inf *models.Inference) ([]models.PIIRedaction, []string, error) {
+    if s.PII == nil {
+        return nil, nil, nil
+    }
+    action := s.Config.PIIAction(inf.ModelName)
+    payloads := []struct {
+        name string
+        data *string
+    }{
+        {"input", &inf.InputData},
+        {"output", &inf.OutputData},
+    }
+
+    var records []models.PIIRedaction
+    var rejected []string
+    for _, p := range payloads {
+        redacted, findings, err := s.PII.Redact(*p.data, action)
+        if err != nil {
+            return nil, nil, fmt.Errorf("%s_data: %w", p.name, err)
+        }
+        *p.data = redacted
+        for _, f := range findings {
+            if action == pii.ActionReject {
+                rejected = append(rejected, fmt.Sprintf("%s %s_data.%s", f.Kind, p.name, f.Path))
+                continue
+            }
+            records = append(records, models.PIIRedaction{
+                ID:          uuid.New().String(),
+                InferenceID: inf.ID,
+                ModelName:   inf.ModelName,
+                Payload:     p.name,
+                Path:        f.Path,
+                Kind:        f.Kind,
+                Action:      string(action),
+                Count:       f.Count,
+            })
+        }
+    }
+    return records, rejected, nil
+}
+
+// recordRedactions stores redaction records; failures are logged since the
+// payloads were already redacted.
+func (s *Server) recordRedactions(ctx context.Context, records []models.PIIRedaction) {
+    if len(records) == 0 || s.PIIRepo == nil {
+        return
+    }
+    if err := s.PIIRepo.InsertRedactions(ctx, records); err != nil {
+        log.Printf("Error recording PII redactions: %v\n", err)
+    }
+}
+
+// handlePIIRedactions lists which payload fields had PII redacted.
+//   GET /pii/redactions?model=chat&inference_id=...&from=...&to=...&limit=100
+func (s *Server) handlePIIRedactions(w http.ResponseWriter, r *http.Request) {
+    win, err := parseTimeRange(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    limit, err := queryInt(r, "limit", defaultViolationLimit)
+    if err != nil || limit <= 0 {
+        http.Error(w, "invalid limit", http.StatusBadRequest)
+        return
+    }
+    win.Limit = limit
+
+    redactions, err := s.PIIRepo.ListRedactions(r.Context(), win, r.URL.Query().Get("inference_id"))
+    if err != nil {
+        log.Printf("Error listing PII redactions: %v\n", err)
+        http.Error(w, "Failed to load PII redactions", http.StatusInternalServerError)
+        return
+    }
+    if redactions == nil {
+        redactions = []models.PIIRedaction{}
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(redactions)
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "sort"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/quality"
+    "github.com/gorilla/mux"
+)
+
+const defaultViolationLimit = 100
+
+type fieldQuality struct {
+    Field       string              `json:"field"`
+    MissingRate float64             `json:"missing_rate"`
+    NullRate    float64             `json:"null_rate"`
+    Types       map[string]int64    `json:"types"`
+    Expected    *models.FieldBounds `json:"expected,omitempty"`
+    Source      string              `json:"source,omitempty"`
+    Violations  map[string]int64    `json:"violations,omitempty"`
+}
+
+type qualityReport struct {
+    ModelName  string           `json:"model_name"`
+    Samples    int64            `json:"samples"`
+    Learning   bool             `json:"learning"`
+    Fields     []fieldQuality   `json:"fields"`
+    Violations map[string]int64 `json:"violations"`
+}
+
+// handleDataQuality reports per-field null/missing rates, the expectations
+// each field is checked against, and violation counts in the window.
+//   GET /data-quality?model=m&from=...&to=...
+func (s *Server) handleDataQuality(w http.ResponseWriter, r *http.Request) {
+    if s.Quality == nil {
+        http.Error(w, "data quality monitoring is disabled", http.StatusNotFound)
+        return
+    }
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    p, err := s.Quality.Profile(r.Context(), win.ModelName)
+    if err != nil {
+        log.Printf("Error loading data quality profile: %v\n", err)
+        http.Error(w, "Failed to load data quality profile", http.StatusInternalServerError)
+        return
+    }
+    counts, err := s.QualityRepo.CountViolations(r.Context(), win)
+    if err != nil {
+        log.Printf("Error counting data quality violations: %v\n", err)
+        http.Error(w, "Failed to load data quality violations", http.StatusInternalServerError)
+        return
+    }
+
+    report := qualityReport{
+        ModelName:  win.ModelName,
+        Samples:    p.Samples,
+        Learning:   quality.Learning(p, s.Quality.LearningSamples),
+        Fields:     []fieldQuality{},
+        Violations: map[string]int64{},
+    }
+    payloads := s.canReadPayloads(r)
+    for name, f := range p.Fields {
+        fq := fieldQuality{
+            Field:      name,
+            Types:      f.Types,
+            Expected:   quality.Expected(f),
+            Violations: counts[name],
+        }
+        // Allowed values are raw input values learned from traffic.
+        if !payloads && fq.Expected != nil && len(fq.Expected.Allowed) > 0 {
+            b := *fq.Expected
+            b.Allowed = nil
+            fq.Expected = &b
+        }
+        if p.Samples > 0 {
+            fq.MissingRate = float64(p.Samples-f.Present) / float64(p.Samples)
+        }
+        if f.Present > 0 {
+            fq.NullRate = float64(f.Nulls) / float64(f.Present)
+        }
+        switch {
+        case f.Declared != nil:
+            fq.Source = "declared"
+        case f.Learned != nil:
+            fq.Source = "learned"
+        }
+        report.Fields = append(report.Fields, fq)
+    }
+    sort.Slice(report.Fields, func(i, j int) bool {
+        return report.Fields[i].Field < report.Fields[j].Field
+    })
+    for _, kinds := range counts {
+        for kind, n := range kinds {
+            report.Violations[kind] += n
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(report)
+}
+
+// handleDataQualityViolations lists the most recent violations in the window.
+//   GET /data-quality/violations?model=m&limit=100
+func (s *Server) handleDataQualityViolations(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    limit, err := queryInt(r, "limit", defaultViolationLimit)
+    if err != nil || limit <= 0 {
+        http.Error(w, "invalid limit", http.StatusBadRequest)
+        return
+    }
+    win.Limit = limit
+
+    violations, err := s.QualityRepo.ListViolations(r.Context(), win)
+    if err != nil {
+        log.Printf("Error listing data quality violations: %v\n", err)
+        http.Error(w, "Failed to load data quality violations", http.StatusInternalServerError)
+        return
+    }
+    if violations == nil {
+        violations = []models.QualityViolation{}
+    }
+    if !s.canReadPayloads(r) {
+        // Details quote the offending values.
+        for i := range violations {
+            violations[i].Detail = redactedDetail
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(violations)
+}
+
+// handleSetDataQualityBounds declares expectations for input fields. Fields
+// set to null fall back to their learned bounds. Body:
+// {
+//   "fields": {
+//     "age":     {"type": "number", "min": 0, "max": 120, "required": true},
+//     "country": {"type": "string", "allowed": ["DE", "FR"], "not_null": true},
+//     "legacy":  null
+//   }
+// }
+func (s *Server) handleSetDataQualityBounds(w http.ResponseWriter, r *http.Request) {
+    if s.Quality == nil {
+        http.Error(w, "data quality monitoring is disabled", http.StatusNotFound)
+        return
+    }
+    model := mux.Vars(r)["model"]
+
+    var body struct {
+        Fields map[string]*models.FieldBounds `json:"fields"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+        return
+    }
+    if len(body.Fields) == 0 {
+        http.Error(w, "fields is required", http.StatusBadRequest)
+        return
+    }
+    for name, b := range body.Fields {
+        if b == nil {
+            continue
+        }
+        switch b.Type {
+        case "", "number", "integer", "string", "boolean", "array", "object":
+        default:
+            http.Error(w, "invalid type for field "+name, http.StatusBadRequest)
+            return
+        }
+        if b.Min != nil && b.Max != nil && *b.Min > *b.Max {
+            http.Error(w, "min must not exceed max for field "+name, http.StatusBadRequest)
+            return
+        }
+    }
+
+    p, err := s.Quality.SetBounds(r.Context(), model, body.Fields)
+    if err != nil {
+        log.Printf("Error saving data quality bounds: %v\n", err)
+        http.Error(w, "Failed to save data quality bounds", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(p)
+}
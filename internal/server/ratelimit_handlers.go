@@ -0,0 +1,96 @@
+package server
+
+import (
+    "errors"
+    "math"
+    "net"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/gorilla/mux"
+)
+
+// defaultMaxBodyBytes caps ingest request bodies when MaxBodyBytes is unset.
+const defaultMaxBodyBytes = 1 << 20
+
+// ingestRoutes are the write-heavy routes limited by IngestLimiter; every
+// other route is limited by QueryLimiter.
+var ingestRoutes = map[string]bool{
+    "POST /inferences":               true,
+    "POST /inferences/{id}/feedback": true,
+    "POST /llm/calls":                true,
+}
+
+// clientKey identifies the caller for rate limiting: the authenticated
+// identity, or else the client IP.
+func (s *Server) clientKey(r *http.Request) string {
+    if id := auth.FromContext(r.Context()); id != nil {
+        return "id:" + id.Name
+    }
+    if s.TrustForwardedFor {
+        if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+            first, _, _ := strings.Cut(fwd, ",")
+            return "ip:" + strings.TrimSpace(first)
+        }
+    }
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        host = r.RemoteAddr
+    }
+    return "ip:" + host
+}
+
+// rateLimit refuses requests over the caller's budget for the route class
+// with 429 and a Retry-After header. Refused requests are not audited.
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        route, _ := mux.CurrentRoute(r).GetPathTemplate()
+        if probeRoutes[route] {
+            next.ServeHTTP(w, r)
+            return
+        }
+        limiter := s.QueryLimiter
+        if ingestRoutes[r.Method+" "+route] {
+            limiter = s.IngestLimiter
+        }
+        if limiter == nil {
+            next.ServeHTTP(w, r)
+            return
+        }
+        if ok, wait := limiter.Allow(s.clientKey(r)); !ok {
+            w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+            http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// limitBody caps the request body at MaxBodyBytes. Requests declaring a
+// larger Content-Length are refused with 413 right away and false is
+// returned; bodies without a length fail to decode past the limit.
+func (s *Server) limitBody(w http.ResponseWriter, r *http.Request) bool {
+    limit := s.MaxBodyBytes
+    if limit <= 0 {
+        limit = defaultMaxBodyBytes
+    }
+    if r.ContentLength > limit {
+        http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+        return false
+    }
+    r.Body = http.MaxBytesReader(w, r.Body, limit)
+    return true
+}
+
+// decodeError answers a failed JSON decode: 413 when the body hit the size
+// limit, 400 otherwise.
+func decodeError(w http.ResponseWriter, err error) {
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+        return
+    }
+    http.Error(w, "Invalid JSON", http.StatusBadRequest)
+}
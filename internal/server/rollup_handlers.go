@@ -0,0 +1,139 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/sketch"
+)
+
+// sketchSummary is the JSON view of a quantile sketch.
+type sketchSummary struct {
+    Count     int64              `json:"count"`
+    Mean      float64            `json:"mean"`
+    Min       float64            `json:"min"`
+    Max       float64            `json:"max"`
+    Quantiles map[string]float64 `json:"quantiles"`
+    Histogram []sketch.Bin       `json:"histogram,omitempty"`
+}
+
+type featureSummary struct {
+    Missing    int64            `json:"missing"`
+    Numeric    *sketchSummary   `json:"numeric,omitempty"`
+    Categories map[string]int64 `json:"categories,omitempty"`
+}
+
+type rollupSummary struct {
+    ModelVersion   string                     `json:"model_version"`
+    BucketStart    time.Time                  `json:"bucket_start"`
+    InferenceCount int64                      `json:"inference_count"`
+    FeedbackCount  int64                      `json:"feedback_count"`
+    Latency        *sketchSummary             `json:"latency_ms,omitempty"`
+    Features       map[string]*featureSummary `json:"features,omitempty"`
+}
+
+func summarize(s *sketch.Sketch, quantiles []float64, histogram bool) *sketchSummary {
+    if s == nil || s.Count == 0 {
+        return nil
+    }
+    out := &sketchSummary{
+        Count:     s.Count,
+        Mean:      s.Mean(),
+        Min:       s.Min,
+        Max:       s.Max,
+        Quantiles: map[string]float64{},
+    }
+    for _, q := range quantiles {
+        out.Quantiles["p"+strconv.FormatFloat(q*100, 'f', -1, 64)] = s.Quantile(q)
+    }
+    if histogram {
+        out.Histogram = s.Histogram()
+    }
+    return out
+}
+
+// handleRollups serves the pre-aggregated rollups of a model, e.g.
+//   GET /rollups?model=fraud&from=...&to=...&quantiles=0.5,0.99&merge=true
+// merge=true folds all buckets of a version into one; features=false omits
+// the per-field aggregates.
+func (s *Server) handleRollups(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    q := r.URL.Query()
+
+    quantiles, err := parseQuantiles(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    merge := q.Get("merge") == "true"
+    withFeatures := q.Get("features") != "false"
+
+    rollups, err := s.RollupRepo.ListRollups(r.Context(), win)
+    if err != nil {
+        log.Printf("Error listing rollups: %v\n", err)
+        http.Error(w, "Failed to load rollups", http.StatusInternalServerError)
+        return
+    }
+
+    if merge {
+        byVersion := map[string]int{}
+        var merged []models.Rollup
+        for _, ro := range rollups {
+            i, ok := byVersion[ro.ModelVersion]
+            if !ok {
+                merged = append(merged, models.Rollup{ModelName: ro.ModelName, ModelVersion: ro.ModelVersion, BucketStart: ro.BucketStart})
+                i = len(merged) - 1
+                byVersion[ro.ModelVersion] = i
+            }
+            if err := merged[i].Merge(&ro); err != nil {
+                log.Printf("Error merging rollups: %v\n", err)
+                http.Error(w, "Failed to merge rollups", http.StatusInternalServerError)
+                return
+            }
+        }
+        rollups = merged
+    }
+
+    // Category counts are raw input values, so only roles that may read
+    // payloads get them.
+    payloads := s.canReadPayloads(r)
+    out := make([]rollupSummary, 0, len(rollups))
+    for _, ro := range rollups {
+        sum := rollupSummary{
+            ModelVersion:   ro.ModelVersion,
+            BucketStart:    ro.BucketStart,
+            InferenceCount: ro.InferenceCount,
+            FeedbackCount:  ro.FeedbackCount,
+            Latency:        summarize(ro.Latency, quantiles, false),
+        }
+        if withFeatures && len(ro.Features) > 0 {
+            sum.Features = map[string]*featureSummary{}
+            for field, f := range ro.Features {
+                fs := &featureSummary{
+                    Missing: f.Missing,
+                    Numeric: summarize(f.Numeric, quantiles, true),
+                }
+                if payloads {
+                    fs.Categories = f.Categories
+                }
+                sum.Features[field] = fs
+            }
+        }
+        out = append(out, sum)
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "from":    win.From,
+        "to":      win.To,
+        "rollups": out,
+    })
+}
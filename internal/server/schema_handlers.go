@@ -0,0 +1,85 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/schema"
+    "github.com/gorilla/mux"
+)
+
+const defaultSchemaEventLimit = 100
+
+// handleGetSchema returns the inferred input and output schemas of a model
+// version. With format=jsonschema each payload is rendered as a JSON Schema
+// document instead of the raw per-path statistics.
+//   GET /schemas/fraud/v1?format=jsonschema
+func (s *Server) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    format := r.URL.Query().Get("format")
+    if format != "" && format != "jsonschema" {
+        http.Error(w, "format must be jsonschema", http.StatusBadRequest)
+        return
+    }
+
+    // Save what this replica observed since the last flush, so the response
+    // includes it.
+    if s.Schemas != nil {
+        if err := s.Schemas.Flush(r.Context()); err != nil {
+            log.Printf("Error saving schemas: %v\n", err)
+        }
+    }
+    schemas, err := s.SchemaRepo.ListSchemas(r.Context(), vars["model"], vars["version"])
+    if err != nil {
+        log.Printf("Error listing schemas: %v\n", err)
+        http.Error(w, "Failed to load schema", http.StatusInternalServerError)
+        return
+    }
+    if len(schemas) == 0 {
+        http.Error(w, "Schema not found", http.StatusNotFound)
+        return
+    }
+
+    out := map[string]interface{}{}
+    for i := range schemas {
+        if format == "jsonschema" {
+            out[schemas[i].Payload] = schema.JSONSchema(&schemas[i])
+        } else {
+            out[schemas[i].Payload] = schemas[i]
+        }
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(out)
+}
+
+// handleSchemaEvents lists recent schema changes of a model.
+//   GET /schemas/events?model=fraud&version=v1&from=...&to=...&limit=100
+func (s *Server) handleSchemaEvents(w http.ResponseWriter, r *http.Request) {
+    win, err := parseWindow(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    limit, err := queryInt(r, "limit", defaultSchemaEventLimit)
+    if err != nil || limit <= 0 {
+        http.Error(w, "invalid limit", http.StatusBadRequest)
+        return
+    }
+    win.Limit = limit
+
+    events, err := s.SchemaRepo.ListSchemaEvents(r.Context(), win)
+    if err != nil {
+        log.Printf("Error listing schema events: %v\n", err)
+        http.Error(w, "Failed to load schema events", http.StatusInternalServerError)
+        return
+    }
+    if events == nil {
+        events = []models.SchemaEvent{}
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(events)
+}
@@ -2,31 +2,176 @@ package server
 
 import (
     "context"
+    "crypto/tls"
     "database/sql"
     "log"
     "net/http"
+    "sync"
+    "time"
 
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/audit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/certs"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/evaluators"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/pii"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/quality"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/ratelimit"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/schema"
     "github.com/gorilla/mux"
 )
 
+// InferenceObserver is notified after an inference has been stored.
+// Observers run synchronously on the ingest path; their errors are logged
+// and never fail the request.
+type InferenceObserver interface {
+    ObserveInference(ctx context.Context, inf models.Inference) error
+}
+
+// FeedbackObserver is notified after feedback has been stored, with the same
+// error handling as InferenceObserver.
+type FeedbackObserver interface {
+    ObserveFeedback(ctx context.Context, fb models.Feedback) error
+}
+
 // Server holds references to repositories and the router
 type Server struct {
-    InferenceRepo repository.InferenceRepository
-    FeedbackRepo  repository.FeedbackRepository
-    Router        *mux.Router
-    httpServer    *http.Server
+    Config            *config.Config
+    InferenceRepo     repository.InferenceRepository
+    FeedbackRepo      repository.FeedbackRepository
+    MetricsRepo       repository.MetricsRepository
+    RollupRepo        repository.RollupRepository
+    QualityRepo       repository.QualityRepository
+    Quality           *quality.Monitor
+    SchemaRepo        repository.SchemaRepository
+    Schemas           *schema.Tracker
+    LLMRepo           repository.LLMRepository
+    EvaluationRepo    repository.EvaluationRepository
+    Evaluations       *evaluators.Runner
+    PIIRepo           repository.PIIRepository
+    PII               *pii.Scanner
+    Encryption        *envelope.Codec
+    EnvelopeRepo      repository.EnvelopeRepository
+    AuditRepo         repository.AuditRepository
+    ErasureRepo       repository.ErasureRepository
+    Audit             *audit.Chain
+    AuditWriter       *audit.Writer
+    Auth              auth.Authenticator
+    IngestLimiter     *ratelimit.Limiter
+    QueryLimiter      *ratelimit.Limiter
+    TrustForwardedFor bool
+    MaxBodyBytes      int64
+    Certs             *certs.Reloader
+    TLS               *tls.Config
+    ReadyChecks       []HealthCheck
+    ReadyTimeout      time.Duration
+    Observers         []InferenceObserver
+    FeedbackObservers []FeedbackObserver
+    Router            *mux.Router
+    httpServer        *http.Server
+    erasures          sync.WaitGroup
 }
 
 // NewServer creates a new Server instance with the given repositories
-func NewServer(db *sql.DB) *Server {
+func NewServer(db *sql.DB, cfg *config.Config) *Server {
     infRepo := repository.NewInferenceRepository(db)
     fbRepo := repository.NewFeedbackRepository(db)
 
     s := &Server{
-        InferenceRepo: infRepo,
-        FeedbackRepo:  fbRepo,
-        Router:        mux.NewRouter(),
+        Config:         cfg,
+        InferenceRepo:  infRepo,
+        FeedbackRepo:   fbRepo,
+        MetricsRepo:    repository.NewMetricsRepository(db),
+        RollupRepo:     repository.NewRollupRepository(db),
+        QualityRepo:    repository.NewQualityRepository(db),
+        SchemaRepo:     repository.NewSchemaRepository(db),
+        LLMRepo:        repository.NewLLMRepository(db),
+        EvaluationRepo: repository.NewEvaluationRepository(db),
+        PIIRepo:        repository.NewPIIRepository(db),
+        ErasureRepo:    repository.NewErasureRepository(db),
+        Router:         mux.NewRouter(),
+    }
+    if len(cfg.EncryptionKeys) > 0 {
+        keys, err := envelope.NewKeyring(cfg.EncryptionPrimaryKey, cfg.EncryptionKeys)
+        if err != nil {
+            log.Fatalf("Invalid encryption keys: %v\n", err)
+        }
+        s.Encryption = envelope.NewCodec(keys, cfg.EncryptionSafeFields)
+        s.EnvelopeRepo = repository.NewEnvelopeRepository(db)
+        s.InferenceRepo = repository.NewEncryptedInferenceRepository(infRepo, s.Encryption)
+        s.FeedbackRepo = repository.NewEncryptedFeedbackRepository(fbRepo, s.Encryption)
+    }
+    if cfg.TLSCertFile != "" {
+        reloader, err := certs.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+        if err != nil {
+            log.Fatalf("Invalid TLS configuration: %v\n", err)
+        }
+        clientAuth := tls.RequireAndVerifyClientCert
+        if cfg.TLSClientAuth == "optional" {
+            clientAuth = tls.VerifyClientCertIfGiven
+        }
+        s.Certs = reloader
+        s.TLS = reloader.TLSConfig(clientAuth)
+    }
+    authenticators, err := newAuthenticators(cfg)
+    if err != nil {
+        log.Fatalf("Invalid authentication configuration: %v\n", err)
+    }
+    if len(authenticators) > 0 {
+        s.Auth = authenticators
+    } else {
+        log.Println("No API keys or JWKS configured; access control is disabled")
+    }
+    if cfg.RateLimitIngestRPS > 0 {
+        s.IngestLimiter = ratelimit.New(cfg.RateLimitIngestRPS, cfg.RateLimitIngestBurst)
+    }
+    if cfg.RateLimitQueryRPS > 0 {
+        s.QueryLimiter = ratelimit.New(cfg.RateLimitQueryRPS, cfg.RateLimitQueryBurst)
+    }
+    s.ReadyChecks = newReadyChecks(db)
+    s.ReadyTimeout = cfg.ReadyTimeout
+    s.TrustForwardedFor = cfg.RateLimitTrustForwardedFor
+    s.MaxBodyBytes = cfg.MaxBodyBytes
+    if cfg.AuditEnabled {
+        s.Audit = audit.NewChain([]byte(cfg.AuditHMACKey))
+        s.AuditRepo = repository.NewAuditRepository(db, s.Audit)
+        s.AuditWriter = audit.NewWriter(s.AuditRepo, cfg.AuditBatchSize, cfg.AuditQueueSize)
+    }
+    if cfg.PIIEnabled {
+        scanner, err := pii.NewScanner(cfg.PIICustomPatterns, []byte(cfg.PIIHMACKey))
+        if err != nil {
+            log.Fatalf("Invalid PII configuration: %v\n", err)
+        }
+        scanner.ScanNumbers = cfg.PIIScanNumbers
+        s.PII = scanner
+    }
+    if cfg.DataQualityEnabled {
+        s.Quality = quality.NewMonitor(s.QualityRepo, cfg.DataQualityLearningSamples)
+        s.Quality.FlushInterval = cfg.DataQualityFlushInterval
+        s.Observers = append(s.Observers, s.safeObserver(s.Quality))
+    }
+    if cfg.SchemaTrackingEnabled {
+        s.Schemas = schema.NewTracker(s.SchemaRepo, cfg.SchemaRemovalWindow)
+        s.Schemas.FlushInterval = cfg.SchemaFlushInterval
+        s.Observers = append(s.Observers, s.Schemas)
+    }
+    if cfg.EvaluatorsEnabled {
+        opts := evaluators.Options{
+            TextPath:      cfg.EvaluatorTextField,
+            ReferencePath: cfg.EvaluatorReferenceField,
+            Blocklist:     cfg.EvaluatorBlocklist,
+        }
+        evals, err := evaluators.Builtin(opts)
+        if err != nil {
+            log.Fatalf("Invalid evaluator configuration: %v\n", err)
+        }
+        s.Evaluations = evaluators.NewRunner(s.EvaluationRepo, s.InferenceRepo, evals, opts,
+            cfg.EvaluatorWorkers, cfg.EvaluatorQueueSize)
+        s.Observers = append(s.Observers, s.Evaluations)
+        s.FeedbackObservers = append(s.FeedbackObservers, s.Evaluations)
     }
     s.Routes()
     return s
@@ -34,28 +179,126 @@ func NewServer(db *sql.DB) *Server {
 
 // Routes sets up our HTTP endpoints
 func (s *Server) Routes() {
-    // Health check
+    // Caller identity, rate limits, then the audit log of mutating calls and
+    // raw payload reads
+    s.Router.Use(s.authenticate, s.rateLimit, s.auditMiddleware)
+    s.Router.HandleFunc("/audit", s.require(auth.PermAdmin, s.handleAudit)).Methods("GET")
+    s.Router.HandleFunc("/audit/verify", s.require(auth.PermAdmin, s.handleVerifyAudit)).Methods("GET")
+
+    // Health check, liveness and readiness probes
     s.Router.HandleFunc("/health", s.handleHealth).Methods("GET")
+    s.Router.HandleFunc("/livez", s.handleLivez).Methods("GET")
+    s.Router.HandleFunc("/readyz", s.handleReadyz).Methods("GET")
 
     // Inference endpoints
-    s.Router.HandleFunc("/inferences", s.handleCreateInference).Methods("POST")
-    s.Router.HandleFunc("/inferences/{id}", s.handleGetInference).Methods("GET")
+    s.Router.HandleFunc("/inferences", s.require(auth.PermIngest, s.handleCreateInference)).Methods("POST")
+    s.Router.HandleFunc("/inferences/{id}", s.require(auth.PermAggregates, s.handleGetInference)).Methods("GET")
+
+    // Traces of multi-step pipelines
+    s.Router.HandleFunc("/traces/{id}", s.require(auth.PermAggregates, s.handleGetTrace)).Methods("GET")
 
     // Feedback endpoint
-    s.Router.HandleFunc("/inferences/{id}/feedback", s.handleCreateFeedback).Methods("POST")
-    s.Router.HandleFunc("/inferences/{id}/feedback", s.handleGetFeedback).Methods("GET")
+    s.Router.HandleFunc("/inferences/{id}/feedback", s.require(auth.PermIngest, s.handleCreateFeedback)).Methods("POST")
+    s.Router.HandleFunc("/inferences/{id}/feedback", s.require(auth.PermPayloads, s.handleGetFeedback)).Methods("GET")
+
+    // Sliced performance metrics
+    s.Router.HandleFunc("/slices", s.require(auth.PermAggregates, s.handleSliceMetrics)).Methods("GET")
+    s.Router.HandleFunc("/slices/worst", s.require(auth.PermAggregates, s.handleWorstSlices)).Methods("GET")
+
+    // Fairness metrics
+    s.Router.HandleFunc("/fairness", s.require(auth.PermAggregates, s.handleFairness)).Methods("GET")
+
+    // Probability calibration
+    s.Router.HandleFunc("/calibration", s.require(auth.PermAggregates, s.handleCalibration)).Methods("GET")
+
+    // Ranking metrics (ROC/PR AUC, KS, lift)
+    s.Router.HandleFunc("/ranking", s.require(auth.PermAggregates, s.handleRanking)).Methods("GET")
+
+    // Champion/challenger comparison
+    s.Router.HandleFunc("/compare", s.require(auth.PermAggregates, s.handleCompare)).Methods("GET")
+
+    // Shadow deployment analysis
+    s.Router.HandleFunc("/shadow", s.require(auth.PermAggregates, s.handleShadow)).Methods("GET")
+
+    // Embedding drift
+    s.Router.HandleFunc("/drift/embeddings", s.require(auth.PermAggregates, s.handleEmbeddingDrift)).Methods("GET")
+
+    // Pre-aggregated rollups
+    s.Router.HandleFunc("/rollups", s.require(auth.PermAggregates, s.handleRollups)).Methods("GET")
+
+    // Input data quality
+    s.Router.HandleFunc("/data-quality", s.require(auth.PermAggregates, s.handleDataQuality)).Methods("GET")
+    s.Router.HandleFunc("/data-quality/violations", s.require(auth.PermAggregates, s.handleDataQualityViolations)).Methods("GET")
+    s.Router.HandleFunc("/data-quality/{model}/bounds", s.require(auth.PermAdmin, s.handleSetDataQualityBounds)).Methods("PUT")
+
+    // Inferred payload schemas
+    s.Router.HandleFunc("/schemas/events", s.require(auth.PermAggregates, s.handleSchemaEvents)).Methods("GET")
+    s.Router.HandleFunc("/schemas/{model}/{version}", s.require(auth.PermAggregates, s.handleGetSchema)).Methods("GET")
+
+    // LLM calls
+    s.Router.HandleFunc("/llm/calls", s.require(auth.PermIngest, s.handleCreateLLMCall)).Methods("POST")
+    s.Router.HandleFunc("/llm/calls/{id}", s.require(auth.PermPayloads, s.handleGetLLMCall)).Methods("GET")
+    s.Router.HandleFunc("/llm/usage", s.require(auth.PermAggregates, s.handleLLMUsage)).Methods("GET")
+    s.Router.HandleFunc("/llm/latency", s.require(auth.PermAggregates, s.handleLLMLatency)).Methods("GET")
+
+    // Envelope key rotation
+    s.Router.HandleFunc("/encryption/rewrap", s.require(auth.PermAdmin, s.handleRewrap)).Methods("POST")
+
+    // PII redaction records
+    s.Router.HandleFunc("/pii/redactions", s.require(auth.PermAggregates, s.handlePIIRedactions)).Methods("GET")
+
+    // Right to erasure by subject
+    s.Router.HandleFunc("/subjects/{id}", s.require(auth.PermAdmin, s.handleEraseSubject)).Methods("DELETE")
+    s.Router.HandleFunc("/erasure-jobs/{id}", s.require(auth.PermAdmin, s.handleGetErasureJob)).Methods("GET")
+
+    // Heuristic evaluator scores
+    s.Router.HandleFunc("/evaluations", s.require(auth.PermAggregates, s.handleEvaluations)).Methods("GET")
+    s.Router.HandleFunc("/inferences/{id}/evaluations", s.require(auth.PermAggregates, s.handleGetInferenceEvaluations)).Methods("GET")
+}
+
+// notifyObservers hands a stored inference to every registered observer.
+func (s *Server) notifyObservers(ctx context.Context, inf models.Inference) {
+    for _, o := range s.Observers {
+        if err := o.ObserveInference(ctx, inf); err != nil {
+            log.Printf("Error observing inference %s: %v\n", inf.ID, err)
+        }
+    }
 }
 
-// starts the HTTP server on the specified port
-func (s *Server) Start(port string) {
+// notifyFeedbackObservers hands stored feedback to every registered observer.
+func (s *Server) notifyFeedbackObservers(ctx context.Context, fb models.Feedback) {
+    for _, o := range s.FeedbackObservers {
+        if err := o.ObserveFeedback(ctx, fb); err != nil {
+            log.Printf("Error observing feedback %s: %v\n", fb.ID, err)
+        }
+    }
+}
+
+// starts the HTTP server on the specified address, e.g. ":8080"
+func (s *Server) Start(addr string) {
     s.httpServer = &http.Server{
-        Addr:    ":" + port,
-        Handler: s.Router,
+        Addr:      addr,
+        Handler:   s.Router,
+        TLSConfig: s.TLS,
+    }
+    if s.Config != nil {
+        s.httpServer.ReadTimeout = s.Config.ReadTimeout
+        s.httpServer.ReadHeaderTimeout = s.Config.ReadHeaderTimeout
+        s.httpServer.WriteTimeout = s.Config.WriteTimeout
+        s.httpServer.IdleTimeout = s.Config.IdleTimeout
     }
 
-    log.Printf("Starting HTTP server on port %s\n", port)
-    if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-        log.Fatalf("Could not listen on port %s: %v\n", port, err)
+    var err error
+    if s.TLS != nil {
+        log.Printf("Starting HTTPS server on %s\n", addr)
+        // Certificates come from TLSConfig, which follows reloads.
+        err = s.httpServer.ListenAndServeTLS("", "")
+    } else {
+        log.Printf("Starting HTTP server on %s\n", addr)
+        err = s.httpServer.ListenAndServe()
+    }
+    if err != nil && err != http.ErrServerClosed {
+        log.Fatalf("Could not listen on %s: %v\n", addr, err)
     }
 }
 
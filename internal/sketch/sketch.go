@@ -0,0 +1,202 @@
+// Package sketch implements a mergeable quantile sketch with relative-error
+// guarantees (the DDSketch algorithm). Values are counted in logarithmically
+// sized bins, so two sketches built with the same accuracy can be merged by
+// adding their bin counts, which is what lets rollups be maintained
+// incrementally.
+package sketch
+
+import (
+    "errors"
+    "math"
+    "sort"
+)
+
+// DefaultRelativeAccuracy bounds the relative error of quantile estimates.
+const DefaultRelativeAccuracy = 0.01
+
+// ErrIncompatible is returned when merging sketches of different accuracy.
+var ErrIncompatible = errors.New("sketches have different relative accuracy")
+
+// Sketch is a DDSketch. The zero value is not usable; use New.
+type Sketch struct {
+    Alpha    float64       `json:"alpha"`
+    Count    int64         `json:"count"`
+    Sum      float64       `json:"sum"`
+    Min      float64       `json:"min"`
+    Max      float64       `json:"max"`
+    Zero     int64         `json:"zero"`
+    Positive map[int]int64 `json:"pos"`
+    Negative map[int]int64 `json:"neg"`
+}
+
+// New returns an empty sketch with the given relative accuracy.
+func New(alpha float64) *Sketch {
+    return &Sketch{
+        Alpha:    alpha,
+        Positive: map[int]int64{},
+        Negative: map[int]int64{},
+    }
+}
+
+// minIndexable is the smallest magnitude that gets its own bin; anything
+// smaller is counted as zero.
+const minIndexable = 1e-9
+
+func (s *Sketch) gamma() float64 {
+    return (1 + s.Alpha) / (1 - s.Alpha)
+}
+
+func (s *Sketch) index(v float64) int {
+    return int(math.Ceil(math.Log(v) / math.Log(s.gamma())))
+}
+
+func (s *Sketch) value(idx int) float64 {
+    g := s.gamma()
+    return 2 * math.Pow(g, float64(idx)) / (g + 1)
+}
+
+// init allocates the bin maps of a sketch decoded from JSON.
+func (s *Sketch) init() {
+    if s.Positive == nil {
+        s.Positive = map[int]int64{}
+    }
+    if s.Negative == nil {
+        s.Negative = map[int]int64{}
+    }
+}
+
+// Add records one value. NaN and infinite values are ignored.
+func (s *Sketch) Add(v float64) {
+    if math.IsNaN(v) || math.IsInf(v, 0) {
+        return
+    }
+    s.init()
+    if s.Count == 0 || v < s.Min {
+        s.Min = v
+    }
+    if s.Count == 0 || v > s.Max {
+        s.Max = v
+    }
+    s.Count++
+    s.Sum += v
+    switch {
+    case v > minIndexable:
+        s.Positive[s.index(v)]++
+    case v < -minIndexable:
+        s.Negative[s.index(-v)]++
+    default:
+        s.Zero++
+    }
+}
+
+// Merge adds the contents of other into s.
+func (s *Sketch) Merge(other *Sketch) error {
+    if other == nil || other.Count == 0 {
+        return nil
+    }
+    if s.Alpha != other.Alpha {
+        return ErrIncompatible
+    }
+    s.init()
+    if s.Count == 0 || other.Min < s.Min {
+        s.Min = other.Min
+    }
+    if s.Count == 0 || other.Max > s.Max {
+        s.Max = other.Max
+    }
+    s.Count += other.Count
+    s.Sum += other.Sum
+    s.Zero += other.Zero
+    for k, c := range other.Positive {
+        s.Positive[k] += c
+    }
+    for k, c := range other.Negative {
+        s.Negative[k] += c
+    }
+    return nil
+}
+
+// Mean returns the exact mean of the recorded values.
+func (s *Sketch) Mean() float64 {
+    if s.Count == 0 {
+        return 0
+    }
+    return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1). The estimate is within
+// the relative accuracy of the true value.
+func (s *Sketch) Quantile(q float64) float64 {
+    if s.Count == 0 {
+        return 0
+    }
+    if q <= 0 {
+        return s.Min
+    }
+    if q >= 1 {
+        return s.Max
+    }
+    rank := int64(q * float64(s.Count-1))
+
+    var seen int64
+    // Negative values, from the largest magnitude to the smallest.
+    for _, idx := range sortedKeys(s.Negative, true) {
+        seen += s.Negative[idx]
+        if seen > rank {
+            return s.clamp(-s.value(idx))
+        }
+    }
+    seen += s.Zero
+    if seen > rank {
+        return 0
+    }
+    for _, idx := range sortedKeys(s.Positive, false) {
+        seen += s.Positive[idx]
+        if seen > rank {
+            return s.clamp(s.value(idx))
+        }
+    }
+    return s.Max
+}
+
+func (s *Sketch) clamp(v float64) float64 {
+    return math.Min(math.Max(v, s.Min), s.Max)
+}
+
+// Bin is one histogram bin of a sketch.
+type Bin struct {
+    Lower float64 `json:"lower"`
+    Upper float64 `json:"upper"`
+    Count int64   `json:"count"`
+}
+
+// Histogram returns the non-empty bins of the sketch in ascending order.
+func (s *Sketch) Histogram() []Bin {
+    var out []Bin
+    g := s.gamma()
+    for _, idx := range sortedKeys(s.Negative, true) {
+        hi := math.Pow(g, float64(idx))
+        out = append(out, Bin{Lower: -hi, Upper: -hi / g, Count: s.Negative[idx]})
+    }
+    if s.Zero > 0 {
+        out = append(out, Bin{Lower: 0, Upper: 0, Count: s.Zero})
+    }
+    for _, idx := range sortedKeys(s.Positive, false) {
+        hi := math.Pow(g, float64(idx))
+        out = append(out, Bin{Lower: hi / g, Upper: hi, Count: s.Positive[idx]})
+    }
+    return out
+}
+
+func sortedKeys(m map[int]int64, desc bool) []int {
+    keys := make([]int, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    if desc {
+        sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+    } else {
+        sort.Ints(keys)
+    }
+    return keys
+}
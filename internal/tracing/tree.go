@@ -0,0 +1,218 @@
+// Package tracing assembles the inferences and LLM calls that share a trace
+// ID into a span tree, so a multi-step pipeline (retrieval, LLM calls, tool
+// calls) can be inspected as one request.
+package tracing
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// ErrInvalidTraceparent is returned for malformed W3C traceparent headers.
+var ErrInvalidTraceparent = errors.New("invalid traceparent header")
+
+var traceparentRe = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// ParseTraceparent extracts the trace ID and parent span ID from a W3C Trace
+// Context traceparent header, e.g.
+//   00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(h string) (traceID, parentSpanID string, err error) {
+    m := traceparentRe.FindStringSubmatch(strings.TrimSpace(strings.ToLower(h)))
+    if m == nil || m[1] == strings.Repeat("0", 32) || m[2] == strings.Repeat("0", 16) {
+        return "", "", ErrInvalidTraceparent
+    }
+    return m[1], m[2], nil
+}
+
+// NewSpanID returns a random 16 hex digit span ID.
+func NewSpanID() string {
+    b := make([]byte, 8)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+// Span kinds.
+const (
+    KindInference = "inference"
+    KindLLMCall   = "llm_call"
+)
+
+// Span is one inference or LLM call within a trace. For LLM calls,
+// ModelName is the model, InputData the prompt and OutputData the
+// completion.
+type Span struct {
+    Kind         string          `json:"kind"`
+    SpanID       string          `json:"span_id"`
+    ParentSpanID string          `json:"parent_span_id,omitempty"`
+    InferenceID  string          `json:"inference_id,omitempty"`
+    CallID       string          `json:"call_id,omitempty"`
+    ModelName    string          `json:"model_name"`
+    ModelVersion string          `json:"model_version"`
+    StartedAt    time.Time       `json:"started_at"`
+    EndedAt      time.Time       `json:"ended_at"`
+    // OffsetMs is the start relative to the start of the trace.
+    OffsetMs float64 `json:"offset_ms"`
+    // DurationMs is the logged latency; spans without one are instants.
+    DurationMs *float64        `json:"duration_ms,omitempty"`
+    InputData  json.RawMessage `json:"input_data"`
+    OutputData json.RawMessage `json:"output_data"`
+    Tags       json.RawMessage `json:"tags,omitempty"`
+    // Orphan is set on spans whose parent was not logged.
+    Orphan   bool    `json:"orphan,omitempty"`
+    Children []*Span `json:"children,omitempty"`
+}
+
+// Trace is the assembled span tree.
+type Trace struct {
+    TraceID    string    `json:"trace_id"`
+    StartedAt  time.Time `json:"started_at"`
+    EndedAt    time.Time `json:"ended_at"`
+    DurationMs float64   `json:"duration_ms"`
+    SpanCount  int       `json:"span_count"`
+    Roots      []*Span   `json:"roots"`
+}
+
+// Build assembles the inferences and LLM calls of one trace into a tree. A
+// span starts at started_at (or created_at when not given) and lasts
+// latency_ms. Spans whose parent is missing, and spans caught in a parent
+// cycle, become roots marked as orphans.
+func Build(traceID string, infs []models.Inference, calls []models.LLMCall) *Trace {
+    t := &Trace{TraceID: traceID, SpanCount: len(infs) + len(calls), Roots: []*Span{}}
+    if t.SpanCount == 0 {
+        return t
+    }
+
+    spans := make([]*Span, 0, t.SpanCount)
+    for _, inf := range infs {
+        spans = append(spans, newSpan(inf))
+    }
+    for _, c := range calls {
+        spans = append(spans, newCallSpan(c))
+    }
+    byID := map[string]*Span{}
+    for i, sp := range spans {
+        if _, dup := byID[sp.SpanID]; !dup && sp.SpanID != "" {
+            byID[sp.SpanID] = sp
+        }
+        if i == 0 || sp.StartedAt.Before(t.StartedAt) {
+            t.StartedAt = sp.StartedAt
+        }
+        if i == 0 || sp.EndedAt.After(t.EndedAt) {
+            t.EndedAt = sp.EndedAt
+        }
+    }
+    t.DurationMs = float64(t.EndedAt.Sub(t.StartedAt)) / float64(time.Millisecond)
+
+    children := map[*Span][]*Span{}
+    var roots []*Span
+    for _, sp := range spans {
+        sp.OffsetMs = float64(sp.StartedAt.Sub(t.StartedAt)) / float64(time.Millisecond)
+        parent, ok := byID[sp.ParentSpanID]
+        switch {
+        case sp.ParentSpanID == "":
+            roots = append(roots, sp)
+        case !ok || parent == sp:
+            sp.Orphan = true
+            roots = append(roots, sp)
+        default:
+            children[parent] = append(children[parent], sp)
+        }
+    }
+
+    visited := map[*Span]bool{}
+    var attach func(sp *Span)
+    attach = func(sp *Span) {
+        visited[sp] = true
+        for _, c := range children[sp] {
+            if !visited[c] {
+                sp.Children = append(sp.Children, c)
+                attach(c)
+            }
+        }
+        sortSpans(sp.Children)
+    }
+    for _, sp := range roots {
+        attach(sp)
+    }
+    for _, sp := range spans {
+        if !visited[sp] {
+            sp.Orphan = true
+            roots = append(roots, sp)
+            attach(sp)
+        }
+    }
+    sortSpans(roots)
+    t.Roots = roots
+    return t
+}
+
+func newSpan(inf models.Inference) *Span {
+    start, end := spanTimes(inf.CreatedAt, inf.StartedAt, inf.LatencyMs)
+    return &Span{
+        Kind:         KindInference,
+        SpanID:       inf.SpanID,
+        ParentSpanID: inf.ParentSpanID,
+        InferenceID:  inf.ID,
+        ModelName:    inf.ModelName,
+        ModelVersion: inf.ModelVersion,
+        StartedAt:    start,
+        EndedAt:      end,
+        DurationMs:   inf.LatencyMs,
+        InputData:    rawJSON(inf.InputData),
+        OutputData:   rawJSON(inf.OutputData),
+        Tags:         rawJSON(inf.Tags),
+    }
+}
+
+func newCallSpan(c models.LLMCall) *Span {
+    start, end := spanTimes(c.CreatedAt, c.StartedAt, c.LatencyMs)
+    prompt, _ := json.Marshal(c.Prompt)
+    completion, _ := json.Marshal(c.Completion)
+    return &Span{
+        Kind:         KindLLMCall,
+        SpanID:       c.SpanID,
+        ParentSpanID: c.ParentSpanID,
+        CallID:       c.ID,
+        ModelName:    c.Model,
+        StartedAt:    start,
+        EndedAt:      end,
+        DurationMs:   c.LatencyMs,
+        InputData:    prompt,
+        OutputData:   completion,
+        Tags:         rawJSON(c.Tags),
+    }
+}
+
+// spanTimes returns when a span started and ended.
+func spanTimes(createdAt time.Time, startedAt *time.Time, latencyMs *float64) (time.Time, time.Time) {
+    start := createdAt
+    if startedAt != nil {
+        start = *startedAt
+    }
+    end := start
+    if latencyMs != nil {
+        end = start.Add(time.Duration(*latencyMs * float64(time.Millisecond)))
+    }
+    return start, end
+}
+
+func rawJSON(s string) json.RawMessage {
+    if s == "" || !json.Valid([]byte(s)) {
+        return nil
+    }
+    return json.RawMessage(s)
+}
+
+func sortSpans(spans []*Span) {
+    sort.SliceStable(spans, func(i, j int) bool {
+        return spans[i].StartedAt.Before(spans[j].StartedAt)
+    })
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_inferences_model_name_created_at;
+ALTER TABLE inferences DROP COLUMN IF EXISTS tags;
\ No newline at end of file
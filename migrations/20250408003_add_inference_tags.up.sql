@@ -0,0 +1,5 @@
+ALTER TABLE inferences
+    ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS index_inferences_model_name_created_at
+    ON inferences (model_name, created_at);
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS rollup_watermarks;
+DROP TABLE IF EXISTS rollup_features;
+DROP TABLE IF EXISTS rollups;
+DROP INDEX IF EXISTS index_feedback_created_at_id;
+DROP INDEX IF EXISTS index_inferences_created_at_id;
+ALTER TABLE inferences DROP COLUMN IF EXISTS latency_ms;
\ No newline at end of file
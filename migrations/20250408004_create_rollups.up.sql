@@ -0,0 +1,34 @@
+ALTER TABLE inferences
+    ADD COLUMN IF NOT EXISTS latency_ms DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS index_inferences_created_at_id
+    ON inferences (created_at, id);
+
+CREATE INDEX IF NOT EXISTS index_feedback_created_at_id
+    ON feedback (created_at, id);
+
+CREATE TABLE IF NOT EXISTS rollups (
+    model_name TEXT NOT NULL,
+    model_version TEXT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    inference_count BIGINT NOT NULL DEFAULT 0,
+    feedback_count BIGINT NOT NULL DEFAULT 0,
+    latency_sketch JSONB,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (model_name, model_version, bucket_start)
+);
+
+CREATE TABLE IF NOT EXISTS rollup_features (
+    model_name TEXT NOT NULL,
+    model_version TEXT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    field TEXT NOT NULL,
+    aggregate JSONB NOT NULL,
+    PRIMARY KEY (model_name, model_version, bucket_start, field)
+);
+
+CREATE TABLE IF NOT EXISTS rollup_watermarks (
+    stream TEXT PRIMARY KEY,
+    last_created_at TIMESTAMPTZ NOT NULL,
+    last_id UUID NOT NULL
+);
\ No newline at end of file
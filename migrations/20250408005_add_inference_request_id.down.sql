@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS index_inferences_request_id;
+ALTER TABLE inferences
+    DROP COLUMN IF EXISTS is_shadow,
+    DROP COLUMN IF EXISTS request_id;
\ No newline at end of file
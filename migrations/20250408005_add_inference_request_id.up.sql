@@ -0,0 +1,7 @@
+ALTER TABLE inferences
+    ADD COLUMN IF NOT EXISTS request_id TEXT,
+    ADD COLUMN IF NOT EXISTS is_shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS index_inferences_request_id
+    ON inferences (request_id)
+    WHERE request_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS data_quality_input_hashes;
+DROP INDEX IF EXISTS index_data_quality_violations_model_created_at;
+DROP TABLE IF EXISTS data_quality_violations;
+DROP TABLE IF EXISTS data_quality_profiles;
\ No newline at end of file
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS data_quality_profiles (
+    model_name TEXT PRIMARY KEY,
+    profile JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS data_quality_violations (
+    id UUID PRIMARY KEY,
+    inference_id UUID NOT NULL,
+    model_name TEXT NOT NULL,
+    field TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    detail TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_violation_inference
+        FOREIGN KEY (inference_id)
+            REFERENCES inferences(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_data_quality_violations_model_created_at
+    ON data_quality_violations (model_name, created_at);
+
+CREATE TABLE IF NOT EXISTS data_quality_input_hashes (
+    model_name TEXT NOT NULL,
+    input_hash TEXT NOT NULL,
+    seen_count BIGINT NOT NULL DEFAULT 1,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (model_name, input_hash)
+);
\ No newline at end of file
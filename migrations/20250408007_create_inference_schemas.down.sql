@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS index_schema_events_model_created_at;
+DROP TABLE IF EXISTS schema_events;
+DROP TABLE IF EXISTS inference_schemas;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS inference_schemas (
+    model_name TEXT NOT NULL,
+    model_version TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    schema JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (model_name, model_version, payload)
+);
+
+CREATE TABLE IF NOT EXISTS schema_events (
+    id UUID PRIMARY KEY,
+    model_name TEXT NOT NULL,
+    model_version TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    field TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    detail TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS index_schema_events_model_created_at
+    ON schema_events (model_name, created_at);
\ No newline at end of file
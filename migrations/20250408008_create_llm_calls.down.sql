@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS index_llm_calls_created_at;
+DROP INDEX IF EXISTS index_llm_calls_model_created_at;
+DROP TABLE IF EXISTS llm_calls;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS llm_calls (
+    id UUID PRIMARY KEY,
+    model TEXT NOT NULL,
+    provider TEXT,
+    prompt TEXT NOT NULL,
+    completion TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION,
+    latency_ms DOUBLE PRECISION,
+    finish_reason TEXT,
+    tags JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS index_llm_calls_model_created_at
+    ON llm_calls (model, created_at);
+
+CREATE INDEX IF NOT EXISTS index_llm_calls_created_at
+    ON llm_calls (created_at);
\ No newline at end of file
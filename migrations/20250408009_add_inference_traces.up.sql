@@ -0,0 +1,9 @@
+ALTER TABLE inferences
+    ADD COLUMN IF NOT EXISTS trace_id TEXT,
+    ADD COLUMN IF NOT EXISTS span_id TEXT,
+    ADD COLUMN IF NOT EXISTS parent_span_id TEXT,
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS index_inferences_trace_id
+    ON inferences (trace_id)
+    WHERE trace_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_evaluation_scores_model_evaluator_created_at;
+DROP TABLE IF EXISTS evaluation_scores;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS evaluation_scores (
+    id UUID PRIMARY KEY,
+    inference_id UUID NOT NULL,
+    model_name TEXT NOT NULL,
+    model_version TEXT NOT NULL,
+    evaluator TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    label TEXT,
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_evaluation_inference
+        FOREIGN KEY (inference_id)
+            REFERENCES inferences(id)
+            ON DELETE CASCADE,
+    CONSTRAINT unique_evaluation_inference_evaluator
+        UNIQUE (inference_id, evaluator)
+);
+
+CREATE INDEX IF NOT EXISTS index_evaluation_scores_model_evaluator_created_at
+    ON evaluation_scores (model_name, evaluator, created_at);
\ No newline at end of file
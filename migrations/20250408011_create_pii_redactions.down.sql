@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS index_pii_redactions_model_created_at;
+DROP INDEX IF EXISTS index_pii_redactions_inference_id;
+DROP TABLE IF EXISTS pii_redactions;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS pii_redactions (
+    id UUID PRIMARY KEY,
+    inference_id UUID NOT NULL,
+    model_name TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    path TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    action TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_pii_redaction_inference
+        FOREIGN KEY (inference_id)
+            REFERENCES inferences(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_pii_redactions_inference_id
+    ON pii_redactions (inference_id);
+
+CREATE INDEX IF NOT EXISTS index_pii_redactions_model_created_at
+    ON pii_redactions (model_name, created_at);
\ No newline at end of file
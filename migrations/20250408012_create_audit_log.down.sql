@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+DROP TRIGGER IF EXISTS audit_log_no_modify ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP INDEX IF EXISTS index_audit_log_resource_id_seq;
+DROP INDEX IF EXISTS index_audit_log_actor_seq;
+DROP TABLE IF EXISTS audit_log;
\ No newline at end of file
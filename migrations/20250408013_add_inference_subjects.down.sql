@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS index_erasure_jobs_subject_hash;
+DROP TABLE IF EXISTS erasure_jobs;
+
+ALTER TABLE feedback
+    DROP CONSTRAINT IF EXISTS fk_inference,
+    ADD CONSTRAINT fk_inference
+        FOREIGN KEY (inference_id)
+            REFERENCES inferences(id);
+
+DROP INDEX IF EXISTS index_inferences_subject_id;
+ALTER TABLE inferences DROP COLUMN IF EXISTS subject_id;
\ No newline at end of file
@@ -0,0 +1,28 @@
+ALTER TABLE inferences ADD COLUMN IF NOT EXISTS subject_id TEXT;
+
+CREATE INDEX IF NOT EXISTS index_inferences_subject_id
+    ON inferences (subject_id)
+    WHERE subject_id IS NOT NULL;
+
+ALTER TABLE feedback
+    DROP CONSTRAINT IF EXISTS fk_inference,
+    ADD CONSTRAINT fk_inference
+        FOREIGN KEY (inference_id)
+            REFERENCES inferences(id)
+            ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS erasure_jobs (
+    id UUID PRIMARY KEY,
+    subject_hash TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    status TEXT NOT NULL,
+    batches INTEGER NOT NULL DEFAULT 0,
+    inferences INTEGER NOT NULL DEFAULT 0,
+    feedback INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS index_erasure_jobs_subject_hash
+    ON erasure_jobs (subject_hash);
\ No newline at end of file
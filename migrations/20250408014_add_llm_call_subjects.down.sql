@@ -0,0 +1,4 @@
+ALTER TABLE erasure_jobs DROP COLUMN IF EXISTS llm_calls;
+
+DROP INDEX IF EXISTS index_llm_calls_subject_id;
+ALTER TABLE llm_calls DROP COLUMN IF EXISTS subject_id;
\ No newline at end of file
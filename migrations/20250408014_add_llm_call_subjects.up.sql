@@ -0,0 +1,7 @@
+ALTER TABLE llm_calls ADD COLUMN IF NOT EXISTS subject_id TEXT;
+
+CREATE INDEX IF NOT EXISTS index_llm_calls_subject_id
+    ON llm_calls (subject_id)
+    WHERE subject_id IS NOT NULL;
+
+ALTER TABLE erasure_jobs ADD COLUMN IF NOT EXISTS llm_calls INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS index_llm_calls_trace_id;
+
+ALTER TABLE llm_calls
+    DROP COLUMN IF EXISTS started_at,
+    DROP COLUMN IF EXISTS parent_span_id,
+    DROP COLUMN IF EXISTS span_id,
+    DROP COLUMN IF EXISTS trace_id;
\ No newline at end of file
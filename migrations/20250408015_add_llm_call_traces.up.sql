@@ -0,0 +1,9 @@
+ALTER TABLE llm_calls
+    ADD COLUMN IF NOT EXISTS trace_id TEXT,
+    ADD COLUMN IF NOT EXISTS span_id TEXT,
+    ADD COLUMN IF NOT EXISTS parent_span_id TEXT,
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS index_llm_calls_trace_id
+    ON llm_calls (trace_id)
+    WHERE trace_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations, so the binary applies them
+// regardless of its working directory.
+package migrations
+
+import "embed"
+
+// FS holds the NNN_name.up.sql and NNN_name.down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
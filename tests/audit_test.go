@@ -0,0 +1,246 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/audit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
+)
+
+func setupAuditServer() (*server.Server, *MockAuditRepo) {
+    s := setupMockServer()
+    s.Audit = audit.NewChain([]byte("audit-key"))
+    repo := &MockAuditRepo{Chain: s.Audit}
+    s.AuditRepo = repo
+    return s, repo
+}
+
+func TestChain_DetectsTampering(t *testing.T) {
+    chain := audit.NewChain(nil)
+    var entries []models.AuditEntry
+    prevSeq, prevHash := int64(0), audit.GenesisHash
+    for _, actor := range []string{"alice", "bob", "carol"} {
+        e := models.AuditEntry{Actor: actor, Method: "GET", Route: "/inferences/{id}", Status: 200,
+            Outcome: audit.OutcomeSuccess, CreatedAt: time.Now()}
+        chain.Link(&e, prevSeq, prevHash)
+        prevSeq, prevHash = e.Seq, e.Hash
+        entries = append(entries, e)
+    }
+    if err := chain.Verify(entries, 0, audit.GenesisHash); err != nil {
+        t.Fatalf("Expected a valid chain, got %v", err)
+    }
+
+    modified := append([]models.AuditEntry{}, entries...)
+    modified[1].Actor = "mallory"
+    if err, ok := chain.Verify(modified, 0, audit.GenesisHash).(*audit.ChainError); !ok || err.Seq != 2 {
+        t.Errorf("Expected a break at seq 2 for a modified entry, got %v", err)
+    }
+
+    removed := []models.AuditEntry{entries[0], entries[2]}
+    if err, ok := chain.Verify(removed, 0, audit.GenesisHash).(*audit.ChainError); !ok || err.Seq != 3 {
+        t.Errorf("Expected a break at seq 3 for a removed entry, got %v", err)
+    }
+
+    // Rehashing under another key does not produce a valid chain.
+    if err := audit.NewChain([]byte("key")).Verify(entries, 0, audit.GenesisHash); err == nil {
+        t.Errorf("Expected keyed verification of unkeyed entries to fail")
+    }
+}
+
+func TestAuditMiddleware_RecordsWritesAndPayloadReads(t *testing.T) {
+    s, repo := setupAuditServer()
+
+    req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{
+        "model_name": "fraud", "model_version": "v1", "input_data": {"x": 1}, "output_data": {"y": 0}
+    }`))
+    req.Header.Set("X-Actor", "ingest-service")
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var created map[string]string
+    json.NewDecoder(rr.Body).Decode(&created)
+    id := created["inference_id"]
+
+    for _, path := range []string{"/inferences/" + id, "/inferences/" + id + "/feedback", "/inferences/missing"} {
+        req, _ = http.NewRequest("GET", path, nil)
+        req.Header.Set("X-Actor", "analyst@example.com")
+        s.Router.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    // Aggregate reads are not audited.
+    req, _ = http.NewRequest("GET", "/health", nil)
+    s.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+    if len(repo.Entries) != 4 {
+        t.Fatalf("Expected 4 audit entries, got %d: %+v", len(repo.Entries), repo.Entries)
+    }
+    first := repo.Entries[0]
+    if first.Actor != "ingest-service" || first.Method != "POST" || first.Route != "/inferences" ||
+        first.ResourceID != id || first.Status != http.StatusCreated || first.Outcome != audit.OutcomeSuccess {
+        t.Errorf("Unexpected entry for the write: %+v", first)
+    }
+    if e := repo.Entries[2]; e.Route != "/inferences/{id}/feedback" || e.ResourceID != id || e.Actor != "analyst@example.com" {
+        t.Errorf("Unexpected entry for the feedback read: %+v", e)
+    }
+    if e := repo.Entries[3]; e.ResourceID != "missing" || e.Status != http.StatusNotFound || e.Outcome != audit.OutcomeRejected {
+        t.Errorf("Unexpected entry for the failed read: %+v", e)
+    }
+
+    req, _ = http.NewRequest("GET", "/audit?after=1&limit=2", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var page struct {
+        Entries   []models.AuditEntry `json:"entries"`
+        NextAfter int64               `json:"next_after"`
+        More      bool                `json:"more"`
+    }
+    json.NewDecoder(rr.Body).Decode(&page)
+    if rr.Code != http.StatusOK || len(page.Entries) != 2 || page.Entries[0].Seq != 2 || page.NextAfter != 3 || !page.More {
+        t.Errorf("Unexpected audit page: %d %+v", rr.Code, page)
+    }
+
+    req, _ = http.NewRequest("GET", "/audit/verify", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var verify map[string]interface{}
+    json.NewDecoder(rr.Body).Decode(&verify)
+    if verify["valid"] != true || verify["entries"] != float64(4) {
+        t.Errorf("Expected a valid chain of 4 entries, got %v", verify)
+    }
+
+    repo.Entries[1].ResourceID = "other"
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    verify = nil
+    json.NewDecoder(rr.Body).Decode(&verify)
+    if verify["valid"] != false || verify["broken_seq"] != float64(2) {
+        t.Errorf("Expected the chain to break at seq 2, got %v", verify)
+    }
+}
+
+func TestAuditMiddleware_RecordsQuotedPayloadReads(t *testing.T) {
+    s, repo := setupAuditServer()
+    s.MetricsRepo = &MockMetricsRepo{}
+    s.QualityRepo = NewMockQualityRepo()
+    s.EvaluationRepo = &MockEvaluationRepo{}
+
+    // These return inputs or details quoting them to callers allowed to see
+    // payloads, so they are audited like the payload reads.
+    for _, path := range []string{"/shadow?model=fraud&primary=v1&shadow=v2", "/data-quality/violations?model=fraud",
+        "/inferences/abc/evaluations", "/evaluations?model=fraud"} {
+        req, _ := http.NewRequest("GET", path, nil)
+        s.Router.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    var routes []string
+    for _, e := range repo.Entries {
+        routes = append(routes, e.Route)
+    }
+    if strings.Join(routes, " ") != "/shadow /data-quality/violations /inferences/{id}/evaluations" {
+        t.Errorf("Unexpected audited routes: %v", routes)
+    }
+}
+
+func TestAuditRepo_AppendChainsToLastEntry(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    chain := audit.NewChain(nil)
+    repo := repository.NewAuditRepository(db, chain)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).WillReturnResult(sqlmock.NewResult(0, 0))
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`)).
+        WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}).AddRow(41, "abc"))
+    mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).WithArgs(int64(42), "alice",
+        sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+        sqlmock.AnyArg(), sqlmock.AnyArg(), "abc", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).WithArgs(int64(43), "bob",
+        sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+        sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectCommit()
+
+    // A batch takes the lock once and chains its entries to each other.
+    entries, err := repo.Append(context.Background(),
+        models.AuditEntry{Actor: "alice", Method: "DELETE", Route: "/subjects/{id}", Status: 200,
+            Outcome: audit.OutcomeSuccess, CreatedAt: time.Now()},
+        models.AuditEntry{Actor: "bob", Method: "POST", Route: "/inferences", Status: 201,
+            Outcome: audit.OutcomeSuccess, CreatedAt: time.Now()})
+    if err != nil {
+        t.Fatalf("Append returned error: %v", err)
+    }
+    if len(entries) != 2 || entries[0].Seq != 42 || entries[0].PrevHash != "abc" || entries[0].Hash != chain.Hash(entries[0]) ||
+        entries[1].Seq != 43 || entries[1].PrevHash != entries[0].Hash {
+        t.Errorf("Unexpected appended entries: %+v", entries)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
+
+func TestAuditWriter_RetriesFailedAppends(t *testing.T) {
+    _, repo := setupAuditServer()
+    repo.Fail = 1
+    writer := audit.NewWriter(repo, 10, 100)
+    writer.RetryInitial = time.Millisecond
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go writer.Run(ctx)
+    writer.Write(models.AuditEntry{Actor: "alice", Method: "POST", Route: "/inferences", Status: 201})
+    writer.Wait()
+
+    if len(repo.Entries) != 1 || repo.Appends != 2 {
+        t.Errorf("Expected the entry stored on the second append, got %d entries in %d appends",
+            len(repo.Entries), repo.Appends)
+    }
+}
+
+func TestAuditWriter_AppendsInBatches(t *testing.T) {
+    s, repo := setupAuditServer()
+    writer := audit.NewWriter(repo, 3, 100)
+    s.AuditWriter = writer
+
+    // Queued before the writer runs, so they are batched deterministically.
+    for i := 0; i < 7; i++ {
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{
+            "model_name": "fraud", "model_version": "v1", "input_data": {"x": 1}, "output_data": {"y": 0}
+        }`))
+        s.Router.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    if len(repo.Entries) != 0 {
+        t.Fatalf("Expected entries to be queued, got %d appended", len(repo.Entries))
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() {
+        writer.Run(ctx)
+        close(done)
+    }()
+    writer.Wait()
+    if len(repo.Entries) != 7 || repo.Appends != 3 {
+        t.Errorf("Expected 7 entries in 3 appends, got %d in %d", len(repo.Entries), repo.Appends)
+    }
+    if err := s.Audit.Verify(repo.Entries, 0, audit.GenesisHash); err != nil {
+        t.Errorf("Expected a valid chain, got %v", err)
+    }
+
+    // Entries queued when the writer is stopped are still appended.
+    writer.Write(models.AuditEntry{Actor: "late", Method: "POST", Route: "/inferences", Status: 201})
+    cancel()
+    <-done
+    if len(repo.Entries) != 8 || repo.Entries[7].Actor != "late" {
+        t.Errorf("Expected the queued entry to be appended on stop, got %+v", repo.Entries)
+    }
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gorilla/mux"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/quality"
+)
+
+func TestRoles_Permissions(t *testing.T) {
+    cases := []struct {
+        role  auth.Role
+        perm  auth.Permission
+        allow bool
+    }{
+        {auth.RoleViewer, auth.PermAggregates, true},
+        {auth.RoleViewer, auth.PermPayloads, false},
+        {auth.RoleAnalyst, auth.PermPayloads, true},
+        {auth.RoleAnalyst, auth.PermIngest, false},
+        {auth.RoleWriter, auth.PermIngest, true},
+        {auth.RoleWriter, auth.PermAggregates, false},
+        {auth.RoleAdmin, auth.PermAdmin, true},
+    }
+    for _, c := range cases {
+        if got := c.role.Can(c.perm); got != c.allow {
+            t.Errorf("%s.Can(%d) = %v, want %v", c.role, c.perm, got, c.allow)
+        }
+    }
+    if _, err := auth.ParseRole("intern"); err == nil {
+        t.Errorf("Expected an error for an unknown role")
+    }
+    var nobody *auth.Identity
+    if nobody.Can(auth.PermAggregates) {
+        t.Errorf("Expected a nil identity to have no permissions")
+    }
+}
+
+func TestRBAC_EnforcedPerRoute(t *testing.T) {
+    s, auditRepo := setupAuditServer()
+    keys := auth.NewAPIKeys()
+    keys.Add("writer-key", auth.Identity{Name: "ingest", Role: auth.RoleWriter})
+    keys.Add("viewer-key", auth.Identity{Name: "intern", Role: auth.RoleViewer})
+    digest := sha256.Sum256([]byte("analyst-key"))
+    if err := keys.AddDigest(hex.EncodeToString(digest[:]), auth.Identity{Name: "ds", Role: auth.RoleAnalyst}); err != nil {
+        t.Fatalf("AddDigest returned error: %v", err)
+    }
+    s.Auth = keys
+
+    call := func(method, path, key, body string) *httptest.ResponseRecorder {
+        req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+        if key != "" {
+            req.Header.Set(auth.APIKeyHeader, key)
+        }
+        req.Header.Set("X-Actor", "spoofed")
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        return rr
+    }
+
+    body := `{"model_name": "churn", "model_version": "v1", "input_data": {"email_body": "hi"}, "output_data": {"p": 1}}`
+    if rr := call("POST", "/inferences", "", body); rr.Code != http.StatusUnauthorized {
+        t.Errorf("Expected 401 without a key, got %d", rr.Code)
+    }
+    if rr := call("POST", "/inferences", "wrong", body); rr.Code != http.StatusUnauthorized {
+        t.Errorf("Expected 401 for an unknown key, got %d", rr.Code)
+    }
+    if rr := call("POST", "/inferences", "viewer-key", body); rr.Code != http.StatusForbidden {
+        t.Errorf("Expected 403 for a viewer ingesting, got %d", rr.Code)
+    }
+    rr := call("POST", "/inferences", "writer-key", body)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201 for a writer, got %d", rr.Code)
+    }
+    var created map[string]string
+    json.NewDecoder(rr.Body).Decode(&created)
+    id := created["inference_id"]
+
+    // Viewers see the inference without its payloads; analysts see everything.
+    rr = call("GET", "/inferences/"+id, "viewer-key", "")
+    var inf models.Inference
+    json.NewDecoder(rr.Body).Decode(&inf)
+    if rr.Code != http.StatusOK || strings.Contains(inf.InputData, "email_body") || strings.Contains(inf.OutputData, `"p"`) {
+        t.Errorf("Unexpected inference for a viewer: %d %+v", rr.Code, inf)
+    }
+    rr = call("GET", "/inferences/"+id, "analyst-key", "")
+    if !strings.Contains(rr.Body.String(), "email_body") {
+        t.Errorf("Expected raw input for an analyst, got %s", rr.Body.String())
+    }
+    if rr := call("GET", "/inferences/"+id+"/feedback", "viewer-key", ""); rr.Code != http.StatusForbidden {
+        t.Errorf("Expected 403 for a viewer reading feedback, got %d", rr.Code)
+    }
+    if rr := call("GET", "/inferences/"+id+"/feedback", "analyst-key", ""); rr.Code != http.StatusOK {
+        t.Errorf("Expected 200 for an analyst reading feedback, got %d", rr.Code)
+    }
+    if rr := call("GET", "/audit", "analyst-key", ""); rr.Code != http.StatusForbidden {
+        t.Errorf("Expected 403 for an analyst reading the audit log, got %d", rr.Code)
+    }
+    if rr := call("DELETE", "/subjects/alice", "writer-key", ""); rr.Code != http.StatusForbidden {
+        t.Errorf("Expected 403 for a writer erasing a subject, got %d", rr.Code)
+    }
+    if rr := call("GET", "/health", "", ""); rr.Code != http.StatusOK {
+        t.Errorf("Expected health checks to stay open, got %d", rr.Code)
+    }
+
+    // Refused requests are audited under the authenticated name, never the
+    // X-Actor header.
+    var denied int
+    for _, e := range auditRepo.Entries {
+        if e.Actor == "spoofed" {
+            t.Errorf("Audit entry took the actor from X-Actor: %+v", e)
+        }
+        if e.Outcome == "denied" {
+            denied++
+        }
+    }
+    if denied != 5 || auditRepo.Entries[2].Actor != "intern" {
+        t.Errorf("Unexpected audit entries: %+v", auditRepo.Entries)
+    }
+}
+
+func TestRBAC_ViewerGetsNoRawPayloads(t *testing.T) {
+    const secret = "SECRET-7731"
+    s, _ := setupAuditServer()
+    now := time.Now().UTC()
+    s.InferenceRepo.InsertInference(context.Background(), models.Inference{
+        ID: "inf-1", ModelName: "m", ModelVersion: "v1", TraceID: "trace-1", SpanID: "span-1",
+        InputData: `{"note": "` + secret + `"}`, OutputData: `{"label": "` + secret + `"}`,
+        Tags: `{}`, CreatedAt: now,
+    })
+    s.MetricsRepo = &MockMetricsRepo{Pairs: []models.ShadowPair{{
+        RequestID: "r1", CreatedAt: now, InputData: `{"note": "` + secret + `"}`,
+        PrimaryID: "inf-1", PrimaryOutput: `{"prediction": "a"}`, ShadowID: "inf-2", ShadowOutput: `{"prediction": "b"}`,
+    }}}
+    qualityRepo := NewMockQualityRepo()
+    qualityRepo.Violations = []models.QualityViolation{{ID: "qv-1", InferenceID: "inf-1", ModelName: "m",
+        Field: "note", Kind: "unseen_category", Detail: fmt.Sprintf("unseen value %q", secret), CreatedAt: now}}
+    qualityRepo.Profiles["m"] = &models.QualityProfile{ModelName: "m", Samples: 10, Fields: map[string]*models.FieldProfile{
+        "note": {Present: 10, Types: map[string]int64{"string": 10},
+            Learned: &models.FieldBounds{Type: "string", Allowed: []string{secret}}},
+    }}
+    s.QualityRepo = qualityRepo
+    s.Quality = quality.NewMonitor(qualityRepo, 5)
+    s.EvaluationRepo = &MockEvaluationRepo{Scores: []models.EvaluationScore{{ID: "ev-1", InferenceID: "inf-1",
+        ModelName: "m", ModelVersion: "v1", Evaluator: "regex", Value: 1, Detail: "matched " + secret, CreatedAt: now}}}
+    s.LLMRepo = &MockLLMRepo{Calls: []models.LLMCall{{ID: "inf-1", Model: "m", Prompt: secret, Completion: secret, TraceID: "trace-1", CreatedAt: now}}}
+    s.PIIRepo = &MockPIIRepo{}
+    rollups := NewMockRollupRepo()
+    rollups.Stored["m"] = &models.Rollup{ModelName: "m", ModelVersion: "v1", BucketStart: now.Truncate(time.Hour),
+        InferenceCount: 1, Features: map[string]*models.FeatureRollup{"note": {Categories: map[string]int64{secret: 1}}}}
+    s.RollupRepo = rollups
+    s.SchemaRepo = NewMockSchemaRepo()
+    keys := auth.NewAPIKeys()
+    keys.Add("viewer-key", auth.Identity{Name: "intern", Role: auth.RoleViewer})
+    s.Auth = keys
+
+    query := url.Values{
+        "model": {"m"}, "version": {"v1"}, "primary": {"v1"}, "shadow": {"v2"}, "a": {"v1"}, "b": {"v2"},
+        "from": {now.Add(-time.Hour).Format(time.RFC3339)}, "to": {now.Add(time.Hour).Format(time.RFC3339)},
+    }.Encode()
+    ok := map[string]bool{}
+    s.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+        tmpl, _ := route.GetPathTemplate()
+        if methods, _ := route.GetMethods(); len(methods) == 0 || methods[0] != "GET" {
+            return nil
+        }
+        id := "inf-1"
+        if strings.HasPrefix(tmpl, "/traces/") {
+            id = "trace-1"
+        }
+        path := strings.NewReplacer("{id}", id, "{model}", "m", "{version}", "v1").Replace(tmpl)
+        req, _ := http.NewRequest("GET", path+"?"+query, nil)
+        req.Header.Set(auth.APIKeyHeader, "viewer-key")
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if strings.Contains(rr.Body.String(), secret) {
+            t.Errorf("GET %s leaked a raw payload to a viewer: %s", tmpl, rr.Body.String())
+        }
+        ok[tmpl] = rr.Code == http.StatusOK
+        return nil
+    })
+
+    // The routes that hold payloads must have answered, or the check above
+    // proved nothing.
+    for _, tmpl := range []string{"/inferences/{id}", "/traces/{id}", "/shadow",
+        "/data-quality", "/data-quality/violations", "/inferences/{id}/evaluations", "/rollups"} {
+        if !ok[tmpl] {
+            t.Errorf("Expected 200 from GET %s for a viewer", tmpl)
+        }
+    }
+}
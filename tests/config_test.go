@@ -0,0 +1,106 @@
+package tests
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+    path := filepath.Join(t.TempDir(), name)
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatalf("WriteFile failed: %v", err)
+    }
+    return path
+}
+
+func TestLoadConfigFile_YAMLWithEnvOverrides(t *testing.T) {
+    path := writeConfigFile(t, "config.yaml", `
+# ml-monitoring
+listen_addr: ":9090"
+http:
+  read_timeout: 15s   # slow clients
+  idle_timeout: "2m"
+db:
+  host: db.internal
+  password: 'it''s secret'
+  max_open_conns: 40
+rollup:
+  enabled: false
+`)
+    t.Setenv("DB_HOST", "override.internal")
+
+    cfg, err := config.LoadConfigFile(path)
+    if err != nil {
+        t.Fatalf("LoadConfigFile returned error: %v", err)
+    }
+    if cfg.ListenAddr != ":9090" || cfg.ReadTimeout != 15*time.Second || cfg.IdleTimeout != 2*time.Minute ||
+        cfg.WriteTimeout != time.Minute || cfg.DBMaxOpenConns != 40 || cfg.RollupEnabled ||
+        cfg.PIIEnabled || cfg.PIIScanNumbers {
+        t.Errorf("Unexpected config: %+v", cfg)
+    }
+    if cfg.DBHost != "override.internal" || cfg.DBPassword != "it's secret" {
+        t.Errorf("Expected env to override the file, got host %q password %q", cfg.DBHost, cfg.DBPassword)
+    }
+
+    var out bytes.Buffer
+    cfg.Print(&out)
+    for _, want := range []string{
+        `db_host: "override.internal" # env`,
+        `db_password: "********" # ` + path + ":9",
+        `shutdown_grace_period: "5s" # default`,
+    } {
+        if !strings.Contains(out.String(), want+"\n") {
+            t.Errorf("Expected %q in printed config:\n%s", want, out.String())
+        }
+    }
+    if strings.Contains(out.String(), "secret") {
+        t.Errorf("Printed config leaks a secret:\n%s", out.String())
+    }
+}
+
+func TestLoadConfigFile_TOML(t *testing.T) {
+    path := writeConfigFile(t, "config.toml", `
+listen_addr = "127.0.0.1:8443"
+
+[http]
+write_timeout = "0s" # disabled
+
+[rate_limit.query]
+rps = 5
+`)
+    cfg, err := config.LoadConfigFile(path)
+    if err != nil {
+        t.Fatalf("LoadConfigFile returned error: %v", err)
+    }
+    if cfg.ListenAddr != "127.0.0.1:8443" || cfg.WriteTimeout != 0 || cfg.RateLimitQueryRPS != 5 {
+        t.Errorf("Unexpected config: %+v", cfg)
+    }
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+    tests := []struct {
+        name, file, content, want string
+    }{
+        {"unknown key", "c.yaml", "http:\n  read_timout: 1s\n", `line 2: unknown setting "http.read_timout" (did you mean "http_read_timeout"?)`},
+        {"invalid value", "c.toml", "[db]\nmax_open_conns = -1\n", "invalid DB_MAX_OPEN_CONNS"},
+        {"value line", "c.yaml", "\nshutdown_grace_period: soon\n", "(set as shutdown_grace_period in"},
+        {"idle above open", "c.yaml", "db:\n  max_open_conns: 2\n  max_idle_conns: 3\n", "must not exceed"},
+        {"listen address", "c.yaml", "listen_addr: 8080\n", "invalid LISTEN_ADDR"},
+        {"duplicate", "c.yaml", "db:\n  host: a\ndb_host: b\n", "line 3: db_host is already set on line 2"},
+        {"tabs", "c.yaml", "db:\n\thost: a\n", "line 2: indent with spaces"},
+        {"list", "c.yaml", "evaluator:\n  blocklist: [a, b]\n", "quote the value"},
+        {"format", "c.json", "{}", "unknown format"},
+    }
+    for _, tt := range tests {
+        _, err := config.LoadConfigFile(writeConfigFile(t, tt.file, tt.content))
+        if err == nil || !strings.Contains(err.Error(), tt.want) {
+            t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+        }
+    }
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/lib/pq"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+)
+
+func TestDSN_QuotesValues(t *testing.T) {
+    cfg := &config.Config{DBHost: "db", DBPort: 5432, DBUser: "app", DBPassword: `p@ss w'rd\`, DBName: "ml", SSLMode: "verify-full"}
+    dsn := db.DSN(cfg)
+    want := `host='db' port=5432 user='app' password='p@ss w\'rd\\' dbname='ml' sslmode='verify-full'`
+    if dsn != want {
+        t.Errorf("DSN = %s, want %s", dsn, want)
+    }
+    if _, err := pq.NewConnector(dsn); err != nil {
+        t.Errorf("libpq rejected the DSN: %v", err)
+    }
+
+    cfg.DBDSN = "postgres://app:s%20cret@db:5432/ml?sslmode=verify-full&sslrootcert=/etc/ssl/ca.pem"
+    if db.DSN(cfg) != cfg.DBDSN {
+        t.Errorf("Expected DB_DSN to be used as given, got %s", db.DSN(cfg))
+    }
+    if _, err := db.Open(cfg); err != nil {
+        t.Errorf("Open returned error: %v", err)
+    }
+    cfg.DBDSN = "host='unterminated"
+    if _, err := db.Open(cfg); err == nil || !strings.Contains(err.Error(), "invalid DB_DSN") {
+        t.Errorf("Expected an invalid DB_DSN error, got %v", err)
+    }
+}
+
+func TestRetry_PingBacksOff(t *testing.T) {
+    database, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer database.Close()
+    refused := errors.New("connection refused")
+    mock.ExpectPing().WillReturnError(refused)
+    mock.ExpectPing().WillReturnError(refused)
+    mock.ExpectPing()
+
+    retry := db.Retry{Timeout: time.Second, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+    if err := retry.Ping(context.Background(), database); err != nil {
+        t.Fatalf("Expected the third ping to succeed, got %v", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+
+    for i := 0; i < 100; i++ {
+        mock.ExpectPing().WillReturnError(refused)
+    }
+    retry = db.Retry{Timeout: 20 * time.Millisecond, Initial: time.Millisecond, Max: 5 * time.Millisecond}
+    start := time.Now()
+    if err := retry.Ping(context.Background(), database); !errors.Is(err, refused) {
+        t.Errorf("Expected the last ping error, got %v", err)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Expected to give up after the timeout, took %s", elapsed)
+    }
+}
+
+func TestPrintConfig_MasksDSNPassword(t *testing.T) {
+    for dsn, want := range map[string]string{
+        "postgres://app:s3cret@db/ml?sslrootcert=/ca.pem":  "postgres://app:xxxxx@db/ml?sslrootcert=/ca.pem",
+        "host=db password='s3 cret' sslrootcert=/ca.pem": "host=db password=******** sslrootcert=/ca.pem",
+    } {
+        t.Setenv("DB_DSN", dsn)
+        cfg, err := config.LoadConfigFile("")
+        if err != nil {
+            t.Fatalf("LoadConfigFile returned error: %v", err)
+        }
+        var out bytes.Buffer
+        cfg.Print(&out)
+        if !strings.Contains(out.String(), "db_dsn: "+strconv.Quote(want)+" # env\n") {
+            t.Errorf("Expected %s in printed config:\n%s", want, out.String())
+        }
+    }
+}
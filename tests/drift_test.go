@@ -0,0 +1,151 @@
+package tests
+
+import (
+    "encoding/json"
+    "fmt"
+    "math/rand"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/drift"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// gaussianVectors draws n vectors of dimension dim around center.
+func gaussianVectors(rng *rand.Rand, n int, center []float64) [][]float64 {
+    out := make([][]float64, n)
+    for i := range out {
+        out[i] = make([]float64, len(center))
+        for j, c := range center {
+            out[i][j] = c + rng.NormFloat64()
+        }
+    }
+    return out
+}
+
+func TestEmbeddingDrift_SameDistribution(t *testing.T) {
+    rng := rand.New(rand.NewSource(7))
+    center := []float64{1, 1, 1, 1, 1, 1, 1, 1}
+    ref := gaussianVectors(rng, 150, center)
+    cur := gaussianVectors(rng, 150, center)
+
+    report, err := drift.Embeddings(ref, cur, drift.DefaultEmbeddingOptions)
+    if err != nil {
+        t.Fatalf("Embeddings returned error: %v", err)
+    }
+    if report.Drifted || report.MMD.PValue < 0.05 {
+        t.Errorf("Expected no drift, got p=%v", report.MMD.PValue)
+    }
+    if report.Classifier.AUC > 0.65 {
+        t.Errorf("Expected classifier near chance, got AUC %v", report.Classifier.AUC)
+    }
+    if report.Centroid.CosineDistance > 0.05 {
+        t.Errorf("Expected small centroid shift, got %v", report.Centroid.CosineDistance)
+    }
+}
+
+func TestEmbeddingDrift_ShiftedDistribution(t *testing.T) {
+    rng := rand.New(rand.NewSource(7))
+    ref := gaussianVectors(rng, 150, []float64{1, 1, 1, 1, 1, 1, 1, 1})
+    cur := gaussianVectors(rng, 150, []float64{1, 1, 1, 1, -1, -1, -1, -1})
+
+    report, err := drift.Embeddings(ref, cur, drift.DefaultEmbeddingOptions)
+    if err != nil {
+        t.Fatalf("Embeddings returned error: %v", err)
+    }
+    if !report.Drifted || report.MMD.PValue > 0.01 {
+        t.Errorf("Expected drift, got p=%v", report.MMD.PValue)
+    }
+    if report.Classifier.AUC < 0.9 || report.Classifier.DriftScore < 0.8 {
+        t.Errorf("Expected separable windows, got %+v", report.Classifier)
+    }
+    // Centroids (1,..,1) and (1,1,1,1,-1,..) are orthogonal
+    if report.Centroid.CosineDistance < 0.8 {
+        t.Errorf("Expected large centroid shift, got %v", report.Centroid.CosineDistance)
+    }
+}
+
+func TestEmbeddingDrift_Validation(t *testing.T) {
+    vecs, skipped := drift.ParseVectors([]string{`[1, 2]`, `[1, "x"]`, `[3]`, `"text"`, `[4, 5]`})
+    if len(vecs) != 2 || skipped != 3 {
+        t.Errorf("Expected 2 vectors and 3 skipped, got %d and %d", len(vecs), skipped)
+    }
+    ref := [][]float64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}}
+    if _, err := drift.Embeddings(ref, [][]float64{{1, 2}}, drift.DefaultEmbeddingOptions); err != drift.ErrTooFewSamples {
+        t.Errorf("Expected ErrTooFewSamples, got %v", err)
+    }
+    cur := [][]float64{{1}, {2}, {3}, {4}, {5}}
+    if _, err := drift.Embeddings(ref, cur, drift.DefaultEmbeddingOptions); err != drift.ErrDimensionMismatch {
+        t.Errorf("Expected ErrDimensionMismatch, got %v", err)
+    }
+}
+
+func TestEmbeddingDriftEndpoint(t *testing.T) {
+    s := setupMockServer()
+    rng := rand.New(rand.NewSource(3))
+    repo := &MockMetricsRepo{}
+    now := time.Now().UTC()
+    for i, v := range gaussianVectors(rng, 60, []float64{2, 2, 2, 2}) {
+        repo.Rows = append(repo.Rows, embeddingRow(v, now.Add(-36*time.Hour+time.Duration(i)*time.Minute)))
+    }
+    for i, v := range gaussianVectors(rng, 60, []float64{-2, -2, 2, 2}) {
+        repo.Rows = append(repo.Rows, embeddingRow(v, now.Add(-12*time.Hour+time.Duration(i)*time.Minute)))
+    }
+    s.MetricsRepo = repo
+
+    from := now.Add(-24 * time.Hour).Format(time.RFC3339)
+    req, _ := http.NewRequest("GET", "/drift/embeddings?model=search&field=query.embedding&permutations=50&from="+from, nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var resp struct {
+        Report drift.EmbeddingReport `json:"report"`
+    }
+    json.Unmarshal(rr.Body.Bytes(), &resp)
+    if resp.Report.ReferenceCount != 60 || resp.Report.CurrentCount != 60 || resp.Report.Dimension != 4 {
+        t.Errorf("Unexpected counts: %+v", resp.Report)
+    }
+    if !resp.Report.Drifted {
+        t.Errorf("Expected drift, got %+v", resp.Report)
+    }
+
+    req, _ = http.NewRequest("GET", "/drift/embeddings?model=search", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 without field, got %d", rr.Code)
+    }
+
+    for _, param := range []string{"max_samples=2001", "permutations=10001", "folds=21", "folds=1", "permutations=-1"} {
+        req, _ = http.NewRequest("GET", "/drift/embeddings?model=search&field=query.embedding&"+param, nil)
+        rr = httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusBadRequest {
+            t.Errorf("Expected 400 for %s, got %d", param, rr.Code)
+        }
+    }
+
+    req, _ = http.NewRequest("GET", "/drift/embeddings?model=other&field=query.embedding", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Errorf("Expected 422 without data, got %d", rr.Code)
+    }
+}
+
+func embeddingRow(v []float64, at time.Time) models.LabeledInference {
+    parts := make([]string, len(v))
+    for i, f := range v {
+        parts[i] = fmt.Sprintf("%g", f)
+    }
+    return models.LabeledInference{Inference: models.Inference{
+        ModelName: "search",
+        InputData: `{"query": {"embedding": [` + strings.Join(parts, ",") + `]}}`,
+        CreatedAt: at,
+    }}
+}
@@ -0,0 +1,275 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+)
+
+func testKey(b byte) []byte {
+    return bytes.Repeat([]byte{b}, envelope.KeySize)
+}
+
+func TestKeyring_SealOpenRewrap(t *testing.T) {
+    old, err := envelope.NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+    if err != nil {
+        t.Fatalf("NewKeyring returned error: %v", err)
+    }
+    env, err := old.Seal("input_data/a", []byte(`{"secret":1}`))
+    if err != nil {
+        t.Fatalf("Seal returned error: %v", err)
+    }
+    if got, err := old.Open("input_data/a", env); err != nil || string(got) != `{"secret":1}` {
+        t.Fatalf("Open = %q, %v", got, err)
+    }
+    if _, err := old.Open("input_data/b", env); err != envelope.ErrDecrypt {
+        t.Errorf("Expected ErrDecrypt for another row, got %v", err)
+    }
+    tampered := *env
+    tampered.Ciphertext = append([]byte{}, env.Ciphertext...)
+    tampered.Ciphertext[len(tampered.Ciphertext)-1] ^= 1
+    if _, err := old.Open("input_data/a", &tampered); err != envelope.ErrDecrypt {
+        t.Errorf("Expected ErrDecrypt for tampered ciphertext, got %v", err)
+    }
+
+    rotated, _ := envelope.NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+    rewrapped, changed, err := rotated.Rewrap(env)
+    if err != nil || !changed || rewrapped.KeyID != "k2" || !bytes.Equal(rewrapped.Ciphertext, env.Ciphertext) {
+        t.Fatalf("Rewrap = %+v, %v, %v", rewrapped, changed, err)
+    }
+    if got, err := rotated.Open("input_data/a", rewrapped); err != nil || string(got) != `{"secret":1}` {
+        t.Errorf("Open after rewrap = %q, %v", got, err)
+    }
+    if _, changed, _ := rotated.Rewrap(rewrapped); changed {
+        t.Errorf("Expected no change when already under the primary key")
+    }
+    if _, err := old.Open("input_data/a", rewrapped); !errors.Is(err, envelope.ErrUnknownKey) {
+        t.Errorf("Expected ErrUnknownKey, got %v", err)
+    }
+
+    if _, err := envelope.NewKeyring("k1", map[string][]byte{"k1": []byte("short")}); err == nil {
+        t.Errorf("Expected an error for a short key")
+    }
+    if _, err := envelope.NewKeyring("k3", map[string][]byte{"k1": testKey(1)}); err == nil {
+        t.Errorf("Expected an error for a missing primary key")
+    }
+}
+
+func TestCodec_SafeFields(t *testing.T) {
+    keys, _ := envelope.NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+    codec := envelope.NewCodec(keys, []string{"input_data.user.country", "input_data.age"})
+    raw := `{"user":{"name":"Jane","country":"DE"},"age":41}`
+
+    stored, err := codec.Encrypt("input_data", "inf-1", raw)
+    if err != nil {
+        t.Fatalf("Encrypt returned error: %v", err)
+    }
+    if strings.Contains(stored, "Jane") {
+        t.Errorf("Stored payload contains plaintext: %s", stored)
+    }
+    var doc map[string]interface{}
+    json.Unmarshal([]byte(stored), &doc)
+    if doc["age"] != float64(41) || doc["user"].(map[string]interface{})["country"] != "DE" || doc[envelope.Field] == nil {
+        t.Errorf("Unexpected stored payload: %s", stored)
+    }
+
+    if got, err := codec.Decrypt("input_data", "inf-1", stored); err != nil || got != raw {
+        t.Errorf("Decrypt = %q, %v", got, err)
+    }
+    if _, err := codec.Decrypt("output_data", "inf-1", stored); err == nil {
+        t.Errorf("Expected decrypting under another column to fail")
+    }
+    if got, _ := codec.Decrypt("input_data", "legacy", raw); got != raw {
+        t.Errorf("Expected plaintext payloads to pass through, got %s", got)
+    }
+    if got := codec.SafeView("input_data", raw); got != `{"age":41,"user":{"country":"DE"}}` {
+        t.Errorf("SafeView = %s", got)
+    }
+    if got := codec.SafeView("output_data", `{"label":1}`); got != `{}` {
+        t.Errorf("SafeView without safe fields = %s", got)
+    }
+}
+
+func TestEncryptedRepositories_AndRewrap(t *testing.T) {
+    s := setupMockServer()
+    rawInf, rawFb := s.InferenceRepo, s.FeedbackRepo
+    keys, _ := envelope.NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+    s.Encryption = envelope.NewCodec(keys, []string{"output_data.prediction"})
+    s.InferenceRepo = repository.NewEncryptedInferenceRepository(rawInf, s.Encryption)
+    s.FeedbackRepo = repository.NewEncryptedFeedbackRepository(rawFb, s.Encryption)
+
+    req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{
+        "model_name": "fraud", "model_version": "v1",
+        "input_data": {"card_holder": "Jane Doe"},
+        "output_data": {"prediction": 1, "explanation": "velocity"}
+    }`))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var created map[string]string
+    json.NewDecoder(rr.Body).Decode(&created)
+    id := created["inference_id"]
+
+    req, _ = http.NewRequest("POST", "/inferences/"+id+"/feedback",
+        bytes.NewBufferString(`{"feedback_data": {"label": 0, "note": "call Jane"}}`))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+
+    stored, _ := rawInf.GetInferenceByID(context.Background(), id)
+    if strings.Contains(stored.InputData, "Jane") || !strings.Contains(stored.OutputData, `"prediction":1`) ||
+        strings.Contains(stored.OutputData, "velocity") {
+        t.Errorf("Unexpected stored payloads: %s %s", stored.InputData, stored.OutputData)
+    }
+    fbs, _ := rawFb.GetFeedbackByInferenceID(context.Background(), id)
+    if len(fbs) != 1 || strings.Contains(fbs[0].FeedbackData, "Jane") {
+        t.Errorf("Unexpected stored feedback: %+v", fbs)
+    }
+
+    req, _ = http.NewRequest("GET", "/inferences/"+id, nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if !strings.Contains(rr.Body.String(), "Jane Doe") || !strings.Contains(rr.Body.String(), "velocity") {
+        t.Errorf("Expected decrypted payloads through the API, got %s", rr.Body.String())
+    }
+
+    // Rotate to k2 and rewrap the stored input plus one legacy plaintext row.
+    rotated, _ := envelope.NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+    s.Encryption = envelope.NewCodec(rotated, nil)
+    input := repository.PayloadColumns[0]
+    envRepo := &MockEnvelopeRepo{Payloads: map[repository.PayloadColumn]map[string]string{
+        input:                        {id: stored.InputData, "legacy": `{"card_holder":"John"}`},
+        repository.PayloadColumns[1]: {},
+        repository.PayloadColumns[2]: {},
+    }}
+    s.EnvelopeRepo = envRepo
+
+    req, _ = http.NewRequest("POST", "/encryption/rewrap?limit=10", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var resp struct {
+        PrimaryKey string `json:"primary_key"`
+        Columns    []struct {
+            Column  string `json:"column"`
+            Updated int    `json:"updated"`
+        } `json:"columns"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if resp.PrimaryKey != "k2" || len(resp.Columns) != 3 || resp.Columns[0].Updated != 2 {
+        t.Errorf("Unexpected rewrap response: %+v", resp)
+    }
+    for rowID, data := range envRepo.Payloads[input] {
+        env, ok, _ := envelope.Extract(data)
+        if !ok || env.KeyID != "k2" {
+            t.Errorf("Row %s not under k2: %s", rowID, data)
+        }
+    }
+    if got, err := s.Encryption.Decrypt("input_data", "legacy", envRepo.Payloads[input]["legacy"]); err != nil || got != `{"card_holder":"John"}` {
+        t.Errorf("Decrypt of encrypted legacy row = %q, %v", got, err)
+    }
+}
+
+func TestRewrap_PagesPastFailedRows(t *testing.T) {
+    s := setupMockServer()
+    keys, _ := envelope.NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+    s.Encryption = envelope.NewCodec(keys, nil)
+    input := repository.PayloadColumns[0]
+    // The first two rows hold corrupt envelopes and can never be rewrapped.
+    envRepo := &MockEnvelopeRepo{Payloads: map[repository.PayloadColumn]map[string]string{
+        input: {
+            "00000000-0000-0000-0000-000000000001": `{"__enc":"corrupt"}`,
+            "00000000-0000-0000-0000-000000000002": `{"__enc":"corrupt"}`,
+            "00000000-0000-0000-0000-000000000003": `{"card_holder":"John"}`,
+        },
+        repository.PayloadColumns[1]: {},
+        repository.PayloadColumns[2]: {},
+    }}
+    s.EnvelopeRepo = envRepo
+
+    type rewrapResp struct {
+        Columns []struct {
+            Updated int `json:"updated"`
+            Failed  int `json:"failed"`
+        } `json:"columns"`
+        Remaining bool   `json:"remaining"`
+        Next      string `json:"next"`
+    }
+    rewrap := func(query string) rewrapResp {
+        t.Helper()
+        req, _ := http.NewRequest("POST", "/encryption/rewrap?"+query, nil)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusOK {
+            t.Fatalf("Expected 200 for %s, got %d: %s", query, rr.Code, rr.Body.String())
+        }
+        var resp rewrapResp
+        json.NewDecoder(rr.Body).Decode(&resp)
+        return resp
+    }
+
+    first := rewrap("limit=2")
+    if first.Columns[0].Failed != 2 || first.Columns[0].Updated != 0 || !first.Remaining ||
+        first.Next != "inferences.input_data:00000000-0000-0000-0000-000000000002" {
+        t.Fatalf("Unexpected first batch: %+v", first)
+    }
+    second := rewrap("limit=2&after=" + first.Next)
+    if second.Columns[0].Failed != 0 || second.Columns[0].Updated != 1 || second.Remaining || second.Next != "" {
+        t.Errorf("Unexpected second batch: %+v", second)
+    }
+
+    // Only the failed rows are left, and a batch ending on the last of them
+    // reports nothing remaining.
+    again := rewrap("limit=2")
+    if again.Columns[0].Failed != 2 || again.Remaining {
+        t.Errorf("Unexpected retry batch: %+v", again)
+    }
+
+    for _, bad := range []string{"after=nope", "after=inferences.input_data:x", "after=audit_log.detail:00000000-0000-0000-0000-000000000001"} {
+        req, _ := http.NewRequest("POST", "/encryption/rewrap?"+bad, nil)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusBadRequest {
+            t.Errorf("Expected 400 for %s, got %d", bad, rr.Code)
+        }
+    }
+}
+
+func TestEnvelopeRepo_ListStale(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewEnvelopeRepository(db)
+
+    mock.ExpectQuery(regexp.QuoteMeta(`FROM feedback`) + `(?s).*` +
+        regexp.QuoteMeta(`(NOT feedback_data ? '__enc' OR feedback_data->'__enc'->>'kid' <> $1)`) + `(?s).*` +
+        regexp.QuoteMeta(`AND ($2::uuid IS NULL OR id > $2::uuid)`) + `(?s).*` + regexp.QuoteMeta(`ORDER BY id`)).
+        WithArgs("k2", "fb-0", 50).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "feedback_data"}).AddRow("fb-1", `{"label": 1}`))
+
+    got, err := repo.ListStale(context.Background(), repository.PayloadColumns[2], "k2", "fb-0", 50)
+    if err != nil {
+        t.Fatalf("ListStale returned error: %v", err)
+    }
+    if len(got) != 1 || got[0].ID != "fb-1" {
+        t.Errorf("Unexpected payloads: %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
+)
+
+func setupErasureServer() (*server.Server, *MockErasureRepo, map[string]string) {
+    s := setupMockServer()
+    llm := &MockLLMRepo{}
+    s.LLMRepo = llm
+    repo := &MockErasureRepo{
+        Inferences: s.InferenceRepo.(*MockInferenceRepo),
+        Feedback:   s.FeedbackRepo.(*MockFeedbackRepo),
+        LLMCalls:   llm,
+    }
+    s.ErasureRepo = repo
+
+    subjects := map[string]string{}
+    for i, subject := range []string{"alice", "alice", "alice", "bob"} {
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{
+            "model_name": "churn", "model_version": "v1", "subject_id": "`+subject+`",
+            "input_data": {"name": "`+subject+`"}, "output_data": {"prediction": 1}, "tags": {"plan": "pro"}
+        }`))
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        var created map[string]string
+        json.NewDecoder(rr.Body).Decode(&created)
+        subjects[created["inference_id"]] = subject
+        if i < 2 {
+            req, _ = http.NewRequest("POST", "/inferences/"+created["inference_id"]+"/feedback",
+                bytes.NewBufferString(`{"feedback_data": {"label": 1}}`))
+            s.Router.ServeHTTP(httptest.NewRecorder(), req)
+        }
+    }
+    for _, subject := range []string{"alice", "bob"} {
+        req, _ := http.NewRequest("POST", "/llm/calls", bytes.NewBufferString(`{
+            "model": "gpt-4o", "prompt": "Hi, I am `+subject+`", "completion": "Hello `+subject+`",
+            "subject_id": "`+subject+`"
+        }`))
+        s.Router.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    return s, repo, subjects
+}
+
+func TestEraseSubject_DeletesInBatches(t *testing.T) {
+    s, repo, subjects := setupErasureServer()
+
+    req, _ := http.NewRequest("DELETE", "/subjects/alice?batch_size=2", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusAccepted {
+        t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var job models.ErasureJob
+    json.NewDecoder(rr.Body).Decode(&job)
+    if job.ID == "" || job.Status != models.ErasureRunning {
+        t.Fatalf("Expected a running job, got %+v", job)
+    }
+    if strings.Contains(rr.Body.String(), "alice") {
+        t.Errorf("Job report contains the subject ID: %s", rr.Body.String())
+    }
+    s.WaitErasures()
+
+    req, _ = http.NewRequest("GET", "/erasure-jobs/"+job.ID, nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 for the job lookup, got %d: %s", rr.Code, rr.Body.String())
+    }
+    json.NewDecoder(rr.Body).Decode(&job)
+    if job.Status != models.ErasureCompleted || job.Mode != models.ErasureModeDelete || job.Batches != 2 ||
+        job.Inferences != 3 || job.Feedback != 2 || job.LLMCalls != 1 || job.FinishedAt == nil {
+        t.Errorf("Unexpected job report: %+v", job)
+    }
+
+    for id, subject := range subjects {
+        _, err := s.InferenceRepo.GetInferenceByID(context.Background(), id)
+        if (subject == "alice") != (err != nil) {
+            t.Errorf("Inference %s of %s: unexpected lookup error %v", id, subject, err)
+        }
+        if fbs, _ := s.FeedbackRepo.GetFeedbackByInferenceID(context.Background(), id); subject == "alice" && len(fbs) > 0 {
+            t.Errorf("Feedback of erased inference %s was kept", id)
+        }
+    }
+    if calls := repo.LLMCalls.Calls; len(calls) != 1 || calls[0].SubjectID != "bob" {
+        t.Errorf("Unexpected LLM calls after erasure: %+v", calls)
+    }
+    // Created, one update per batch, then completed.
+    if len(repo.Updates) != 4 || repo.Updates[0].Status != models.ErasureRunning {
+        t.Errorf("Unexpected job updates: %+v", repo.Updates)
+    }
+}
+
+func TestEraseSubject_Anonymize(t *testing.T) {
+    s, repo, subjects := setupErasureServer()
+
+    req, _ := http.NewRequest("DELETE", "/subjects/alice?mode=anonymize", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusAccepted {
+        t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var job models.ErasureJob
+    json.NewDecoder(rr.Body).Decode(&job)
+    s.WaitErasures()
+    job = repo.Jobs[job.ID]
+    if job.Status != models.ErasureCompleted || job.Inferences != 3 || job.Feedback != 2 || job.LLMCalls != 1 {
+        t.Fatalf("Unexpected anonymize report: %+v", job)
+    }
+    for id, subject := range subjects {
+        inf, err := s.InferenceRepo.GetInferenceByID(context.Background(), id)
+        if err != nil {
+            t.Fatalf("Anonymized inference %s was deleted", id)
+        }
+        if subject == "alice" && (inf.InputData != "{}" || inf.OutputData != "{}" || inf.Tags != "{}" || inf.SubjectID != "") {
+            t.Errorf("Unexpected anonymized inference: %+v", inf)
+        }
+        fbs, _ := s.FeedbackRepo.GetFeedbackByInferenceID(context.Background(), id)
+        for _, fb := range fbs {
+            if subject == "alice" && fb.FeedbackData != "{}" {
+                t.Errorf("Feedback of anonymized inference %s was kept: %+v", id, fb)
+            }
+        }
+        if subject == "bob" && inf.SubjectID != "bob" {
+            t.Errorf("Inference of another subject changed: %+v", inf)
+        }
+    }
+
+    for _, c := range repo.LLMCalls.Calls {
+        if c.SubjectID == "alice" || strings.Contains(c.Prompt+c.Completion, "alice") {
+            t.Errorf("LLM call of alice was not anonymized: %+v", c)
+        }
+    }
+    if len(repo.LLMCalls.Calls) != 2 {
+        t.Errorf("Anonymize deleted LLM calls: %+v", repo.LLMCalls.Calls)
+    }
+
+    req, _ = http.NewRequest("DELETE", "/subjects/alice?mode=shred", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for an unknown mode, got %d", rr.Code)
+    }
+}
+
+func TestErasureRepo_DeleteBatch(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewErasureRepository(db)
+
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM inferences WHERE subject_id = $1 LIMIT $2`) + `(?s).*` +
+        regexp.QuoteMeta(`DELETE FROM feedback`) + `.*` + regexp.QuoteMeta(`DELETE FROM inferences`) + `.*` +
+        regexp.QuoteMeta(`DELETE FROM llm_calls`)).
+        WithArgs("alice", 100).
+        WillReturnRows(sqlmock.NewRows([]string{"inferences", "feedback", "llm_calls"}).AddRow(3, 2, 1))
+
+    batch, err := repo.EraseBatch(context.Background(), "alice", models.ErasureModeDelete, 100)
+    if err != nil || batch != (models.ErasureBatch{Inferences: 3, Feedback: 2, LLMCalls: 1}) {
+        t.Errorf("EraseBatch = %+v, %v", batch, err)
+    }
+    if _, err := repo.EraseBatch(context.Background(), "alice", "shred", 100); err == nil {
+        t.Errorf("Expected an error for an unknown mode")
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
+
+func TestErasureRepo_AnonymizeBatch(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewErasureRepository(db)
+
+    // Every column that can hold subject data is cleared, including the
+    // details derived from payloads.
+    mock.ExpectQuery(regexp.QuoteMeta(`UPDATE feedback SET feedback_data = '{}'::jsonb`) + `(?s).*` +
+        regexp.QuoteMeta(`UPDATE data_quality_violations SET detail = ''`) + `.*` +
+        regexp.QuoteMeta(`UPDATE evaluation_scores SET detail = NULL`) + `.*` +
+        regexp.QuoteMeta(`output_data = '{}'::jsonb`) + `.*` +
+        regexp.QuoteMeta(`SET prompt = '', completion = ''`)).
+        WithArgs("alice", 50).
+        WillReturnRows(sqlmock.NewRows([]string{"inferences", "feedback", "llm_calls"}).AddRow(2, 1, 4))
+
+    batch, err := repo.EraseBatch(context.Background(), "alice", models.ErasureModeAnonymize, 50)
+    if err != nil || batch != (models.ErasureBatch{Inferences: 2, Feedback: 1, LLMCalls: 4}) {
+        t.Errorf("EraseBatch = %+v, %v", batch, err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
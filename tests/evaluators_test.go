@@ -0,0 +1,263 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/evaluators"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+)
+
+func scoreMap(scores []models.EvaluationScore) map[string]models.EvaluationScore {
+    out := map[string]models.EvaluationScore{}
+    for _, s := range scores {
+        out[s.Evaluator] = s
+    }
+    return out
+}
+
+func TestBuiltinEvaluators(t *testing.T) {
+    evals, err := evaluators.Builtin(evaluators.Options{Blocklist: []string{`\bpassword\b`, `ssn`}})
+    if err != nil {
+        t.Fatalf("Builtin returned error: %v", err)
+    }
+    runner := evaluators.NewRunner(nil, nil, evals, evaluators.DefaultOptions, 1, 1)
+    eval := func(text string) map[string]models.EvaluationScore {
+        return scoreMap(runner.Evaluate(evaluators.Input{Text: text}, false))
+    }
+
+    got := eval("```json\n{\"answer\": 42}\n```")
+    if got["json_valid"].Value != 1 {
+        t.Errorf("Expected fenced JSON to be valid, got %+v", got["json_valid"])
+    }
+    if got["length_chars"].Value != 26 || got["length_words"].Value != 4 {
+        t.Errorf("Unexpected length scores: %+v %+v", got["length_chars"], got["length_words"])
+    }
+    if _, ok := got["bleu"]; ok {
+        t.Errorf("Reference evaluators should not run without feedback")
+    }
+
+    got = eval("I'm sorry, but I can't help with sharing the admin PASSWORD.")
+    if got["json_valid"].Value != 0 || got["json_valid"].Detail == "" {
+        t.Errorf("Expected invalid JSON with a detail, got %+v", got["json_valid"])
+    }
+    if got["refusal"].Value != 1 {
+        t.Errorf("Expected a refusal, got %+v", got["refusal"])
+    }
+    if got["blocklist"].Value != 1 || got["blocklist"].Detail != "PASSWORD" {
+        t.Errorf("Expected one blocklist hit, got %+v", got["blocklist"])
+    }
+
+    got = eval("Paris is the capital of France.")
+    if got["refusal"].Value != 0 || got["blocklist"].Value != 0 {
+        t.Errorf("Unexpected refusal/blocklist scores: %+v %+v", got["refusal"], got["blocklist"])
+    }
+
+    if _, err := evaluators.Builtin(evaluators.Options{Blocklist: []string{"("}}); err == nil {
+        t.Errorf("Expected an error for an invalid blocklist pattern")
+    }
+}
+
+func TestDetectLanguage(t *testing.T) {
+    cases := map[string]string{
+        "The model is trained on the data that you have provided for this task.": "en",
+        "Das ist nicht die Antwort, die ich mit dem Modell erwartet habe.":        "de",
+        "Je pense que la réponse est dans les données pour le modèle.":             "fr",
+        "Привет, как дела? Это ответ модели.":                                      "ru",
+        "これは日本語の文章です。":                                                         "ja",
+        "这是一个中文句子。":                                                              "zh",
+        "42 + 17":  "und",
+        "Yes.":     "und",
+    }
+    for text, want := range cases {
+        if got, conf := evaluators.DetectLanguage(text); got != want || (want != "und" && conf <= 0.5) {
+            t.Errorf("DetectLanguage(%q) = %s (%.2f), want %s", text, got, conf, want)
+        }
+    }
+}
+
+func TestReferenceOverlap(t *testing.T) {
+    ref := evaluators.Tokenize("The cat sat on the mat.")
+    if got := evaluators.BLEU(ref, ref); math.Abs(got-1) > 1e-9 {
+        t.Errorf("BLEU of identical texts = %v, want 1", got)
+    }
+    if got := evaluators.RougeL(ref, ref); got != 1 {
+        t.Errorf("ROUGE-L of identical texts = %v, want 1", got)
+    }
+    other := evaluators.Tokenize("Dogs bark loudly")
+    if evaluators.BLEU(other, ref) != 0 || evaluators.Rouge1(other, ref) != 0 {
+        t.Errorf("Expected zero overlap for disjoint texts")
+    }
+
+    // LCS of "the cat on the mat" and the reference is 5 tokens:
+    // P = 5/5, R = 5/6, F1 = 10/11.
+    cand := evaluators.Tokenize("the cat on the mat")
+    if got := evaluators.RougeL(cand, ref); math.Abs(got-10.0/11) > 1e-9 {
+        t.Errorf("ROUGE-L = %v, want %v", got, 10.0/11)
+    }
+    if got := evaluators.BLEU(cand, ref); got <= 0 || got >= 1 {
+        t.Errorf("BLEU = %v, want in (0, 1)", got)
+    }
+}
+
+func TestEvaluationRunner_Endpoints(t *testing.T) {
+    s := setupMockServer()
+    repo := &MockEvaluationRepo{}
+    evals, _ := evaluators.Builtin(evaluators.DefaultOptions)
+    runner := evaluators.NewRunner(repo, s.InferenceRepo, evals, evaluators.DefaultOptions, 2, 10)
+    s.EvaluationRepo = repo
+    s.Evaluations = runner
+    s.Observers = append(s.Observers, runner)
+    s.FeedbackObservers = append(s.FeedbackObservers, runner)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go runner.Run(ctx)
+
+    req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{
+        "model_name": "chat", "model_version": "v1",
+        "input_data": {"prompt": "Where does the cat sit?"},
+        "output_data": {"completion": "The cat sat on the mat."}
+    }`))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+    var created map[string]string
+    json.NewDecoder(rr.Body).Decode(&created)
+    id := created["inference_id"]
+    runner.Wait()
+
+    req, _ = http.NewRequest("POST", "/inferences/"+id+"/feedback",
+        bytes.NewBufferString(`{"feedback_data": {"reference": "The cat sat on the mat."}}`))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+    runner.Wait()
+
+    req, _ = http.NewRequest("GET", "/inferences/"+id+"/evaluations", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var scores []models.EvaluationScore
+    json.NewDecoder(rr.Body).Decode(&scores)
+    got := scoreMap(scores)
+    if got["language"].Label != "en" || got["refusal"].Value != 0 || got["json_valid"].Value != 0 {
+        t.Errorf("Unexpected inference scores: %+v", scores)
+    }
+    if math.Abs(got["bleu"].Value-1) > 1e-9 || got["rouge_l"].Value != 1 {
+        t.Errorf("Expected perfect reference overlap, got %+v %+v", got["bleu"], got["rouge_l"])
+    }
+
+    req, _ = http.NewRequest("GET", "/evaluations?model=chat&evaluator=language&bucket=hour", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var resp struct {
+        Buckets []models.EvaluationAggregate `json:"buckets"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if len(resp.Buckets) != 1 || resp.Buckets[0].Count != 1 || resp.Buckets[0].Labels["en"] != 1 {
+        t.Errorf("Unexpected aggregates: %+v", resp.Buckets)
+    }
+
+    req, _ = http.NewRequest("GET", "/evaluations?model=chat&bucket=month", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for an unknown bucket, got %d", rr.Code)
+    }
+}
+
+func TestEvaluationRunner_QueueFull(t *testing.T) {
+    evals, _ := evaluators.Builtin(evaluators.DefaultOptions)
+    runner := evaluators.NewRunner(&MockEvaluationRepo{}, nil, evals, evaluators.DefaultOptions, 1, 1)
+    inf := models.Inference{ID: "a", ModelName: "chat", OutputData: `"hi"`}
+    if err := runner.ObserveInference(context.Background(), inf); err != nil {
+        t.Fatalf("First enqueue failed: %v", err)
+    }
+    if err := runner.ObserveInference(context.Background(), inf); err != evaluators.ErrQueueFull {
+        t.Errorf("Expected ErrQueueFull, got %v", err)
+    }
+}
+
+func TestEvaluationRunner_DrainWaitsForQueuedJobs(t *testing.T) {
+    evals, _ := evaluators.Builtin(evaluators.DefaultOptions)
+    repo := &MockEvaluationRepo{}
+    runner := evaluators.NewRunner(repo, nil, evals, evaluators.DefaultOptions, 1, 10)
+    for _, id := range []string{"a", "b", "c"} {
+        inf := models.Inference{ID: id, ModelName: "chat", OutputData: `"hi"`}
+        if err := runner.ObserveInference(context.Background(), inf); err != nil {
+            t.Fatalf("Enqueue failed: %v", err)
+        }
+    }
+
+    // Nothing processes the queue yet, so Drain gives up at the deadline.
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if err := runner.Drain(ctx); err != context.DeadlineExceeded {
+        t.Errorf("Expected DeadlineExceeded, got %v", err)
+    }
+
+    runCtx, stop := context.WithCancel(context.Background())
+    defer stop()
+    go runner.Run(runCtx)
+    ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if err := runner.Drain(ctx); err != nil {
+        t.Fatalf("Drain returned error: %v", err)
+    }
+    seen := map[string]bool{}
+    repo.mu.Lock()
+    for _, sc := range repo.Scores {
+        seen[sc.InferenceID] = true
+    }
+    repo.mu.Unlock()
+    if len(seen) != 3 {
+        t.Errorf("Expected scores for 3 inferences after Drain, got %v", seen)
+    }
+}
+
+func TestEvaluationRepo_AggregateMergesLabels(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewEvaluationRepository(db)
+
+    from := time.Now().Add(-24 * time.Hour)
+    to := time.Now()
+    day := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+    mock.ExpectQuery(regexp.QuoteMeta(`date_trunc($6, created_at AT TIME ZONE 'UTC')`)).
+        WithArgs("chat", "", "language", from, to, "day").
+        WillReturnRows(sqlmock.NewRows([]string{"evaluator", "bucket", "label", "count", "avg", "min", "max"}).
+            AddRow("language", day, "de", 1, 0.6, 0.6, 0.6).
+            AddRow("language", day, "en", 3, 0.8, 0.7, 0.9))
+
+    got, err := repo.Aggregate(context.Background(),
+        models.WindowQuery{ModelName: "chat", From: from, To: to}, "language", "day")
+    if err != nil {
+        t.Fatalf("Aggregate returned error: %v", err)
+    }
+    if len(got) != 1 || got[0].Count != 4 || math.Abs(got[0].Mean-0.75) > 1e-9 ||
+        got[0].Min != 0.6 || got[0].Max != 0.9 || got[0].Labels["en"] != 3 || got[0].Labels["de"] != 1 {
+        t.Errorf("Unexpected aggregate: %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
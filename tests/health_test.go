@@ -0,0 +1,127 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/ratelimit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
+)
+
+type readyResponse struct {
+    Status string `json:"status"`
+    Checks map[string]struct {
+        Status    string  `json:"status"`
+        LatencyMs float64 `json:"latency_ms"`
+        Error     string  `json:"error"`
+    } `json:"checks"`
+}
+
+func TestProbes_LivezAndReadyz(t *testing.T) {
+    s := setupMockServer()
+    s.Auth = auth.Authenticators{}
+    s.QueryLimiter = ratelimit.New(0.001, 1)
+    s.ReadyTimeout = 50 * time.Millisecond
+    s.ReadyChecks = []server.HealthCheck{
+        {Name: "database", Check: func(context.Context) error { return nil }},
+        {Name: "migrations", Check: func(context.Context) error { return errors.New("at version 12, want 13") }},
+        {Name: "stuck", Check: func(context.Context) error { time.Sleep(time.Second); return nil }},
+    }
+
+    // Probes are open and never rate limited.
+    for i := 0; i < 3; i++ {
+        req, _ := http.NewRequest("GET", "/livez", nil)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusOK {
+            t.Fatalf("Expected 200 from /livez, got %d", rr.Code)
+        }
+    }
+
+    req, _ := http.NewRequest("GET", "/readyz", nil)
+    rr := httptest.NewRecorder()
+    start := time.Now()
+    s.Router.ServeHTTP(rr, req)
+    if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+        t.Errorf("Expected /readyz to stop at its timeout, took %s", elapsed)
+    }
+    if rr.Code != http.StatusServiceUnavailable {
+        t.Fatalf("Expected 503, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var resp readyResponse
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if resp.Status != "unavailable" || resp.Checks["database"].Status != "ok" ||
+        resp.Checks["migrations"].Error != "at version 12, want 13" || resp.Checks["stuck"].Error != "timed out" {
+        t.Errorf("Unexpected readiness: %+v", resp)
+    }
+
+    s.ReadyChecks = s.ReadyChecks[:1]
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Errorf("Expected 200 when every check passes, got %d: %s", rr.Code, rr.Body.String())
+    }
+}
+
+func TestDatabaseChecks(t *testing.T) {
+    database, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer database.Close()
+    latest, err := db.LatestMigration()
+    if err != nil || latest < 20250408013 {
+        t.Fatalf("LatestMigration = %d, %v", latest, err)
+    }
+    checks := map[string]server.HealthCheck{}
+    for _, c := range server.DatabaseChecks(database, latest) {
+        checks[c.Name] = c
+    }
+    versionQuery := regexp.QuoteMeta(`SELECT version, dirty FROM schema_migrations`)
+    ctx := context.Background()
+
+    mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+    if err := checks["database"].Check(ctx); err == nil {
+        t.Errorf("Expected the database check to fail")
+    }
+    mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+    if err := checks["migrations"].Check(ctx); err != nil {
+        t.Errorf("Expected current migrations to pass, got %v", err)
+    }
+    mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest-1, false))
+    if err := checks["migrations"].Check(ctx); err == nil {
+        t.Errorf("Expected an old migration version to fail")
+    }
+    mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest+1, false))
+    if err := checks["migrations"].Check(ctx); err != nil {
+        t.Errorf("Expected a newer migration version to pass, got %v", err)
+    }
+    mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, true))
+    if err := checks["migrations"].Check(ctx); err == nil {
+        t.Errorf("Expected a dirty migration to fail")
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+
+    database.SetMaxOpenConns(1)
+    if err := checks["connection_pool"].Check(ctx); err != nil {
+        t.Errorf("Expected a free pool to pass, got %v", err)
+    }
+    mock.ExpectBegin()
+    tx, _ := database.Begin()
+    if err := checks["connection_pool"].Check(ctx); err == nil {
+        t.Errorf("Expected an exhausted pool to fail")
+    }
+    mock.ExpectRollback()
+    tx.Rollback()
+}
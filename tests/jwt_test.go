@@ -0,0 +1,241 @@
+package tests
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+)
+
+var b64 = base64.RawURLEncoding
+
+// signJWT builds a compact JWT signed with an RSA (RS256) or P-256 (ES256)
+// private key.
+func signJWT(t *testing.T, kid string, key crypto.Signer, claims map[string]interface{}) string {
+    alg := "RS256"
+    if _, ok := key.(*ecdsa.PrivateKey); ok {
+        alg = "ES256"
+    }
+    header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+    payload, _ := json.Marshal(claims)
+    input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+    digest := sha256.Sum256([]byte(input))
+
+    var sig []byte
+    switch k := key.(type) {
+    case *rsa.PrivateKey:
+        var err error
+        if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+            t.Fatalf("Signing failed: %v", err)
+        }
+    case *ecdsa.PrivateKey:
+        r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+        if err != nil {
+            t.Fatalf("Signing failed: %v", err)
+        }
+        sig = make([]byte, 64)
+        r.FillBytes(sig[:32])
+        s.FillBytes(sig[32:])
+    }
+    return input + "." + b64.EncodeToString(sig)
+}
+
+func jwksJSON(rsaKey *rsa.PublicKey, ecKey *ecdsa.PublicKey) string {
+    return fmt.Sprintf(`{"keys": [
+        {"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": %q, "e": %q},
+        {"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": %q, "y": %q},
+        {"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}
+    ]}`, b64.EncodeToString(rsaKey.N.Bytes()), b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+        b64.EncodeToString(ecKey.X.Bytes()), b64.EncodeToString(ecKey.Y.Bytes()))
+}
+
+func TestJWTAuthenticator_ValidatesTokens(t *testing.T) {
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("GenerateKey failed: %v", err)
+    }
+    ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    path := filepath.Join(t.TempDir(), "jwks.json")
+    os.WriteFile(path, []byte(jwksJSON(&rsaKey.PublicKey, &ecKey.PublicKey)), 0o600)
+
+    keys, err := auth.NewKeySet(path, time.Hour)
+    if err != nil {
+        t.Fatalf("NewKeySet returned error: %v", err)
+    }
+    a := auth.NewJWTAuthenticator(keys, auth.JWTConfig{
+        Issuer:     "https://sso.example.com",
+        Audience:   "ml-monitoring",
+        RolesClaim: "realm_access.roles",
+        RoleMap:    map[string]auth.Role{"ml-readers": auth.RoleViewer, "ml-admins": auth.RoleAdmin},
+    })
+    now := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    a.Now = func() time.Time { return now }
+
+    claims := func(overrides map[string]interface{}) map[string]interface{} {
+        c := map[string]interface{}{
+            "iss": "https://sso.example.com", "aud": []string{"other", "ml-monitoring"},
+            "sub": "jane", "exp": now.Add(time.Hour).Unix(),
+            "realm_access": map[string]interface{}{"roles": []string{"ml-readers", "ml-admins"}},
+        }
+        for k, v := range overrides {
+            if v == nil {
+                delete(c, k)
+                continue
+            }
+            c[k] = v
+        }
+        return c
+    }
+
+    id, err := a.Verify(signJWT(t, "rsa-1", rsaKey, claims(nil)))
+    if err != nil || id.Name != "jane" || id.Role != auth.RoleAdmin {
+        t.Fatalf("Verify RS256 = %+v, %v", id, err)
+    }
+    id, err = a.Verify(signJWT(t, "ec-1", ecKey, claims(map[string]interface{}{
+        "realm_access": map[string]interface{}{"roles": "ml-readers"}})))
+    if err != nil || id.Role != auth.RoleViewer {
+        t.Fatalf("Verify ES256 = %+v, %v", id, err)
+    }
+
+    otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    rejected := map[string]string{
+        "expired":        signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})),
+        "no exp":         signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"exp": nil})),
+        "not yet valid":  signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+        "wrong issuer":   signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+        "wrong audience": signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"aud": "other"})),
+        "no role":        signJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"realm_access": nil})),
+        "unknown kid":    signJWT(t, "rsa-2", rsaKey, claims(nil)),
+        "wrong key":      signJWT(t, "ec-1", otherKey, claims(nil)),
+        "alg mismatch":   signJWT(t, "rsa-1", ecKey, claims(nil)),
+        "alg none": b64.EncodeToString([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." +
+            b64.EncodeToString([]byte(`{"sub":"jane"}`)) + ".",
+    }
+    for name, token := range rejected {
+        if id, err := a.Verify(token); err == nil {
+            t.Errorf("%s: expected an error, got %+v", name, id)
+        }
+    }
+
+    // Tampering with the claims invalidates the signature.
+    parts := strings.Split(signJWT(t, "rsa-1", rsaKey, claims(nil)), ".")
+    forged, _ := json.Marshal(claims(map[string]interface{}{"sub": "mallory"}))
+    if _, err := a.Verify(parts[0] + "." + b64.EncodeToString(forged) + "." + parts[2]); err == nil {
+        t.Errorf("Expected an error for forged claims")
+    }
+}
+
+func TestJWT_BearerAuthOnServer(t *testing.T) {
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("GenerateKey failed: %v", err)
+    }
+    ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(jwksJSON(&rsaKey.PublicKey, &ecKey.PublicKey)))
+    }))
+    defer jwks.Close()
+
+    keys, err := auth.NewKeySet(jwks.URL, time.Hour)
+    if err != nil {
+        t.Fatalf("NewKeySet returned error: %v", err)
+    }
+    apiKeys := auth.NewAPIKeys()
+    apiKeys.Add("writer-key", auth.Identity{Name: "ingest", Role: auth.RoleWriter})
+    s := setupMockServer()
+    s.Auth = auth.Authenticators{apiKeys, auth.NewJWTAuthenticator(keys, auth.JWTConfig{
+        Issuer: "https://sso.example.com", Audience: "ml-monitoring",
+    })}
+
+    token := signJWT(t, "ec-1", ecKey, map[string]interface{}{
+        "iss": "https://sso.example.com", "aud": "ml-monitoring", "sub": "intern",
+        "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{"viewer"},
+    })
+    call := func(path, header, value string) int {
+        req, _ := http.NewRequest("GET", path, nil)
+        req.Header.Set(header, value)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        return rr.Code
+    }
+    if code := call("/inferences/missing", "Authorization", "Bearer "+token); code != http.StatusNotFound {
+        t.Errorf("Expected a viewer token to reach the handler, got %d", code)
+    }
+    if code := call("/audit", "Authorization", "Bearer "+token); code != http.StatusForbidden {
+        t.Errorf("Expected 403 for a viewer reading the audit log, got %d", code)
+    }
+    if code := call("/audit", "Authorization", "Bearer "+token+"x"); code != http.StatusUnauthorized {
+        t.Errorf("Expected 401 for a bad token, got %d", code)
+    }
+    if code := call("/audit", auth.APIKeyHeader, "writer-key"); code != http.StatusForbidden {
+        t.Errorf("Expected API keys to keep working next to JWTs, got %d", code)
+    }
+}
+
+func TestKeySet_RefreshDoesNotBlockKnownKeys(t *testing.T) {
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("GenerateKey failed: %v", err)
+    }
+    ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    entered, release := make(chan struct{}), make(chan struct{})
+    var fetches int32
+    jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&fetches, 1) > 1 {
+            close(entered)
+            <-release
+        }
+        w.Write([]byte(jwksJSON(&rsaKey.PublicKey, &ecKey.PublicKey)))
+    }))
+    defer jwks.Close()
+
+    keys, err := auth.NewKeySet(jwks.URL, time.Millisecond)
+    if err != nil {
+        t.Fatalf("NewKeySet returned error: %v", err)
+    }
+    time.Sleep(5 * time.Millisecond)
+    refreshed := make(chan error)
+    go func() {
+        _, err := keys.Key("rsa-1")
+        refreshed <- err
+    }()
+    <-entered
+
+    // The refresh is stuck on the endpoint; a known key is still served.
+    got := make(chan error)
+    go func() {
+        _, err := keys.Key("ec-1")
+        got <- err
+    }()
+    select {
+    case err := <-got:
+        if err != nil {
+            t.Errorf("Expected the known key, got %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("Key blocked on a refresh in flight")
+    }
+    close(release)
+    if err := <-refreshed; err != nil {
+        t.Errorf("Expected the refresh to succeed, got %v", err)
+    }
+    if n := atomic.LoadInt32(&fetches); n != 2 {
+        t.Errorf("Expected one refetch, got %d fetches", n)
+    }
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+)
+
+func TestLLMPrice_Lookup(t *testing.T) {
+    cfg := &config.Config{LLMPrices: map[string]config.LLMPrice{
+        "gpt-4o":       {PromptPerMillion: 2.5, CompletionPerMillion: 10},
+        "gpt-4o*":      {PromptPerMillion: 5, CompletionPerMillion: 15},
+        "gpt-4o-mini*": {PromptPerMillion: 0.15, CompletionPerMillion: 0.6},
+    }}
+    cases := map[string]float64{
+        "gpt-4o":            2.5,
+        "gpt-4o-2024-08-06": 5,
+        "gpt-4o-mini-2024":  0.15,
+    }
+    for model, want := range cases {
+        p, ok := cfg.LLMPrice(model)
+        if !ok || p.PromptPerMillion != want {
+            t.Errorf("LLMPrice(%s) = %+v, %v; want prompt price %v", model, p, ok, want)
+        }
+    }
+    if _, ok := cfg.LLMPrice("claude"); ok {
+        t.Errorf("Expected no price for unknown model")
+    }
+    p, _ := cfg.LLMPrice("gpt-4o")
+    if got := p.Cost(1000, 500); math.Abs(got-0.0075) > 1e-12 {
+        t.Errorf("Cost = %v, want 0.0075", got)
+    }
+}
+
+func TestLLMCallEndpoints(t *testing.T) {
+    s := setupMockServer()
+    repo := &MockLLMRepo{}
+    s.LLMRepo = repo
+    s.Config = &config.Config{LLMPrices: map[string]config.LLMPrice{
+        "gpt-4o": {PromptPerMillion: 2.5, CompletionPerMillion: 10},
+    }}
+
+    post := func(body string) *httptest.ResponseRecorder {
+        req, _ := http.NewRequest("POST", "/llm/calls", bytes.NewBufferString(body))
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        return rr
+    }
+
+    rr := post(`{"model":"gpt-4o","prompt":"hi","completion":"hello","prompt_tokens":1000,"completion_tokens":500,"cost_usd":9,"finish_reason":"stop"}`)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var created struct {
+        CallID  string   `json:"call_id"`
+        CostUSD *float64 `json:"cost_usd"`
+    }
+    json.Unmarshal(rr.Body.Bytes(), &created)
+    if created.CostUSD == nil || math.Abs(*created.CostUSD-0.0075) > 1e-12 {
+        t.Errorf("Expected price table cost 0.0075 to win, got %v", created.CostUSD)
+    }
+
+    // Unpriced model keeps the client-supplied cost, or none
+    post(`{"model":"local-llama","prompt":"a","completion":"b","prompt_tokens":10,"completion_tokens":5,"cost_usd":0.5}`)
+    post(`{"model":"local-llama","prompt":"a","completion":"b","prompt_tokens":10,"completion_tokens":5}`)
+
+    if rr := post(`{"prompt":"a"}`); rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 without model, got %d", rr.Code)
+    }
+    if rr := post(`{"model":"x","prompt_tokens":-1}`); rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for negative tokens, got %d", rr.Code)
+    }
+
+    req, _ := http.NewRequest("GET", "/llm/calls/"+created.CallID, nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var call models.LLMCall
+    json.Unmarshal(rr.Body.Bytes(), &call)
+    if rr.Code != http.StatusOK || call.FinishReason != "stop" || call.PromptTokens != 1000 {
+        t.Errorf("Unexpected call: %d %+v", rr.Code, call)
+    }
+
+    req, _ = http.NewRequest("GET", "/llm/usage?model=local-llama", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var usage struct {
+        Total models.LLMUsage `json:"total"`
+    }
+    json.Unmarshal(rr.Body.Bytes(), &usage)
+    if usage.Total.Calls != 2 || usage.Total.CostUSD != 0.5 || usage.Total.UnpricedCalls != 1 || usage.Total.PromptTokens != 20 {
+        t.Errorf("Unexpected usage total: %+v", usage.Total)
+    }
+}
+
+func TestLLMRepo_LatencyPercentiles(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewLLMRepository(db)
+
+    from := time.Now().Add(-time.Hour)
+    to := time.Now()
+    mock.ExpectQuery(regexp.QuoteMeta(`percentile_cont($4::float8[]) WITHIN GROUP (ORDER BY latency_ms)`)).
+        WithArgs("", from, to, "{0.5,0.99}").
+        WillReturnRows(sqlmock.NewRows([]string{"model", "count", "avg", "percentile_cont"}).
+            AddRow("gpt-4o", 10, 120.5, "{100,480.25}"))
+
+    got, err := repo.LatencyPercentiles(context.Background(),
+        models.WindowQuery{From: from, To: to}, []float64{0.5, 0.99})
+    if err != nil {
+        t.Fatalf("LatencyPercentiles returned error: %v", err)
+    }
+    if len(got) != 1 || got[0].Quantiles["0.5"] != 100 || got[0].Quantiles["0.99"] != 480.25 || got[0].Count != 10 {
+        t.Errorf("Unexpected latency: %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
+
+func TestLLMRepo_ListCallsByTrace(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewLLMRepository(db)
+
+    start := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    mock.ExpectQuery(regexp.QuoteMeta(`WHERE trace_id = $1`) + `(?s).*` +
+        regexp.QuoteMeta(`ORDER BY COALESCE(started_at, created_at), id`)).
+        WithArgs("trace-1").
+        WillReturnRows(sqlmock.NewRows([]string{"id", "model", "provider", "prompt", "completion",
+            "prompt_tokens", "completion_tokens", "cost_usd", "latency_ms", "finish_reason", "tags",
+            "subject_id", "trace_id", "span_id", "parent_span_id", "started_at", "created_at"}).
+            AddRow("call-1", "gpt-4o", "", "hi", "hello", 3, 2, nil, 120.0, "stop", "{}",
+                "", "trace-1", "span-2", "span-1", start, start.Add(time.Second)))
+
+    got, err := repo.ListCallsByTrace(context.Background(), "trace-1")
+    if err != nil {
+        t.Fatalf("ListCallsByTrace returned error: %v", err)
+    }
+    if len(got) != 1 || got[0].SpanID != "span-2" || got[0].ParentSpanID != "span-1" ||
+        got[0].StartedAt == nil || !got[0].StartedAt.Equal(start) || got[0].CostUSD != nil {
+        t.Errorf("Unexpected calls: %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
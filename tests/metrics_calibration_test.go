@@ -0,0 +1,140 @@
+package tests
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+func TestCalibration_PerfectAndOverconfident(t *testing.T) {
+    // Scores of 0.25 with a 25% positive rate are perfectly calibrated.
+    var recs []metrics.Record
+    for i := 0; i < 8; i++ {
+        label := 0.0
+        if i < 2 {
+            label = 1
+        }
+        recs = append(recs, metrics.Record{Version: "v1", Score: 0.25, HasScore: true, Label: label, HasLabel: true})
+    }
+    rep := metrics.Calibration(recs, metrics.DefaultOptions, metrics.CalibrationOptions{Bins: 4})[0]
+    if rep.ECE > 1e-9 {
+        t.Errorf("Expected ECE 0, got %v", rep.ECE)
+    }
+    if math.Abs(rep.Brier-0.1875) > 1e-9 {
+        t.Errorf("Expected Brier 0.1875, got %v", rep.Brier)
+    }
+    if rep.Bins[1].Count != 8 {
+        t.Errorf("Expected all scores in bin [0.25, 0.5), got %+v", rep.Bins)
+    }
+
+    // The same labels scored at 0.95 are badly overconfident.
+    for i := range recs {
+        recs[i].Score = 0.95
+    }
+    rep = metrics.Calibration(recs, metrics.DefaultOptions, metrics.CalibrationOptions{Bins: 4, ECEThreshold: 0.1})[0]
+    if math.Abs(rep.ECE-0.7) > 1e-9 || !rep.Alert {
+        t.Errorf("Expected ECE 0.7 with alert, got %v alert=%v", rep.ECE, rep.Alert)
+    }
+}
+
+func TestCalibrationEndpoint_Alert(t *testing.T) {
+    var rows []models.LabeledInference
+    for i := 0; i < 10; i++ {
+        rows = append(rows, labeledRow(i, "v2", `{}`, `{"prediction":1,"score":0.9}`, `{"label":0}`))
+    }
+    s := setupMockServer()
+    s.Config = &config.Config{CalibrationECEThreshold: 0.05}
+    s.MetricsRepo = &MockMetricsRepo{Rows: rows}
+
+    req, _ := http.NewRequest("GET", "/calibration?model=fraud", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 OK, got %d", rr.Code)
+    }
+
+    var resp struct {
+        Alerts []metrics.CalibrationReport `json:"alerts"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if len(resp.Alerts) != 1 || resp.Alerts[0].ModelVersion != "v2" {
+        t.Errorf("Expected one alert for v2, got %s", fmt.Sprint(resp.Alerts))
+    }
+}
+
+func TestCalibrationEndpoint_ReportsTruncatedWindow(t *testing.T) {
+    // One row more than the window limit; the oldest one is from v0.
+    rows := []models.LabeledInference{labeledRow(0, "v0", `{}`, `{"prediction":1,"score":0.9}`, `{"label":1}`)}
+    for i := 1; i <= 10; i++ {
+        rows = append(rows, labeledRow(i, "v1", `{}`, `{"prediction":1,"score":0.9}`, `{"label":1}`))
+    }
+    s := setupMockServer()
+    s.Config = &config.Config{MetricsWindowLimit: 10}
+    s.MetricsRepo = &MockMetricsRepo{Rows: rows}
+
+    type calibrationResp struct {
+        Truncated bool                        `json:"truncated"`
+        Reports   []metrics.CalibrationReport `json:"reports"`
+    }
+    get := func() calibrationResp {
+        t.Helper()
+        req, _ := http.NewRequest("GET", "/calibration?model=fraud", nil)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusOK {
+            t.Fatalf("Expected 200 OK, got %d", rr.Code)
+        }
+        var resp calibrationResp
+        json.NewDecoder(rr.Body).Decode(&resp)
+        return resp
+    }
+
+    resp := get()
+    if !resp.Truncated || len(resp.Reports) != 1 || resp.Reports[0].ModelVersion != "v1" || resp.Reports[0].Count != 10 {
+        t.Errorf("Expected the newest 10 rows flagged as truncated, got truncated=%v %+v", resp.Truncated, resp.Reports)
+    }
+
+    s.MetricsRepo = &MockMetricsRepo{Rows: rows[1:]}
+    if resp := get(); resp.Truncated || len(resp.Reports) != 1 || resp.Reports[0].Count != 10 {
+        t.Errorf("Expected a complete window, got truncated=%v %+v", resp.Truncated, resp.Reports)
+    }
+}
+
+func TestCalibrationAndRankingEndpoints_BoundBins(t *testing.T) {
+    s := setupMockServer()
+    s.MetricsRepo = &MockMetricsRepo{Rows: []models.LabeledInference{
+        labeledRow(0, "v1", `{}`, `{"prediction":1,"score":0.9}`, `{"label":1}`),
+        labeledRow(1, "v1", `{}`, `{"prediction":0,"score":0.2}`, `{"label":0}`),
+    }}
+    cases := map[string]int{
+        "/calibration?model=fraud&bins=1000":                    http.StatusOK,
+        "/calibration?model=fraud&bins=1001":                    http.StatusBadRequest,
+        "/calibration?model=fraud&bins=0":                       http.StatusBadRequest,
+        "/calibration?model=fraud&bins=9999999":                 http.StatusBadRequest,
+        "/ranking?model=fraud&bins=1000":                        http.StatusOK,
+        "/ranking?model=fraud&bins=1001":                        http.StatusBadRequest,
+        "/ranking?model=fraud&max_points=10000":                 http.StatusOK,
+        "/ranking?model=fraud&max_points=10001":                 http.StatusBadRequest,
+        "/ranking?model=fraud&max_points=1":                     http.StatusBadRequest,
+        "/slices/worst?model=fraud&metric=accuracy&bins=1000":   http.StatusOK,
+        "/slices/worst?model=fraud&metric=accuracy&bins=1001":   http.StatusBadRequest,
+        "/slices/worst?model=fraud&metric=accuracy&bins=0":      http.StatusBadRequest,
+        "/slices/worst?model=fraud&metric=accuracy&limit=0":     http.StatusBadRequest,
+        "/slices/worst?model=fraud&metric=accuracy&min_count=0": http.StatusBadRequest,
+    }
+    for path, want := range cases {
+        req, _ := http.NewRequest("GET", path, nil)
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != want {
+            t.Errorf("GET %s: expected %d, got %d: %s", path, want, rr.Code, rr.Body.String())
+        }
+    }
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// accuracyRecords returns n labeled records of which `correct` are right.
+func accuracyRecords(version string, n, correct int) []metrics.Record {
+    var recs []metrics.Record
+    for i := 0; i < n; i++ {
+        label := 1.0
+        if i >= correct {
+            label = 0
+        }
+        recs = append(recs, metrics.Record{Version: version, Prediction: 1.0, Label: label, HasLabel: true})
+    }
+    return recs
+}
+
+func TestCompare_TwoProportionZ(t *testing.T) {
+    m, _ := metrics.Lookup("accuracy")
+    a := accuracyRecords("v1", 1000, 700)
+    b := accuracyRecords("v2", 1000, 760)
+
+    c, err := metrics.Compare(m, a, b, "v1", "v2", metrics.DefaultOptions, metrics.CompareOptions{})
+    if err != nil {
+        t.Fatalf("Compare returned error: %v", err)
+    }
+    if c.Test != metrics.TestTwoProportionZ {
+        t.Errorf("Expected z-test, got %s", c.Test)
+    }
+    if math.Abs(c.Delta-0.06) > 1e-9 {
+        t.Errorf("Expected delta 0.06, got %v", c.Delta)
+    }
+    // z = 0.06 / sqrt(0.73*0.27*2/1000) ~ 3.02 => p ~ 0.0025
+    if c.PValue > 0.01 || !c.Significant || c.Better != "b" {
+        t.Errorf("Expected significant improvement of b, got p=%v better=%q", c.PValue, c.Better)
+    }
+    if c.CI.Lower > 0.06 || c.CI.Upper < 0.06 {
+        t.Errorf("CI %+v does not contain the delta", c.CI)
+    }
+}
+
+func TestCompare_MannWhitneyAndBootstrap(t *testing.T) {
+    regression := func(version string, offset float64) []metrics.Record {
+        var recs []metrics.Record
+        for i := 0; i < 50; i++ {
+            recs = append(recs, metrics.Record{Version: version, Prediction: float64(i) + offset, Label: float64(i), HasLabel: true})
+        }
+        return recs
+    }
+    mae, _ := metrics.Lookup("mae")
+    c, err := metrics.Compare(mae, regression("v1", 1), regression("v2", 1), "v1", "v2", metrics.DefaultOptions, metrics.CompareOptions{})
+    if err != nil || c.Test != metrics.TestMannWhitneyU || c.PValue < 0.9 || c.Significant {
+        t.Errorf("Expected non-significant Mann-Whitney result for identical errors, got %+v (%v)", c, err)
+    }
+
+    f1, _ := metrics.Lookup("f1")
+    a := accuracyRecords("v1", 200, 100)
+    b := accuracyRecords("v2", 200, 190)
+    c, err = metrics.Compare(f1, a, b, "v1", "v2", metrics.DefaultOptions, metrics.CompareOptions{Resamples: 200, Seed: 7})
+    if err != nil || c.Test != metrics.TestBootstrap {
+        t.Fatalf("Expected bootstrap comparison, got %+v (%v)", c, err)
+    }
+    if !c.Significant || c.CI.Lower <= 0 {
+        t.Errorf("Expected a significant positive f1 delta, got %+v", c)
+    }
+}
+
+func TestMannWhitneyU_Separated(t *testing.T) {
+    var x, y []float64
+    for i := 0; i < 30; i++ {
+        x = append(x, float64(i))
+        y = append(y, float64(i+100))
+    }
+    if p := metrics.MannWhitneyU(x, y); p > 1e-6 {
+        t.Errorf("Expected tiny p-value for separated samples, got %v", p)
+    }
+}
+
+func TestCompareEndpoint(t *testing.T) {
+    var rows []models.LabeledInference
+    for i := 0; i < 40; i++ {
+        version, label := "v1", i%2
+        if i >= 20 {
+            version, label = "v2", 1
+        }
+        rows = append(rows, labeledRow(i, version, `{}`, `{"prediction":1}`, fmt.Sprintf(`{"label":%d}`, label)))
+    }
+    s := setupMockServer()
+    s.MetricsRepo = &MockMetricsRepo{Rows: rows}
+
+    req, _ := http.NewRequest("GET", "/compare?model=fraud&a=v1&b=v2&metric=accuracy", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 OK, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var c metrics.Comparison
+    json.NewDecoder(rr.Body).Decode(&c)
+    if c.A.N != 20 || c.B.N != 20 || math.Abs(c.Delta-0.5) > 1e-9 {
+        t.Errorf("Unexpected comparison: %+v", c)
+    }
+
+    req, _ = http.NewRequest("GET", "/compare?model=fraud&a=v1&metric=accuracy", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 without b, got %d", rr.Code)
+    }
+
+    req, _ = http.NewRequest("GET", "/compare?model=fraud&a=v1&b=v2&metric=accuracy&resamples=10001", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for too many resamples, got %d", rr.Code)
+    }
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+)
+
+// fairnessRecords: group A is selected 8/10 times, group B 4/10 times.
+// Labels are positive for the first 5 records of each group.
+func fairnessRecords() []metrics.Record {
+    var recs []metrics.Record
+    add := func(group string, selected int) {
+        for i := 0; i < 10; i++ {
+            pred, label := 0.0, 0.0
+            if i < selected {
+                pred = 1
+            }
+            if i < 5 {
+                label = 1
+            }
+            recs = append(recs, metrics.Record{
+                Version:    "v1",
+                Input:      map[string]interface{}{"gender": group},
+                Prediction: pred,
+                Label:      label,
+                HasLabel:   true,
+            })
+        }
+    }
+    add("A", 8)
+    add("B", 4)
+    return recs
+}
+
+func TestFairness_Metrics(t *testing.T) {
+    reports := metrics.Fairness(fairnessRecords(), metrics.DefaultOptions, metrics.FairnessOptions{Attribute: "gender"})
+    if len(reports) != 1 {
+        t.Fatalf("Expected 1 report, got %d", len(reports))
+    }
+    m := reports[0].Metrics
+
+    near := func(name string, want float64) {
+        got, ok := m[name]
+        if !ok {
+            t.Errorf("Missing metric %s", name)
+            return
+        }
+        if math.Abs(got.Value-want) > 1e-9 {
+            t.Errorf("%s = %v, want %v", name, got.Value, want)
+        }
+    }
+    near("demographic_parity_difference", 0.4)
+    near("disparate_impact_ratio", 0.5)
+    // TPR: A 5/5, B 4/5. FPR: A 3/5, B 0/5.
+    near("equal_opportunity_difference", 0.2)
+    near("equalized_odds_difference", 0.6)
+
+    ci := m["demographic_parity_difference"].CI
+    if ci == nil || ci.Lower > 0.4 || ci.Upper < 0.4 {
+        t.Errorf("Expected CI around 0.4, got %+v", ci)
+    }
+}
+
+func TestFairness_MinCountDropsGroups(t *testing.T) {
+    reports := metrics.Fairness(fairnessRecords(), metrics.DefaultOptions, metrics.FairnessOptions{Attribute: "gender", MinCount: 11})
+    if len(reports[0].Groups) != 0 || len(reports[0].Metrics) != 0 {
+        t.Errorf("Expected no groups above min_count, got %+v", reports[0])
+    }
+}
+
+func TestFairnessEndpoint_NoAttribute(t *testing.T) {
+    s := setupMockServer()
+    s.Config = &config.Config{}
+    s.MetricsRepo = &MockMetricsRepo{}
+
+    req, _ := http.NewRequest("GET", "/fairness?model=fraud", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 without configured attribute, got %d", rr.Code)
+    }
+}
+
+func TestConfig_ProtectedAttribute(t *testing.T) {
+    t.Setenv("FAIRNESS_PROTECTED_ATTRIBUTES", "credit=applicant.gender, *=gender")
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        t.Fatalf("LoadConfig returned error: %v", err)
+    }
+    if got := cfg.ProtectedAttribute("credit"); got != "applicant.gender" {
+        t.Errorf("Expected applicant.gender, got %q", got)
+    }
+    if got := cfg.ProtectedAttribute("other"); got != "gender" {
+        t.Errorf("Expected fallback gender, got %q", got)
+    }
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+    "encoding/json"
+    "math"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+)
+
+func scoredRecords(scores []float64, labels []int) []metrics.Record {
+    var recs []metrics.Record
+    for i := range scores {
+        recs = append(recs, metrics.Record{
+            Version: "v1", Score: scores[i], HasScore: true, Label: float64(labels[i]), HasLabel: true,
+        })
+    }
+    return recs
+}
+
+func TestRanking_KnownAUC(t *testing.T) {
+    // 3 of the 4 positive/negative pairs are ordered correctly => AUC 0.75
+    recs := scoredRecords([]float64{0.9, 0.8, 0.7, 0.1}, []int{1, 0, 1, 0})
+    reports := metrics.Ranking(recs, metrics.DefaultOptions, metrics.RankingOptions{K: []int{1, 2}, Bins: 2})
+    if len(reports) != 1 {
+        t.Fatalf("Expected 1 report, got %d", len(reports))
+    }
+    rep := reports[0]
+    if math.Abs(rep.ROCAUC-0.75) > 1e-9 {
+        t.Errorf("Expected ROC AUC 0.75, got %v", rep.ROCAUC)
+    }
+    // AP = 0.5*1 + 0.5*(2/3)
+    if math.Abs(rep.PRAUC-(0.5+1.0/3)) > 1e-9 {
+        t.Errorf("Expected PR AUC 0.8333, got %v", rep.PRAUC)
+    }
+    if math.Abs(rep.KS-0.5) > 1e-9 {
+        t.Errorf("Expected KS 0.5, got %v", rep.KS)
+    }
+    if rep.PrecisionAtK[1] != 1 || rep.PrecisionAtK[2] != 0.5 {
+        t.Errorf("Unexpected precision@k: %v", rep.PrecisionAtK)
+    }
+    if len(rep.Gain) != 2 || rep.Gain[1].Gain != 1 || rep.Gain[0].Lift != 1 {
+        t.Errorf("Unexpected gain curve: %+v", rep.Gain)
+    }
+    first, last := rep.ROC[0], rep.ROC[len(rep.ROC)-1]
+    if first.FPR != 0 || first.TPR != 0 || last.FPR != 1 || last.TPR != 1 {
+        t.Errorf("ROC curve should span (0,0) to (1,1), got %+v", rep.ROC)
+    }
+}
+
+func TestRanking_TiesAndSingleClass(t *testing.T) {
+    recs := scoredRecords([]float64{0.5, 0.5}, []int{1, 0})
+    auc, err := metrics.AUC(recs, metrics.DefaultOptions)
+    if err != nil || auc != 0.5 {
+        t.Errorf("Expected AUC 0.5 for tied scores, got %v (%v)", auc, err)
+    }
+
+    if _, err := metrics.AUC(scoredRecords([]float64{0.2, 0.4}, []int{1, 1}), metrics.DefaultOptions); err == nil {
+        t.Error("Expected error for a single class, got nil")
+    }
+}
+
+func TestRanking_NonFiniteScores(t *testing.T) {
+    recs := scoredRecords([]float64{0.9, math.NaN(), 0.1, math.Inf(1)}, []int{1, 1, 0, 0})
+    done := make(chan []metrics.RankingReport, 1)
+    go func() { done <- metrics.Ranking(recs, metrics.DefaultOptions, metrics.RankingOptions{Bins: 2}) }()
+    select {
+    case reports := <-done:
+        if len(reports) != 1 || reports[0].Count != 2 || reports[0].ROCAUC != 1 {
+            t.Errorf("Expected non-finite scores to be skipped, got %+v", reports)
+        }
+    case <-time.After(3 * time.Second):
+        t.Fatal("Ranking did not return with a NaN score")
+    }
+
+    for _, v := range []interface{}{"NaN", "Inf", "-Infinity", json.Number("NaN")} {
+        if _, ok := jsonpath.Float(v); ok {
+            t.Errorf("Expected Float(%v) to be rejected", v)
+        }
+    }
+}
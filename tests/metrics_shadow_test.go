@@ -0,0 +1,109 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+func shadowPairs() []models.ShadowPair {
+    var pairs []models.ShadowPair
+    for i := 0; i < 10; i++ {
+        primaryPred, shadowPred := 1, 1
+        if i < 2 {
+            shadowPred = 0
+        }
+        score := float64(i) / 10
+        pairs = append(pairs, models.ShadowPair{
+            RequestID:     fmt.Sprintf("req-%d", i),
+            InputData:     fmt.Sprintf(`{"n":%d}`, i),
+            PrimaryID:     fmt.Sprintf("p-%d", i),
+            PrimaryOutput: fmt.Sprintf(`{"prediction":%d,"score":%v}`, primaryPred, score),
+            ShadowID:      fmt.Sprintf("s-%d", i),
+            ShadowOutput:  fmt.Sprintf(`{"prediction":%d,"score":%v}`, shadowPred, score*2),
+        })
+    }
+    return pairs
+}
+
+func TestAnalyzeShadow(t *testing.T) {
+    rep := metrics.AnalyzeShadow(shadowPairs(), metrics.DefaultExtractor, 1)
+    if rep.Pairs != 10 || rep.AgreementRate == nil || *rep.AgreementRate != 0.8 {
+        t.Fatalf("Expected agreement 0.8 over 10 pairs, got %+v", rep)
+    }
+    if rep.ScoreCorrelation == nil || math.Abs(*rep.ScoreCorrelation-1) > 1e-9 {
+        t.Errorf("Expected perfectly correlated scores, got %v", rep.ScoreCorrelation)
+    }
+    if len(rep.Disagreements) != 1 || rep.Disagreements[0].RequestID != "req-0" {
+        t.Errorf("Expected one disagreement (limit), got %+v", rep.Disagreements)
+    }
+}
+
+func TestShadowEndpoint(t *testing.T) {
+    s := setupMockServer()
+    s.MetricsRepo = &MockMetricsRepo{Pairs: shadowPairs()}
+
+    req, _ := http.NewRequest("GET", "/shadow?model=fraud&primary=v1&shadow=v2", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 OK, got %d", rr.Code)
+    }
+    var resp struct {
+        Truncated bool                 `json:"truncated"`
+        Report    metrics.ShadowReport `json:"report"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if len(resp.Report.Disagreements) != 2 || resp.Truncated {
+        t.Errorf("Expected 2 disagreements in a complete window, got %d truncated=%v",
+            len(resp.Report.Disagreements), resp.Truncated)
+    }
+
+    // Over the window limit only the newest pairs count; the two oldest
+    // were the disagreements.
+    s.Config = &config.Config{MetricsWindowLimit: 8}
+    req, _ = http.NewRequest("GET", "/shadow?model=fraud&primary=v1&shadow=v2", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    resp.Report = metrics.ShadowReport{}
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if !resp.Truncated || resp.Report.Pairs != 8 || len(resp.Report.Disagreements) != 0 {
+        t.Errorf("Expected the newest 8 pairs flagged as truncated, got %d pairs truncated=%v",
+            resp.Report.Pairs, resp.Truncated)
+    }
+}
+
+func TestCreateInference_ShadowRequiresRequestID(t *testing.T) {
+    s := setupMockServer()
+
+    body := []byte(`{"model_name":"fraud","model_version":"v2","input_data":{},"output_data":{},"shadow":true}`)
+    req, _ := http.NewRequest("POST", "/inferences", bytes.NewBuffer(body))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for shadow without request_id, got %d", rr.Code)
+    }
+
+    req, _ = http.NewRequest("POST", "/inferences", bytes.NewBuffer(body))
+    req.Header.Set("X-Request-ID", "req-42")
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201 with X-Request-ID, got %d", rr.Code)
+    }
+    var resp map[string]string
+    json.NewDecoder(rr.Body).Decode(&resp)
+    inf, _ := s.InferenceRepo.GetInferenceByID(context.Background(), resp["inference_id"])
+    if inf.RequestID != "req-42" || !inf.IsShadow {
+        t.Errorf("Expected shadow inference with request_id req-42, got %+v", inf)
+    }
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/metrics"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+)
+
+// labeledRow builds a labeled inference for the "fraud" model
+func labeledRow(i int, version, input, output, feedback string) models.LabeledInference {
+    return models.LabeledInference{
+        Inference: models.Inference{
+            ID:           fmt.Sprintf("inf-%d", i),
+            ModelName:    "fraud",
+            ModelVersion: version,
+            InputData:    input,
+            OutputData:   output,
+            Tags:         `{"segment":"retail"}`,
+            CreatedAt:    time.Now().Add(-time.Hour),
+            HasFeedback:  feedback != "",
+        },
+        FeedbackData: feedback,
+    }
+}
+
+// sliceRows returns 20 rows where every German prediction is wrong
+func sliceRows() []models.LabeledInference {
+    var rows []models.LabeledInference
+    for i := 0; i < 20; i++ {
+        country, label := "FR", 1
+        if i%2 == 0 {
+            country, label = "DE", 0
+        }
+        input := fmt.Sprintf(`{"country":%q,"age":%d}`, country, 18+i)
+        rows = append(rows, labeledRow(i, "v1", input, `{"prediction":1}`, fmt.Sprintf(`{"label":%d}`, label)))
+    }
+    return rows
+}
+
+func TestParseSlice_Match(t *testing.T) {
+    rec := metrics.Record{
+        Input: map[string]interface{}{"country": "DE", "age": 21.0},
+        Tags:  map[string]interface{}{"segment": "retail"},
+    }
+    cases := map[string]bool{
+        "country = 'DE'":                          true,
+        "country != 'DE'":                         false,
+        "age between 18 and 25":                   true,
+        "country = 'DE' and age > 30":             false,
+        "country in ('FR', 'DE')":                 true,
+        "tags.segment = 'retail'":                 true,
+        "missing is null":                         true,
+        "age >= 21 AND tags.segment is not null": true,
+    }
+    for expr, want := range cases {
+        s, err := metrics.ParseSlice(expr)
+        if err != nil {
+            t.Fatalf("ParseSlice(%q) returned error: %v", expr, err)
+        }
+        if got := s.Match(rec); got != want {
+            t.Errorf("ParseSlice(%q).Match = %v, want %v", expr, got, want)
+        }
+    }
+}
+
+func TestParseSlice_Invalid(t *testing.T) {
+    for _, expr := range []string{"", "country =", "age between 1", "country ~ 'x'", "country = 'DE"} {
+        if _, err := metrics.ParseSlice(expr); err == nil {
+            t.Errorf("Expected error for %q, got nil", expr)
+        }
+    }
+}
+
+func TestFindWorstSlices(t *testing.T) {
+    m, _ := metrics.Lookup("accuracy")
+    recs := metrics.DefaultExtractor.Extract(sliceRows())
+
+    overall, worst := metrics.FindWorstSlices(m, recs, metrics.DefaultOptions, metrics.WorstSliceOptions{
+        Fields: []string{"country"}, MinCount: 5, Limit: 1,
+    })
+    if overall.Value == nil || *overall.Value != 0.5 {
+        t.Fatalf("Expected overall accuracy 0.5, got %+v", overall)
+    }
+    if len(worst) != 1 || worst[0].Slice != "country = 'DE'" || *worst[0].Value != 0 {
+        t.Errorf("Expected country = 'DE' with accuracy 0 as worst slice, got %+v", worst)
+    }
+}
+
+func TestWorstSlicesEndpoint(t *testing.T) {
+    s := setupMockServer()
+    s.MetricsRepo = &MockMetricsRepo{Rows: sliceRows()}
+
+    req, _ := http.NewRequest("GET", "/slices/worst?model=fraud&metric=accuracy&min_count=5", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 OK, got %d: %s", rr.Code, rr.Body.String())
+    }
+
+    var resp struct {
+        Slices []metrics.SliceResult `json:"slices"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if len(resp.Slices) == 0 || resp.Slices[0].Slice != "country = 'DE'" {
+        t.Errorf("Expected country = 'DE' first, got %+v", resp.Slices)
+    }
+}
+
+func TestSliceMetricsEndpoint_BadSlice(t *testing.T) {
+    s := setupMockServer()
+    s.MetricsRepo = &MockMetricsRepo{Rows: sliceRows()}
+
+    q := url.Values{"model": {"fraud"}, "metric": {"accuracy"}, "slice": {"country ="}}
+    req, _ := http.NewRequest("GET", "/slices?"+q.Encode(), nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 Bad Request, got %d", rr.Code)
+    }
+}
@@ -0,0 +1,33 @@
+package tests
+
+import (
+    "io/fs"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/db"
+    "github.com/Olt-Kondirolli91/ml-monitoring/migrations"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+    ups, _ := fs.Glob(migrations.FS, "*.up.sql")
+    downs, _ := fs.Glob(migrations.FS, "*.down.sql")
+    if len(ups) == 0 || len(ups) != len(downs) {
+        t.Fatalf("Expected matching up and down migrations, got %d and %d", len(ups), len(downs))
+    }
+    for _, up := range ups {
+        down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+        if _, err := fs.Stat(migrations.FS, down); err != nil {
+            t.Errorf("Missing %s for %s", down, up)
+        }
+    }
+
+    latest, err := db.LatestMigration()
+    if err != nil {
+        t.Fatalf("LatestMigration returned error: %v", err)
+    }
+    if last := ups[len(ups)-1]; !strings.HasPrefix(last, strconv.FormatUint(uint64(latest), 10)+"_") {
+        t.Errorf("LatestMigration = %d, but the last migration is %s", latest, last)
+    }
+}
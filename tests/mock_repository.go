@@ -2,10 +2,16 @@ package tests
 
 import (
     "context"
+    "encoding/json"
     "errors"
+    "math"
+    "sort"
     "sync"
     "time"
 
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/audit"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/envelope"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
     "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
 )
@@ -64,6 +70,18 @@ func (m *MockInferenceRepo) GetInferenceByID(ctx context.Context, inferenceID st
     return &inf, nil
 }
 
+func (m *MockInferenceRepo) ListInferencesByTrace(ctx context.Context, traceID string) ([]models.Inference, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    var out []models.Inference
+    for _, inf := range m.store {
+        if inf.TraceID == traceID {
+            out = append(out, inf)
+        }
+    }
+    return out, nil
+}
+
 // MockFeedbackRepo is an in-memory implementation
 type MockFeedbackRepo struct {
     store map[string][]models.Feedback
@@ -100,3 +118,638 @@ func (m *MockFeedbackRepo) GetFeedbackByInferenceID(ctx context.Context, inferen
     }
     return feedbacks, nil
 }
+
+// MockMetricsRepo serves a fixed set of labeled inferences and shadow pairs,
+// given oldest first. Like the real repository, it keeps the newest q.Limit.
+type MockMetricsRepo struct {
+    Rows  []models.LabeledInference
+    Pairs []models.ShadowPair
+}
+
+func (m *MockMetricsRepo) ListShadowPairs(ctx context.Context, q models.WindowQuery, shadowVersion string) ([]models.ShadowPair, error) {
+    if q.Limit > 0 && len(m.Pairs) > q.Limit {
+        return m.Pairs[len(m.Pairs)-q.Limit:], nil
+    }
+    return m.Pairs, nil
+}
+
+// SampleInputValues serves the input_data value at path from Rows
+func (m *MockMetricsRepo) SampleInputValues(ctx context.Context, q models.WindowQuery, path string) ([]string, error) {
+    rows, _ := m.ListLabeledInferences(ctx, q)
+    var out []string
+    for _, row := range rows {
+        doc, err := jsonpath.Decode(row.InputData)
+        if err != nil {
+            continue
+        }
+        if v, ok := jsonpath.Lookup(doc, path); ok && v != nil && len(out) < q.Limit {
+            raw, _ := json.Marshal(v)
+            out = append(out, string(raw))
+        }
+    }
+    return out, nil
+}
+
+func (m *MockMetricsRepo) ListLabeledInferences(ctx context.Context, q models.WindowQuery) ([]models.LabeledInference, error) {
+    var out []models.LabeledInference
+    for _, row := range m.Rows {
+        if row.ModelName != q.ModelName {
+            continue
+        }
+        if q.ModelVersion != "" && row.ModelVersion != q.ModelVersion {
+            continue
+        }
+        if row.CreatedAt.Before(q.From) || !row.CreatedAt.Before(q.To) {
+            continue
+        }
+        if q.LabeledOnly && row.FeedbackData == "" {
+            continue
+        }
+        out = append(out, row)
+    }
+    if q.Limit > 0 && len(out) > q.Limit {
+        out = out[len(out)-q.Limit:]
+    }
+    return out, nil
+}
+
+// MockRollupRepo keeps the rollup streams and tables in memory
+type MockRollupRepo struct {
+    Inferences []models.Inference
+    Feedback   []models.FeedbackEvent
+    Stored     map[string]*models.Rollup
+    watermarks map[string]models.Watermark
+    mu         sync.Mutex
+}
+
+func NewMockRollupRepo() *MockRollupRepo {
+    return &MockRollupRepo{
+        Stored:     make(map[string]*models.Rollup),
+        watermarks: make(map[string]models.Watermark),
+    }
+}
+
+func rollupKey(r models.Rollup) string {
+    return r.ModelName + "|" + r.ModelVersion + "|" + r.BucketStart.UTC().Format(time.RFC3339)
+}
+
+func afterWatermark(t time.Time, id string, wm models.Watermark) bool {
+    return t.After(wm.CreatedAt) || (t.Equal(wm.CreatedAt) && id > wm.ID)
+}
+
+func (m *MockRollupRepo) GetWatermark(ctx context.Context, stream string) (models.Watermark, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if wm, ok := m.watermarks[stream]; ok {
+        return wm, nil
+    }
+    return repository.ZeroWatermark(stream), nil
+}
+
+func (m *MockRollupRepo) FetchInferencesAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.Inference, error) {
+    var out []models.Inference
+    for _, inf := range m.Inferences {
+        if afterWatermark(inf.CreatedAt, inf.ID, wm) && inf.CreatedAt.Before(until) && len(out) < limit {
+            out = append(out, inf)
+        }
+    }
+    return out, nil
+}
+
+func (m *MockRollupRepo) FetchFeedbackAfter(ctx context.Context, wm models.Watermark, until time.Time, limit int) ([]models.FeedbackEvent, error) {
+    var out []models.FeedbackEvent
+    for _, ev := range m.Feedback {
+        if afterWatermark(ev.CreatedAt, ev.ID, wm) && ev.CreatedAt.Before(until) && len(out) < limit {
+            out = append(out, ev)
+        }
+    }
+    return out, nil
+}
+
+func (m *MockRollupRepo) ApplyRollups(ctx context.Context, from, to models.Watermark, deltas []models.Rollup) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    current, ok := m.watermarks[from.Stream]
+    if !ok {
+        current = repository.ZeroWatermark(from.Stream)
+    }
+    if !current.CreatedAt.Equal(from.CreatedAt) || current.ID != from.ID {
+        return repository.ErrWatermarkMoved
+    }
+    for i := range deltas {
+        k := rollupKey(deltas[i])
+        stored, ok := m.Stored[k]
+        if !ok {
+            stored = &models.Rollup{ModelName: deltas[i].ModelName, ModelVersion: deltas[i].ModelVersion, BucketStart: deltas[i].BucketStart}
+            m.Stored[k] = stored
+        }
+        if err := stored.Merge(&deltas[i]); err != nil {
+            return err
+        }
+    }
+    m.watermarks[to.Stream] = to
+    return nil
+}
+
+func (m *MockRollupRepo) ListRollups(ctx context.Context, q models.WindowQuery) ([]models.Rollup, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.Rollup
+    for _, r := range m.Stored {
+        if r.ModelName == q.ModelName && (q.ModelVersion == "" || r.ModelVersion == q.ModelVersion) &&
+            !r.BucketStart.Before(q.From) && r.BucketStart.Before(q.To) {
+            out = append(out, *r)
+        }
+    }
+    return out, nil
+}
+
+// MockQualityRepo is an in-memory QualityRepository
+type MockQualityRepo struct {
+    mu         sync.Mutex
+    Profiles   map[string]*models.QualityProfile
+    Hashes     map[string]int64
+    Violations []models.QualityViolation
+    // Saves counts the calls to SaveProfile.
+    Saves int
+}
+
+func NewMockQualityRepo() *MockQualityRepo {
+    return &MockQualityRepo{
+        Profiles: map[string]*models.QualityProfile{},
+        Hashes:   map[string]int64{},
+    }
+}
+
+func (m *MockQualityRepo) GetProfile(ctx context.Context, model string) (*models.QualityProfile, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.Profiles[model], nil
+}
+
+func (m *MockQualityRepo) SaveProfile(ctx context.Context, p *models.QualityProfile) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.Profiles[p.ModelName] = p
+    m.Saves++
+    return nil
+}
+
+func (m *MockQualityRepo) RecordInputHash(ctx context.Context, model, hash string) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.Hashes[model+"|"+hash]++
+    return m.Hashes[model+"|"+hash], nil
+}
+
+func (m *MockQualityRepo) InsertViolations(ctx context.Context, vs []models.QualityViolation) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, v := range vs {
+        v.CreatedAt = time.Now().UTC()
+        m.Violations = append(m.Violations, v)
+    }
+    return nil
+}
+
+func (m *MockQualityRepo) ListViolations(ctx context.Context, q models.WindowQuery) ([]models.QualityViolation, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.QualityViolation
+    for i := len(m.Violations) - 1; i >= 0 && len(out) < q.Limit; i-- {
+        v := m.Violations[i]
+        if v.ModelName == q.ModelName && !v.CreatedAt.Before(q.From) && v.CreatedAt.Before(q.To) {
+            out = append(out, v)
+        }
+    }
+    return out, nil
+}
+
+func (m *MockQualityRepo) CountViolations(ctx context.Context, q models.WindowQuery) (map[string]map[string]int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    out := map[string]map[string]int64{}
+    for _, v := range m.Violations {
+        if v.ModelName != q.ModelName || v.CreatedAt.Before(q.From) || !v.CreatedAt.Before(q.To) {
+            continue
+        }
+        if out[v.Field] == nil {
+            out[v.Field] = map[string]int64{}
+        }
+        out[v.Field][v.Kind]++
+    }
+    return out, nil
+}
+
+// MockSchemaRepo is an in-memory SchemaRepository
+type MockSchemaRepo struct {
+    mu      sync.Mutex
+    Schemas map[string]*models.Schema
+    Events  []models.SchemaEvent
+    // Saves counts the calls to SaveSchema.
+    Saves int
+}
+
+func NewMockSchemaRepo() *MockSchemaRepo {
+    return &MockSchemaRepo{Schemas: map[string]*models.Schema{}}
+}
+
+func schemaKey(model, version, payload string) string {
+    return model + "|" + version + "|" + payload
+}
+
+func (m *MockSchemaRepo) GetSchema(ctx context.Context, model, version, payload string) (*models.Schema, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.Schemas[schemaKey(model, version, payload)], nil
+}
+
+func (m *MockSchemaRepo) SaveSchema(ctx context.Context, s *models.Schema) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.Schemas[schemaKey(s.ModelName, s.ModelVersion, s.Payload)] = s
+    m.Saves++
+    return nil
+}
+
+func (m *MockSchemaRepo) ListSchemas(ctx context.Context, model, version string) ([]models.Schema, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.Schema
+    for _, s := range m.Schemas {
+        if s.ModelName == model && (version == "" || s.ModelVersion == version) {
+            out = append(out, *s)
+        }
+    }
+    return out, nil
+}
+
+func (m *MockSchemaRepo) InsertSchemaEvents(ctx context.Context, events []models.SchemaEvent) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, e := range events {
+        e.CreatedAt = time.Now().UTC()
+        m.Events = append(m.Events, e)
+    }
+    return nil
+}
+
+func (m *MockSchemaRepo) ListSchemaEvents(ctx context.Context, q models.WindowQuery) ([]models.SchemaEvent, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.SchemaEvent
+    for i := len(m.Events) - 1; i >= 0 && len(out) < q.Limit; i-- {
+        e := m.Events[i]
+        if e.ModelName == q.ModelName && (q.ModelVersion == "" || e.ModelVersion == q.ModelVersion) &&
+            !e.CreatedAt.Before(q.From) && e.CreatedAt.Before(q.To) {
+            out = append(out, e)
+        }
+    }
+    return out, nil
+}
+
+// MockLLMRepo is an in-memory LLMRepository
+type MockLLMRepo struct {
+    mu    sync.Mutex
+    Calls []models.LLMCall
+}
+
+func (m *MockLLMRepo) InsertCall(ctx context.Context, c models.LLMCall) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if c.CreatedAt.IsZero() {
+        c.CreatedAt = time.Now().UTC()
+    }
+    m.Calls = append(m.Calls, c)
+    return nil
+}
+
+func (m *MockLLMRepo) GetCallByID(ctx context.Context, id string) (*models.LLMCall, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, c := range m.Calls {
+        if c.ID == id {
+            return &c, nil
+        }
+    }
+    return nil, errNotFound
+}
+
+func (m *MockLLMRepo) ListCallsByTrace(ctx context.Context, traceID string) ([]models.LLMCall, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.LLMCall
+    for _, c := range m.Calls {
+        if c.TraceID == traceID {
+            out = append(out, c)
+        }
+    }
+    return out, nil
+}
+
+func (m *MockLLMRepo) Usage(ctx context.Context, q models.WindowQuery) ([]models.LLMUsage, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.LLMUsage
+    for _, c := range m.Calls {
+        if (q.ModelName != "" && c.Model != q.ModelName) || c.CreatedAt.Before(q.From) || !c.CreatedAt.Before(q.To) {
+            continue
+        }
+        day := c.CreatedAt.UTC().Truncate(24 * time.Hour)
+        i := 0
+        for i < len(out) && !(out[i].Model == c.Model && out[i].Day.Equal(day)) {
+            i++
+        }
+        if i == len(out) {
+            out = append(out, models.LLMUsage{Model: c.Model, Day: day})
+        }
+        out[i].Calls++
+        out[i].PromptTokens += int64(c.PromptTokens)
+        out[i].CompletionTokens += int64(c.CompletionTokens)
+        if c.CostUSD != nil {
+            out[i].CostUSD += *c.CostUSD
+        } else {
+            out[i].UnpricedCalls++
+        }
+    }
+    return out, nil
+}
+
+func (m *MockLLMRepo) LatencyPercentiles(ctx context.Context, q models.WindowQuery, quantiles []float64) ([]models.LLMLatency, error) {
+    return nil, nil
+}
+
+// MockEvaluationRepo is an in-memory EvaluationRepository
+type MockEvaluationRepo struct {
+    mu     sync.Mutex
+    Scores []models.EvaluationScore
+}
+
+func (m *MockEvaluationRepo) UpsertScores(ctx context.Context, scores []models.EvaluationScore) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, s := range scores {
+        if s.CreatedAt.IsZero() {
+            s.CreatedAt = time.Now().UTC()
+        }
+        replaced := false
+        for i, old := range m.Scores {
+            if old.InferenceID == s.InferenceID && old.Evaluator == s.Evaluator {
+                s.ID = old.ID
+                m.Scores[i] = s
+                replaced = true
+            }
+        }
+        if !replaced {
+            m.Scores = append(m.Scores, s)
+        }
+    }
+    return nil
+}
+
+func (m *MockEvaluationRepo) ListScoresByInference(ctx context.Context, inferenceID string) ([]models.EvaluationScore, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.EvaluationScore
+    for _, s := range m.Scores {
+        if s.InferenceID == inferenceID {
+            out = append(out, s)
+        }
+    }
+    return out, nil
+}
+
+// Aggregate buckets by truncating UTC timestamps; weeks are not aligned to
+// Mondays as in Postgres.
+func (m *MockEvaluationRepo) Aggregate(ctx context.Context, q models.WindowQuery, evaluator, bucket string) ([]models.EvaluationAggregate, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    size := map[string]time.Duration{"hour": time.Hour, "day": 24 * time.Hour, "week": 7 * 24 * time.Hour}[bucket]
+    var out []models.EvaluationAggregate
+    for _, s := range m.Scores {
+        if s.ModelName != q.ModelName || (q.ModelVersion != "" && s.ModelVersion != q.ModelVersion) ||
+            (evaluator != "" && s.Evaluator != evaluator) || s.CreatedAt.Before(q.From) || !s.CreatedAt.Before(q.To) {
+            continue
+        }
+        start := s.CreatedAt.UTC().Truncate(size)
+        i := 0
+        for i < len(out) && !(out[i].Evaluator == s.Evaluator && out[i].BucketStart.Equal(start)) {
+            i++
+        }
+        if i == len(out) {
+            out = append(out, models.EvaluationAggregate{Evaluator: s.Evaluator, BucketStart: start, Min: s.Value, Max: s.Value})
+        }
+        a := &out[i]
+        a.Mean = (a.Mean*float64(a.Count) + s.Value) / float64(a.Count+1)
+        a.Count++
+        a.Min = math.Min(a.Min, s.Value)
+        a.Max = math.Max(a.Max, s.Value)
+        if s.Label != "" {
+            if a.Labels == nil {
+                a.Labels = map[string]int64{}
+            }
+            a.Labels[s.Label]++
+        }
+    }
+    return out, nil
+}
+
+// MockPIIRepo is an in-memory PIIRepository
+type MockPIIRepo struct {
+    mu         sync.Mutex
+    Redactions []models.PIIRedaction
+}
+
+func (m *MockPIIRepo) InsertRedactions(ctx context.Context, rs []models.PIIRedaction) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, red := range rs {
+        if red.CreatedAt.IsZero() {
+            red.CreatedAt = time.Now().UTC()
+        }
+        m.Redactions = append(m.Redactions, red)
+    }
+    return nil
+}
+
+func (m *MockPIIRepo) ListRedactions(ctx context.Context, q models.WindowQuery, inferenceID string) ([]models.PIIRedaction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.PIIRedaction
+    for _, red := range m.Redactions {
+        if (q.ModelName != "" && red.ModelName != q.ModelName) || (inferenceID != "" && red.InferenceID != inferenceID) ||
+            red.CreatedAt.Before(q.From) || !red.CreatedAt.Before(q.To) {
+            continue
+        }
+        out = append(out, red)
+    }
+    return out, nil
+}
+
+// MockEnvelopeRepo is an in-memory EnvelopeRepository keyed by column and
+// row ID
+type MockEnvelopeRepo struct {
+    mu       sync.Mutex
+    Payloads map[repository.PayloadColumn]map[string]string
+}
+
+func (m *MockEnvelopeRepo) ListStale(ctx context.Context, col repository.PayloadColumn, primaryKeyID, afterID string, limit int) ([]repository.StoredPayload, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    ids := make([]string, 0, len(m.Payloads[col]))
+    for id := range m.Payloads[col] {
+        if afterID == "" || id > afterID {
+            ids = append(ids, id)
+        }
+    }
+    sort.Strings(ids)
+    var out []repository.StoredPayload
+    for _, id := range ids {
+        data := m.Payloads[col][id]
+        env, ok, _ := envelope.Extract(data)
+        if ok && env.KeyID == primaryKeyID {
+            continue
+        }
+        if len(out) < limit {
+            out = append(out, repository.StoredPayload{ID: id, Data: data})
+        }
+    }
+    return out, nil
+}
+
+func (m *MockEnvelopeRepo) UpdatePayload(ctx context.Context, col repository.PayloadColumn, id, data string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.Payloads[col][id] = data
+    return nil
+}
+
+// MockAuditRepo is an in-memory AuditRepository chaining entries with Chain
+type MockAuditRepo struct {
+    mu      sync.Mutex
+    Chain   *audit.Chain
+    Entries []models.AuditEntry
+    // Appends counts the calls to Append.
+    Appends int
+    // Fail makes the next Fail calls to Append return an error.
+    Fail int
+}
+
+func (m *MockAuditRepo) Append(ctx context.Context, entries ...models.AuditEntry) ([]models.AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.Appends++
+    if m.Fail > 0 {
+        m.Fail--
+        return nil, errors.New("audit store unavailable")
+    }
+    var out []models.AuditEntry
+    for _, e := range entries {
+        prevSeq, prevHash := int64(0), audit.GenesisHash
+        if n := len(m.Entries); n > 0 {
+            prevSeq, prevHash = m.Entries[n-1].Seq, m.Entries[n-1].Hash
+        }
+        m.Chain.Link(&e, prevSeq, prevHash)
+        m.Entries = append(m.Entries, e)
+        out = append(out, e)
+    }
+    return out, nil
+}
+
+func (m *MockAuditRepo) ListEntries(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var out []models.AuditEntry
+    for _, e := range m.Entries {
+        if e.Seq <= q.AfterSeq || (q.Actor != "" && e.Actor != q.Actor) ||
+            (q.ResourceID != "" && e.ResourceID != q.ResourceID) {
+            continue
+        }
+        if len(out) == q.Limit {
+            break
+        }
+        out = append(out, e)
+    }
+    return out, nil
+}
+
+// MockErasureRepo erases from the in-memory inference, feedback and LLM
+// call mocks. LLMCalls may be nil.
+type MockErasureRepo struct {
+    Inferences *MockInferenceRepo
+    Feedback   *MockFeedbackRepo
+    LLMCalls   *MockLLMRepo
+    mu         sync.Mutex
+    Jobs       map[string]models.ErasureJob
+    // Updates records every stored job state in order.
+    Updates    []models.ErasureJob
+}
+
+func (m *MockErasureRepo) EraseBatch(ctx context.Context, subjectID, mode string, limit int) (models.ErasureBatch, error) {
+    m.Inferences.mu.Lock()
+    defer m.Inferences.mu.Unlock()
+    m.Feedback.mu.Lock()
+    defer m.Feedback.mu.Unlock()
+    var b models.ErasureBatch
+    for id, inf := range m.Inferences.store {
+        if inf.SubjectID != subjectID || b.Inferences == limit {
+            continue
+        }
+        b.Inferences++
+        b.Feedback += len(m.Feedback.store[id])
+        if mode == models.ErasureModeAnonymize {
+            inf.InputData, inf.OutputData, inf.Tags, inf.RequestID, inf.SubjectID = "{}", "{}", "{}", "", ""
+            m.Inferences.store[id] = inf
+            for i := range m.Feedback.store[id] {
+                m.Feedback.store[id][i].FeedbackData = "{}"
+            }
+            continue
+        }
+        delete(m.Feedback.store, id)
+        delete(m.Inferences.store, id)
+    }
+    if m.LLMCalls == nil {
+        return b, nil
+    }
+    m.LLMCalls.mu.Lock()
+    defer m.LLMCalls.mu.Unlock()
+    kept := m.LLMCalls.Calls[:0]
+    for _, c := range m.LLMCalls.Calls {
+        if c.SubjectID != subjectID || b.LLMCalls == limit {
+            kept = append(kept, c)
+            continue
+        }
+        b.LLMCalls++
+        if mode == models.ErasureModeAnonymize {
+            c.Prompt, c.Completion, c.Tags, c.SubjectID = "", "", "{}", ""
+            kept = append(kept, c)
+        }
+    }
+    m.LLMCalls.Calls = kept
+    return b, nil
+}
+
+func (m *MockErasureRepo) InsertJob(ctx context.Context, job models.ErasureJob) error {
+    return m.UpdateJob(ctx, job)
+}
+
+func (m *MockErasureRepo) UpdateJob(ctx context.Context, job models.ErasureJob) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.Jobs == nil {
+        m.Jobs = map[string]models.ErasureJob{}
+    }
+    m.Jobs[job.ID] = job
+    m.Updates = append(m.Updates, job)
+    return nil
+}
+
+func (m *MockErasureRepo) GetJob(ctx context.Context, id string) (*models.ErasureJob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    job, ok := m.Jobs[id]
+    if !ok {
+        return nil, errNotFound
+    }
+    return &job, nil
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/config"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/pii"
+)
+
+func TestPIIScanner_Detectors(t *testing.T) {
+    scanner, err := pii.NewScanner(map[string]string{"employee_id": `EMP-\d{6}`}, []byte("secret"))
+    if err != nil {
+        t.Fatalf("NewScanner returned error: %v", err)
+    }
+    cases := map[string]string{
+        "mail Jane.Doe@example.co.uk now":  "email",
+        "call +1 415-555-0100 today":       "phone",
+        "call (030) 1234-5678":             "phone",
+        "call 555.123.4567":                "phone",
+        "card 4111 1111 1111 1111":         "credit_card",
+        "IBAN DE89 3704 0044 0532 0130 00": "iban",
+        "from 192.168.10.1":                "ip",
+        "from 2001:db8::8a2e:370:7334":     "ip",
+        "badge EMP-123456":                 "employee_id",
+    }
+    for text, kind := range cases {
+        _, findings, err := scanner.Redact(`{"text": "`+text+`"}`, pii.ActionMask)
+        if err != nil {
+            t.Fatalf("Redact(%q) returned error: %v", text, err)
+        }
+        if len(findings) != 1 || findings[0].Kind != kind || findings[0].Path != "text" {
+            t.Errorf("Redact(%q) findings = %+v, want one %s", text, findings, kind)
+        }
+    }
+
+    clean := []string{
+        "card 4111 1111 1111 1112", // fails Luhn
+        "IBAN DE89 3704 0044 0532 0130 02",
+        "on 2025-04-10 at 10:30:00",
+        "order 1234567890",
+        "use std::vector and a::b",
+        "version 1.2.3",
+        "price 123.4567",
+        "ratio 12345.6789",
+        "pi 3.14159265358979",
+        "total 0.4111111111111111",
+    }
+    for _, text := range clean {
+        if _, findings, _ := scanner.Redact(`"`+text+`"`, pii.ActionMask); len(findings) != 0 {
+            t.Errorf("Redact(%q) findings = %+v, want none", text, findings)
+        }
+    }
+}
+
+func TestPIIScanner_Numbers(t *testing.T) {
+    scanner, _ := pii.NewScanner(nil, []byte("secret"))
+    raw := `{"order_id":4111111111111111,"amount":123.4567}`
+    if out, findings, _ := scanner.Redact(raw, pii.ActionMask); out != raw || len(findings) != 0 {
+        t.Errorf("Expected numbers to be left alone by default, got %s %+v", out, findings)
+    }
+    scanner.ScanNumbers = true
+    out, findings, _ := scanner.Redact(raw, pii.ActionMask)
+    if out != `{"amount":123.4567,"order_id":"[REDACTED:credit_card]"}` || len(findings) != 1 {
+        t.Errorf("Expected a Luhn-valid number to be masked with ScanNumbers, got %s %+v", out, findings)
+    }
+}
+
+func TestPIIScanner_Actions(t *testing.T) {
+    scanner, _ := pii.NewScanner(nil, []byte("secret"))
+    scanner.ScanNumbers = true
+    raw := `{"user":{"email":"Jane@Example.com","age":41},"card":4111111111111111,"notes":["hi","ip 10.0.0.1"]}`
+
+    masked, findings, err := scanner.Redact(raw, pii.ActionMask)
+    if err != nil {
+        t.Fatalf("Redact returned error: %v", err)
+    }
+    if strings.Contains(masked, "Jane") || strings.Contains(masked, "4111") || strings.Contains(masked, "10.0.0.1") {
+        t.Errorf("Masked payload still contains PII: %s", masked)
+    }
+    if !strings.Contains(masked, `"email":"[REDACTED:email]"`) || !strings.Contains(masked, `"ip [REDACTED:ip]"`) {
+        t.Errorf("Unexpected masked payload: %s", masked)
+    }
+    if len(findings) != 3 || findings[0].Path != "card" || findings[1].Path != "notes.1" || findings[2].Path != "user.email" {
+        t.Errorf("Unexpected findings: %+v", findings)
+    }
+
+    // Hashes are keyed, stable and computed on the normalised value.
+    h1, _, _ := scanner.Redact(`{"email":"Jane@Example.com"}`, pii.ActionHash)
+    h2, _, _ := scanner.Redact(`{"email":"jane@example.com"}`, pii.ActionHash)
+    if h1 != h2 || !strings.HasPrefix(h1, `{"email":"[email:`) {
+        t.Errorf("Expected equal hashes, got %s and %s", h1, h2)
+    }
+    other, _ := pii.NewScanner(nil, []byte("other"))
+    if h3, _, _ := other.Redact(`{"email":"jane@example.com"}`, pii.ActionHash); h3 == h1 {
+        t.Errorf("Expected hashes to depend on the key")
+    }
+    noKey, _ := pii.NewScanner(nil, nil)
+    if _, _, err := noKey.Redact(raw, pii.ActionHash); err != pii.ErrHashKeyRequired {
+        t.Errorf("Expected ErrHashKeyRequired, got %v", err)
+    }
+
+    dropped, _, _ := scanner.Redact(raw, pii.ActionDrop)
+    var doc map[string]interface{}
+    json.Unmarshal([]byte(dropped), &doc)
+    user := doc["user"].(map[string]interface{})
+    if _, ok := user["email"]; ok || user["age"] != float64(41) || doc["card"] != nil {
+        t.Errorf("Unexpected dropped payload: %s", dropped)
+    }
+    if notes := doc["notes"].([]interface{}); len(notes) != 2 || notes[0] != "hi" || notes[1] != nil {
+        t.Errorf("Unexpected dropped notes: %v", notes)
+    }
+
+    unchanged, findings, _ := scanner.Redact(raw, pii.ActionReject)
+    if unchanged != raw || len(findings) != 3 {
+        t.Errorf("Reject should report findings without changing the payload")
+    }
+}
+
+func TestCreateInference_PII(t *testing.T) {
+    s := setupMockServer()
+    repo := &MockPIIRepo{}
+    s.PIIRepo = repo
+    s.PII, _ = pii.NewScanner(nil, []byte("secret"))
+    s.Config = &config.Config{PIIActions: map[string]string{"*": "mask", "strict": "reject"}}
+
+    post := func(model string) *httptest.ResponseRecorder {
+        body := `{"model_name":"` + model + `","model_version":"v1",
+            "input_data":{"email":"jane@example.com","account":12345678901234567890},
+            "output_data":{"reply":"Sent to +44 20 7946 0958"}}`
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(body))
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        return rr
+    }
+
+    rr := post("strict")
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("Expected 422, got %d", rr.Code)
+    }
+    if strings.Contains(rr.Body.String(), "jane") || !strings.Contains(rr.Body.String(), "email input_data.email") {
+        t.Errorf("Unexpected rejection message: %s", rr.Body.String())
+    }
+
+    rr = post("chat")
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+    var created map[string]string
+    json.NewDecoder(rr.Body).Decode(&created)
+    inf, err := s.InferenceRepo.GetInferenceByID(context.Background(), created["inference_id"])
+    if err != nil {
+        t.Fatalf("Inference not stored: %v", err)
+    }
+    if inf.InputData != `{"account":12345678901234567890,"email":"[REDACTED:email]"}` ||
+        inf.OutputData != `{"reply":"Sent to [REDACTED:phone]"}` {
+        t.Errorf("Unexpected stored payloads: %s %s", inf.InputData, inf.OutputData)
+    }
+
+    req, _ := http.NewRequest("GET", "/pii/redactions?inference_id="+inf.ID, nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var redactions []models.PIIRedaction
+    json.NewDecoder(rr.Body).Decode(&redactions)
+    if len(redactions) != 2 || redactions[0].Payload != "input" || redactions[0].Path != "email" ||
+        redactions[1].Payload != "output" || redactions[1].Kind != "phone" || redactions[1].Action != "mask" {
+        t.Errorf("Unexpected redactions: %+v", redactions)
+    }
+}
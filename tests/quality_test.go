@@ -0,0 +1,231 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/quality"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
+)
+
+func violationKinds(vs []models.QualityViolation) map[string]string {
+    out := map[string]string{}
+    for _, v := range vs {
+        out[v.Field] = v.Kind
+    }
+    return out
+}
+
+func TestQuality_LearnedExpectations(t *testing.T) {
+    p := quality.NewProfile("fraud")
+    for i := 0; i < 10; i++ {
+        doc := map[string]interface{}{
+            "amount":  float64(10 * i),
+            "country": []string{"DE", "FR"}[i%2],
+            "user":    map[string]interface{}{"age": float64(30 + i)},
+        }
+        if vs := quality.Observe(p, quality.Flatten(doc), 10); len(vs) != 0 {
+            t.Fatalf("Expected no violations while learning, got %+v", vs)
+        }
+    }
+    if p.Fields["user.age"] == nil || p.Fields["user.age"].Learned == nil {
+        t.Fatalf("Expected learned bounds for nested field, got %+v", p.Fields)
+    }
+
+    // amount learned range is [0, 90] widened by 10% of the span
+    good := map[string]interface{}{"amount": 95.0, "country": "DE", "user": map[string]interface{}{"age": 35.0}}
+    if vs := quality.Check(p, quality.Flatten(good)); len(vs) != 0 {
+        t.Errorf("Expected no violations, got %+v", vs)
+    }
+
+    bad := map[string]interface{}{"amount": 500.0, "country": "US", "user": map[string]interface{}{"age": "35"}}
+    got := violationKinds(quality.Check(p, quality.Flatten(bad)))
+    want := map[string]string{
+        "amount":   quality.KindOutOfRange,
+        "country":  quality.KindUnseenCategory,
+        "user.age": quality.KindTypeMismatch,
+    }
+    for field, kind := range want {
+        if got[field] != kind {
+            t.Errorf("Field %s: expected %s, got %q", field, kind, got[field])
+        }
+    }
+
+    missing := map[string]interface{}{"amount": nil, "country": "DE"}
+    got = violationKinds(quality.Check(p, quality.Flatten(missing)))
+    if got["amount"] != quality.KindNull || got["user.age"] != quality.KindMissing {
+        t.Errorf("Expected null amount and missing user.age, got %v", got)
+    }
+}
+
+func TestQuality_DeclaredBoundsOverrideLearned(t *testing.T) {
+    p := quality.NewProfile("fraud")
+    for i := 0; i < 5; i++ {
+        quality.Observe(p, quality.Flatten(map[string]interface{}{"amount": float64(i)}), 5)
+    }
+    max := 1000.0
+    p.Fields["amount"].Declared = &models.FieldBounds{Type: "integer", Max: &max}
+
+    if vs := quality.Check(p, map[string]interface{}{"amount": 500.0}); len(vs) != 0 {
+        t.Errorf("Expected declared bounds to allow 500, got %+v", vs)
+    }
+    got := violationKinds(quality.Check(p, map[string]interface{}{"amount": 2.5}))
+    if got["amount"] != quality.KindTypeMismatch {
+        t.Errorf("Expected non-integer to violate integer type, got %v", got)
+    }
+    // Declared bounds are not required by default
+    if vs := quality.Check(p, map[string]interface{}{}); len(vs) != 0 {
+        t.Errorf("Expected missing optional field to pass, got %+v", vs)
+    }
+}
+
+func TestQualityMonitor_RecordsViolationsAndDuplicates(t *testing.T) {
+    repo := NewMockQualityRepo()
+    mon := quality.NewMonitor(repo, 3)
+    ctx := context.Background()
+
+    for i := 0; i < 3; i++ {
+        inf := models.Inference{ID: fmt.Sprintf("inf-%d", i), ModelName: "fraud",
+            InputData: fmt.Sprintf(`{"amount": %d, "country": "DE"}`, i)}
+        if err := mon.ObserveInference(ctx, inf); err != nil {
+            t.Fatalf("ObserveInference returned error: %v", err)
+        }
+    }
+    if len(repo.Violations) != 0 {
+        t.Fatalf("Expected no violations during learning, got %+v", repo.Violations)
+    }
+
+    // Same payload with keys in a different order is a duplicate; the
+    // country field silently turned null.
+    dup := models.Inference{ID: "inf-dup", ModelName: "fraud", InputData: `{"country": null, "amount": 1}`}
+    mon.ObserveInference(ctx, dup)
+    mon.ObserveInference(ctx, models.Inference{ID: "inf-dup2", ModelName: "fraud", InputData: `{"amount":1,"country":null}`})
+
+    got := violationKinds(repo.Violations)
+    if got["country"] != quality.KindNull || got[quality.WholeInput] != quality.KindDuplicate {
+        t.Errorf("Expected null and duplicate violations, got %+v", repo.Violations)
+    }
+    if repo.Profiles["fraud"] == nil || repo.Profiles["fraud"].Samples != 5 {
+        t.Errorf("Expected persisted profile with 5 samples, got %+v", repo.Profiles["fraud"])
+    }
+}
+
+func TestQualityMonitor_FlushesChangedProfiles(t *testing.T) {
+    repo := NewMockQualityRepo()
+    mon := quality.NewMonitor(repo, 3)
+    mon.FlushInterval = time.Hour
+    ctx := context.Background()
+
+    for i := 0; i < 5; i++ {
+        inf := models.Inference{ID: fmt.Sprintf("inf-%d", i), ModelName: "fraud",
+            InputData: fmt.Sprintf(`{"amount": %d}`, i)}
+        if err := mon.ObserveInference(ctx, inf); err != nil {
+            t.Fatalf("ObserveInference returned error: %v", err)
+        }
+    }
+    if repo.Saves != 0 {
+        t.Fatalf("Expected no saves on ingest, got %d", repo.Saves)
+    }
+    if p, _ := mon.Profile(ctx, "fraud"); p.Samples != 5 {
+        t.Errorf("Expected the cached profile to have 5 samples, got %d", p.Samples)
+    }
+
+    if err := mon.Flush(ctx); err != nil {
+        t.Fatalf("Flush returned error: %v", err)
+    }
+    mon.Flush(ctx)
+    if repo.Saves != 1 || repo.Profiles["fraud"].Samples != 5 {
+        t.Errorf("Expected one save of 5 samples, got %d saves of %+v", repo.Saves, repo.Profiles["fraud"])
+    }
+
+    // Stopping Run saves what changed since the last flush.
+    mon.ObserveInference(ctx, models.Inference{ID: "inf-5", ModelName: "fraud", InputData: `{"amount": 5}`})
+    runCtx, stop := context.WithCancel(ctx)
+    done := make(chan struct{})
+    go func() {
+        mon.Run(runCtx)
+        close(done)
+    }()
+    stop()
+    <-done
+    if repo.Saves != 2 || repo.Profiles["fraud"].Samples != 6 {
+        t.Errorf("Expected a final save of 6 samples, got %d saves of %+v", repo.Saves, repo.Profiles["fraud"])
+    }
+}
+
+func TestDataQualityEndpoints(t *testing.T) {
+    s := setupMockServer()
+    repo := NewMockQualityRepo()
+    s.QualityRepo = repo
+    s.Quality = quality.NewMonitor(repo, 2)
+    s.Observers = []server.InferenceObserver{s.Quality}
+
+    put := []byte(`{"fields": {"age": {"type": "number", "min": 0, "max": 120, "required": true}}}`)
+    req, _ := http.NewRequest("PUT", "/data-quality/fraud/bounds", bytes.NewBuffer(put))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 from bounds, got %d: %s", rr.Code, rr.Body.String())
+    }
+
+    for _, input := range []string{`{"age": 30}`, `{"age": 300}`, `{"name": "x"}`} {
+        body := []byte(`{"model_name":"fraud","model_version":"v1","input_data":` + input + `,"output_data":{}}`)
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBuffer(body))
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusCreated {
+            t.Fatalf("Expected 201, got %d", rr.Code)
+        }
+    }
+
+    req, _ = http.NewRequest("GET", "/data-quality?model=fraud", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var report struct {
+        Samples    int64            `json:"samples"`
+        Learning   bool             `json:"learning"`
+        Violations map[string]int64 `json:"violations"`
+        Fields     []struct {
+            Field       string  `json:"field"`
+            MissingRate float64 `json:"missing_rate"`
+            Source      string  `json:"source"`
+        } `json:"fields"`
+    }
+    json.Unmarshal(rr.Body.Bytes(), &report)
+    if report.Samples != 3 || report.Learning {
+        t.Errorf("Expected 3 samples past learning, got %+v", report)
+    }
+    if report.Violations[quality.KindOutOfRange] != 1 || report.Violations[quality.KindMissing] != 1 {
+        t.Errorf("Expected one out_of_range and one missing violation, got %v", report.Violations)
+    }
+    if len(report.Fields) == 0 || report.Fields[0].Field != "age" || report.Fields[0].Source != "declared" {
+        t.Errorf("Unexpected fields: %+v", report.Fields)
+    }
+
+    req, _ = http.NewRequest("GET", "/data-quality/violations?model=fraud&limit=1", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var violations []models.QualityViolation
+    json.Unmarshal(rr.Body.Bytes(), &violations)
+    if len(violations) != 1 || violations[0].Kind != quality.KindMissing {
+        t.Errorf("Expected the latest missing violation, got %+v", violations)
+    }
+
+    bad := []byte(`{"fields": {"age": {"min": 10, "max": 1}}}`)
+    req, _ = http.NewRequest("PUT", "/data-quality/fraud/bounds", bytes.NewBuffer(bad))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for inverted bounds, got %d", rr.Code)
+    }
+}
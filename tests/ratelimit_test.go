@@ -0,0 +1,110 @@
+package tests
+
+import (
+    "bytes"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/ratelimit"
+)
+
+func TestLimiter_TokenBucket(t *testing.T) {
+    now := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    l := ratelimit.New(2, 3)
+    l.Now = func() time.Time { return now }
+
+    for i := 0; i < 3; i++ {
+        if ok, _ := l.Allow("a"); !ok {
+            t.Fatalf("Expected request %d within the burst to pass", i+1)
+        }
+    }
+    ok, wait := l.Allow("a")
+    if ok || wait != 500*time.Millisecond {
+        t.Errorf("Expected a refusal with a 500ms wait, got %v %v", ok, wait)
+    }
+    if ok, _ := l.Allow("b"); !ok {
+        t.Errorf("Expected another key to have its own bucket")
+    }
+
+    now = now.Add(500 * time.Millisecond)
+    if ok, _ := l.Allow("a"); !ok {
+        t.Errorf("Expected a token after refilling")
+    }
+
+    // Idle buckets are dropped once they have refilled.
+    now = now.Add(2 * time.Minute)
+    l.Allow("c")
+    if l.Len() != 1 {
+        t.Errorf("Expected idle buckets to be swept, %d left", l.Len())
+    }
+}
+
+func TestRateLimit_PerClientAndRouteClass(t *testing.T) {
+    s := setupMockServer()
+    s.IngestLimiter = ratelimit.New(1, 2)
+    s.QueryLimiter = ratelimit.New(1, 1)
+
+    call := func(method, path, remote string) *httptest.ResponseRecorder {
+        req, _ := http.NewRequest(method, path, bytes.NewBufferString(
+            `{"model_name": "m", "model_version": "v1", "input_data": {}, "output_data": {}}`))
+        req.RemoteAddr = remote
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        return rr
+    }
+
+    for i := 0; i < 2; i++ {
+        if rr := call("POST", "/inferences", "10.0.0.1:5000"); rr.Code != http.StatusCreated {
+            t.Fatalf("Expected 201 within the burst, got %d", rr.Code)
+        }
+    }
+    rr := call("POST", "/inferences", "10.0.0.1:5001")
+    if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+        t.Errorf("Expected 429 with Retry-After: 1, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+    }
+    if rr := call("POST", "/inferences", "10.0.0.2:5000"); rr.Code != http.StatusCreated {
+        t.Errorf("Expected another client to be unaffected, got %d", rr.Code)
+    }
+    // Queries have their own budget.
+    if rr := call("GET", "/inferences/missing", "10.0.0.1:5000"); rr.Code != http.StatusNotFound {
+        t.Errorf("Expected the query budget to be separate, got %d", rr.Code)
+    }
+    if rr := call("GET", "/inferences/missing", "10.0.0.1:5000"); rr.Code != http.StatusTooManyRequests {
+        t.Errorf("Expected 429 once the query budget is spent, got %d", rr.Code)
+    }
+}
+
+func TestMaxBodySize(t *testing.T) {
+    s := setupMockServer()
+    s.MaxBodyBytes = 256
+    big := `{"model_name": "m", "model_version": "v1", "input_data": {"blob": "` +
+        strings.Repeat("x", 1024) + `"}, "output_data": {}}`
+
+    req, _ := http.NewRequest("POST", "/inferences", strings.NewReader(big))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusRequestEntityTooLarge {
+        t.Errorf("Expected 413 for a large declared body, got %d", rr.Code)
+    }
+
+    // Without a Content-Length the limit applies while decoding.
+    req, _ = http.NewRequest("POST", "/inferences/some-id/feedback",
+        io.MultiReader(strings.NewReader(`{"feedback_data": "`+strings.Repeat("y", 1024)+`"}`)))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusRequestEntityTooLarge {
+        t.Errorf("Expected 413 for a large streamed body, got %d", rr.Code)
+    }
+
+    req, _ = http.NewRequest("POST", "/inferences", strings.NewReader(
+        `{"model_name": "m", "model_version": "v1", "input_data": {}, "output_data": {}}`))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Errorf("Expected small bodies to pass, got %d", rr.Code)
+    }
+}
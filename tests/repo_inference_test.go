@@ -22,8 +22,10 @@ func TestInsertInference_Success(t *testing.T) {
     repo := repository.NewInferenceRepository(db)
 
     // The query your InsertInference method executes:
-    query := regexp.QuoteMeta(`INSERT INTO inferences (id, model_name, model_version, input_data, output_data, has_feedback)
-        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6)`)
+    query := regexp.QuoteMeta(`INSERT INTO inferences (id, model_name, model_version, input_data, output_data, tags, latency_ms,
+            request_id, is_shadow, has_feedback, trace_id, span_id, parent_span_id, started_at, subject_id)
+        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6::jsonb, $7, NULLIF($8, ''), $9, $10,
+            NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), $14, NULLIF($15, ''))`)
 
     mock.ExpectExec(query).
         WithArgs(
@@ -32,7 +34,16 @@ func TestInsertInference_Success(t *testing.T) {
             "v1",
             `{"sample":"input"}`,
             `{"prediction":"output"}`,
+            `{}`,
+            nil,
+            "",
             false,
+            false,
+            "",
+            "",
+            "",
+            nil,
+            "subject-7",
         ).
         WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -42,6 +53,7 @@ func TestInsertInference_Success(t *testing.T) {
         ModelVersion: "v1",
         InputData:    `{"sample":"input"}`,
         OutputData:   `{"prediction":"output"}`,
+        SubjectID:    "subject-7",
         HasFeedback:  false,
     }
 
@@ -64,8 +76,10 @@ func TestInsertInference_DBError(t *testing.T) {
     defer db.Close()
 
     repo := repository.NewInferenceRepository(db)
-    query := regexp.QuoteMeta(`INSERT INTO inferences (id, model_name, model_version, input_data, output_data, has_feedback)
-        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6)`)
+    query := regexp.QuoteMeta(`INSERT INTO inferences (id, model_name, model_version, input_data, output_data, tags, latency_ms,
+            request_id, is_shadow, has_feedback, trace_id, span_id, parent_span_id, started_at, subject_id)
+        VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6::jsonb, $7, NULLIF($8, ''), $9, $10,
+            NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), $14, NULLIF($15, ''))`)
 
     // Simulate a DB error
     mock.ExpectExec(query).
@@ -99,11 +113,14 @@ func TestGetInferenceByID_Success(t *testing.T) {
 
     repo := repository.NewInferenceRepository(db)
 
-    query := regexp.QuoteMeta(`SELECT id, model_name, model_version, input_data, output_data, created_at, has_feedback
+    query := regexp.QuoteMeta(`SELECT id, model_name, model_version, input_data, output_data, tags, latency_ms,
+               COALESCE(request_id, ''), is_shadow, created_at, has_feedback,
+               COALESCE(trace_id, ''), COALESCE(span_id, ''), COALESCE(parent_span_id, ''), started_at,
+               COALESCE(subject_id, '')
         FROM inferences
         WHERE id = $1`)
 
-    columns := []string{"id", "model_name", "model_version", "input_data", "output_data", "created_at", "has_feedback"}
+    columns := []string{"id", "model_name", "model_version", "input_data", "output_data", "tags", "latency_ms", "request_id", "is_shadow", "created_at", "has_feedback", "trace_id", "span_id", "parent_span_id", "started_at", "subject_id"}
     mock.ExpectQuery(query).
         WithArgs("some-inf-id").
         WillReturnRows(
@@ -113,8 +130,17 @@ func TestGetInferenceByID_Success(t *testing.T) {
                 "v1",
                 `{"sample":"input"}`,
                 `{"prediction":"output"}`,
+                `{}`,
+                12.5,
+                "req-1",
+                false,
                 time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC),
                 false,
+                "",
+                "",
+                "",
+                nil,
+                "",
             ),
         )
 
@@ -140,7 +166,10 @@ func TestGetInferenceByID_NotFound(t *testing.T) {
 
     repo := repository.NewInferenceRepository(db)
 
-    query := regexp.QuoteMeta(`SELECT id, model_name, model_version, input_data, output_data, created_at, has_feedback
+    query := regexp.QuoteMeta(`SELECT id, model_name, model_version, input_data, output_data, tags, latency_ms,
+               COALESCE(request_id, ''), is_shadow, created_at, has_feedback,
+               COALESCE(trace_id, ''), COALESCE(span_id, ''), COALESCE(parent_span_id, ''), started_at,
+               COALESCE(subject_id, '')
         FROM inferences
         WHERE id = $1`)
 
@@ -148,7 +177,7 @@ func TestGetInferenceByID_NotFound(t *testing.T) {
     mock.ExpectQuery(query).
         WithArgs("non-existent-id").
         WillReturnRows(sqlmock.NewRows([]string{
-            "id", "model_name", "model_version", "input_data", "output_data", "created_at", "has_feedback",
+            "id", "model_name", "model_version", "input_data", "output_data", "tags", "latency_ms", "request_id", "is_shadow", "created_at", "has_feedback", "trace_id", "span_id", "parent_span_id", "started_at", "subject_id",
         }))
 
     inf, err := repo.GetInferenceByID(context.Background(), "non-existent-id")
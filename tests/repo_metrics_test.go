@@ -0,0 +1,72 @@
+package tests
+
+import (
+    "context"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/repository"
+)
+
+func TestMetricsRepo_ListLabeledInferencesKeepsNewest(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewMetricsRepository(db)
+
+    to := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    from := to.Add(-time.Hour)
+    mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY i.created_at DESC`) + `\s*` + regexp.QuoteMeta(`LIMIT $6`)).
+        WithArgs("fraud", "", from, to, false, 2).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "model_name", "model_version", "input_data",
+            "output_data", "tags", "created_at", "has_feedback", "feedback_data"}).
+            AddRow("inf-3", "fraud", "v1", "{}", "{}", "{}", to.Add(-time.Minute), true, `{"label":1}`).
+            AddRow("inf-2", "fraud", "v1", "{}", "{}", "{}", to.Add(-2*time.Minute), false, nil))
+
+    got, err := repo.ListLabeledInferences(context.Background(),
+        models.WindowQuery{ModelName: "fraud", From: from, To: to, Limit: 2})
+    if err != nil {
+        t.Fatalf("ListLabeledInferences returned error: %v", err)
+    }
+    if len(got) != 2 || got[0].ID != "inf-2" || got[1].ID != "inf-3" || got[1].FeedbackData != `{"label":1}` {
+        t.Errorf("Expected the newest rows oldest first, got %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
+
+func TestMetricsRepo_ListShadowPairsKeepsNewest(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("Failed to open sqlmock: %v", err)
+    }
+    defer db.Close()
+    repo := repository.NewMetricsRepository(db)
+
+    to := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    from := to.Add(-time.Hour)
+    mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY p.created_at DESC`) + `\s*` + regexp.QuoteMeta(`LIMIT $6`)).
+        WithArgs("fraud", "v1", "v2", from, to, 2).
+        WillReturnRows(sqlmock.NewRows([]string{"request_id", "created_at", "input_data", "id",
+            "output_data", "id", "output_data"}).
+            AddRow("req-3", to.Add(-time.Minute), "{}", "p-3", "{}", "s-3", "{}").
+            AddRow("req-2", to.Add(-2*time.Minute), "{}", "p-2", "{}", "s-2", "{}"))
+
+    got, err := repo.ListShadowPairs(context.Background(),
+        models.WindowQuery{ModelName: "fraud", ModelVersion: "v1", From: from, To: to, Limit: 2}, "v2")
+    if err != nil {
+        t.Fatalf("ListShadowPairs returned error: %v", err)
+    }
+    if len(got) != 2 || got[0].RequestID != "req-2" || got[1].RequestID != "req-3" {
+        t.Errorf("Expected the newest pairs oldest first, got %+v", got)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Unfulfilled expectations: %v", err)
+    }
+}
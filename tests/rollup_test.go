@@ -0,0 +1,179 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/rollup"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/sketch"
+)
+
+func TestSketch_QuantilesAndMerge(t *testing.T) {
+    a := sketch.New(sketch.DefaultRelativeAccuracy)
+    b := sketch.New(sketch.DefaultRelativeAccuracy)
+    for i := 1; i <= 1000; i++ {
+        if i%2 == 0 {
+            a.Add(float64(i))
+        } else {
+            b.Add(float64(i))
+        }
+    }
+    if err := a.Merge(b); err != nil {
+        t.Fatalf("Merge returned error: %v", err)
+    }
+    if a.Count != 1000 || a.Min != 1 || a.Max != 1000 {
+        t.Fatalf("Unexpected merged sketch: count=%d min=%v max=%v", a.Count, a.Min, a.Max)
+    }
+    for _, q := range []float64{0.5, 0.9, 0.99} {
+        want := q * 999
+        got := a.Quantile(q)
+        if math.Abs(got-want)/want > 0.02 {
+            t.Errorf("Quantile(%v) = %v, want ~%v", q, got, want)
+        }
+    }
+
+    // JSON round trip keeps the sketch mergeable
+    raw, _ := json.Marshal(a)
+    var decoded sketch.Sketch
+    json.Unmarshal(raw, &decoded)
+    if decoded.Quantile(0.5) != a.Quantile(0.5) {
+        t.Errorf("Quantile changed after JSON round trip")
+    }
+}
+
+func TestAggregator_SkipsEncryptionEnvelope(t *testing.T) {
+    agg := rollup.NewAggregator(time.Hour)
+    agg.AddInference(models.Inference{ModelName: "fraud", ModelVersion: "v1", CreatedAt: time.Now(),
+        InputData: `{"age": 41, "__enc": {"v": 1, "kid": "2025-04", "dek": "x", "ct": "y"}}`})
+    rollups := agg.Rollups()
+    if len(rollups) != 1 || rollups[0].InferenceCount != 1 {
+        t.Fatalf("Unexpected rollups: %+v", rollups)
+    }
+    if _, ok := rollups[0].Features["__enc"]; ok || rollups[0].Features["age"].Numeric.Count != 1 {
+        t.Errorf("Expected only the clear fields to be rolled up, got %+v", rollups[0].Features)
+    }
+}
+
+func TestFeatureRollup_KeepsTopCategories(t *testing.T) {
+    sum := func(f *models.FeatureRollup) (n int64) {
+        for _, c := range f.Categories {
+            n += c
+        }
+        return n
+    }
+
+    var f models.FeatureRollup
+    for i := 0; i < models.MaxCategories; i++ {
+        f.AddCategory(fmt.Sprintf("early-%d", i), 1)
+    }
+    // A value that only turns frequent once the set is full still makes it.
+    for i := 0; i < 50; i++ {
+        f.AddCategory("late", 1)
+    }
+    if len(f.Categories) != models.MaxCategories || f.Categories["late"] < 50 || sum(&f) != 150 {
+        t.Errorf("Expected late among %d values with all 150 counted, got %d (%d values, %d counted)",
+            models.MaxCategories, f.Categories["late"], len(f.Categories), sum(&f))
+    }
+
+    a, b := &models.FeatureRollup{}, &models.FeatureRollup{}
+    for i := 0; i < models.MaxCategories; i++ {
+        a.AddCategory(fmt.Sprintf("a-%d", i), 1)
+        b.AddCategory(fmt.Sprintf("b-%d", i), 2)
+    }
+    a.AddCategory("a-0", 10)
+    // a-0 and 99 of the b values are kept; a-1..a-99 and b-99 go to other.
+    a.Merge(b)
+    if len(a.Categories) != models.MaxCategories+1 || a.Categories["a-0"] != 11 || a.Categories["b-7"] != 2 ||
+        a.Categories[models.OtherCategory] != 101 || sum(a) != 310 {
+        t.Errorf("Expected the most frequent values kept and the rest under %s, got %v", models.OtherCategory, a.Categories)
+    }
+}
+
+func TestRollupWorker_IncrementalAndIdempotent(t *testing.T) {
+    repo := NewMockRollupRepo()
+    base := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+    for i := 0; i < 10; i++ {
+        latency := float64(10 * (i + 1))
+        repo.Inferences = append(repo.Inferences, models.Inference{
+            ID:           fmt.Sprintf("inf-%02d", i),
+            ModelName:    "fraud",
+            ModelVersion: "v1",
+            InputData:    fmt.Sprintf(`{"amount":%d,"country":"DE","note":null}`, i),
+            LatencyMs:    &latency,
+            CreatedAt:    base.Add(time.Duration(i) * time.Minute),
+        })
+    }
+    repo.Feedback = append(repo.Feedback, models.FeedbackEvent{
+        ID: "fb-1", CreatedAt: base.Add(2 * time.Hour), ModelName: "fraud", ModelVersion: "v1", InferenceCreatedAt: base,
+    })
+
+    w := rollup.NewWorker(repo, time.Hour, time.Minute, 0, 4)
+    ctx := context.Background()
+    if err := w.RunOnce(ctx); err != nil {
+        t.Fatalf("RunOnce returned error: %v", err)
+    }
+    // A second pass must not double count.
+    if err := w.RunOnce(ctx); err != nil {
+        t.Fatalf("RunOnce returned error: %v", err)
+    }
+
+    if len(repo.Stored) != 1 {
+        t.Fatalf("Expected a single bucket, got %d", len(repo.Stored))
+    }
+    for _, r := range repo.Stored {
+        if r.InferenceCount != 10 || r.FeedbackCount != 1 {
+            t.Errorf("Expected 10 inferences and 1 feedback, got %d / %d", r.InferenceCount, r.FeedbackCount)
+        }
+        if r.Latency == nil || r.Latency.Count != 10 || r.Latency.Max != 100 {
+            t.Errorf("Unexpected latency sketch: %+v", r.Latency)
+        }
+        if r.Features["country"].Categories["DE"] != 10 || r.Features["note"].Missing != 10 {
+            t.Errorf("Unexpected feature rollups: %+v", r.Features)
+        }
+        if r.Features["amount"].Numeric.Count != 10 {
+            t.Errorf("Expected 10 numeric amount values, got %d", r.Features["amount"].Numeric.Count)
+        }
+    }
+
+    // New traffic is folded into the existing bucket.
+    repo.Inferences = append(repo.Inferences, models.Inference{
+        ID: "inf-10", ModelName: "fraud", ModelVersion: "v1", InputData: `{}`, CreatedAt: base.Add(30 * time.Minute),
+    })
+    w.RunOnce(ctx)
+    for _, r := range repo.Stored {
+        if r.InferenceCount != 11 {
+            t.Errorf("Expected 11 inferences after incremental run, got %d", r.InferenceCount)
+        }
+    }
+
+    s := setupMockServer()
+    s.RollupRepo = repo
+    req, _ := http.NewRequest("GET", "/rollups?model=fraud&merge=true&quantiles=0.5", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200 OK, got %d", rr.Code)
+    }
+    var resp struct {
+        Rollups []struct {
+            InferenceCount int64 `json:"inference_count"`
+            Latency        struct {
+                Quantiles map[string]float64 `json:"quantiles"`
+            } `json:"latency_ms"`
+        } `json:"rollups"`
+    }
+    json.NewDecoder(rr.Body).Decode(&resp)
+    if len(resp.Rollups) != 1 || resp.Rollups[0].InferenceCount != 11 {
+        t.Fatalf("Unexpected rollups response: %+v", resp)
+    }
+    if p50 := resp.Rollups[0].Latency.Quantiles["p50"]; math.Abs(p50-50) > 1 {
+        t.Errorf("Expected latency p50 ~50, got %v", p50)
+    }
+}
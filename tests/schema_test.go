@@ -0,0 +1,145 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/jsonpath"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/schema"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/server"
+)
+
+func observeJSON(t *testing.T, s *models.Schema, raw string, window int64) []models.SchemaEvent {
+    doc, err := jsonpath.Decode(raw)
+    if err != nil {
+        t.Fatalf("Decode(%s) returned error: %v", raw, err)
+    }
+    return schema.Observe(s, doc, time.Now(), window)
+}
+
+func TestSchema_DetectsChanges(t *testing.T) {
+    s := schema.NewSchema("fraud", "v1", schema.PayloadInput)
+
+    if events := observeJSON(t, s, `{"amount": 1, "user": {"id": "u1"}, "items": [{"sku": "a"}]}`, 3); len(events) != 0 {
+        t.Fatalf("Expected no events for the initial schema, got %+v", events)
+    }
+    for _, path := range []string{"amount", "user", "user.id", "items", "items[]", "items[].sku"} {
+        if s.Fields[path] == nil {
+            t.Errorf("Expected path %s in schema, got %v", path, s.Fields)
+        }
+    }
+
+    // Null is not a type change; a new field is reported.
+    events := observeJSON(t, s, `{"amount": null, "user": {"id": "u2", "tier": "gold"}, "items": []}`, 3)
+    if len(events) != 1 || events[0].Kind != schema.EventFieldAdded || events[0].Field != "user.tier" {
+        t.Errorf("Expected only user.tier to be added, got %+v", events)
+    }
+
+    events = observeJSON(t, s, `{"amount": "12.5", "user": {"id": "u3", "tier": "gold"}}`, 3)
+    if len(events) != 1 || events[0].Kind != schema.EventTypeChanged || events[0].Detail != "number -> string" {
+        t.Errorf("Expected amount type change, got %+v", events)
+    }
+
+    // items[].sku was last seen in observation 1; after 3 more it is removed.
+    events = observeJSON(t, s, `{"amount": 1, "user": {"id": "u4", "tier": "gold"}}`, 3)
+    removed := map[string]bool{}
+    for _, e := range events {
+        if e.Kind == schema.EventFieldRemoved {
+            removed[e.Field] = true
+        }
+    }
+    if !removed["items[]"] || !removed["items[].sku"] || removed["items"] {
+        t.Errorf("Expected items[] and items[].sku removed, got %+v", events)
+    }
+
+    events = observeJSON(t, s, `{"amount": 1, "user": {"id": "u5"}, "items": [{"sku": "b"}]}`, 3)
+    readded := map[string]bool{}
+    for _, e := range events {
+        if e.Kind == schema.EventFieldAdded {
+            readded[e.Field] = true
+        }
+    }
+    if !readded["items[].sku"] {
+        t.Errorf("Expected items[].sku to reappear, got %+v", events)
+    }
+}
+
+func TestSchema_JSONSchema(t *testing.T) {
+    s := schema.NewSchema("fraud", "v1", schema.PayloadInput)
+    observeJSON(t, s, `{"amount": 1, "user": {"id": "u1"}, "tags": ["a"]}`, 100)
+    observeJSON(t, s, `{"amount": null, "user": {"id": "u2", "tier": "gold"}}`, 100)
+
+    doc := schema.JSONSchema(s)
+    if !reflect.DeepEqual(doc["required"], []string{"amount", "user"}) {
+        t.Errorf("Unexpected required: %v", doc["required"])
+    }
+    props := doc["properties"].(map[string]interface{})
+    if !reflect.DeepEqual(props["amount"].(map[string]interface{})["type"], []string{"number", "null"}) {
+        t.Errorf("Expected nullable number amount, got %v", props["amount"])
+    }
+    user := props["user"].(map[string]interface{})
+    if !reflect.DeepEqual(user["required"], []string{"id"}) {
+        t.Errorf("Expected only user.id required, got %v", user["required"])
+    }
+    items := props["tags"].(map[string]interface{})["items"].(map[string]interface{})
+    if items["type"] != "string" {
+        t.Errorf("Expected string array items, got %v", items)
+    }
+}
+
+func TestSchemaEndpoints(t *testing.T) {
+    s := setupMockServer()
+    repo := NewMockSchemaRepo()
+    s.SchemaRepo = repo
+    s.Schemas = schema.NewTracker(repo, 100)
+    s.Schemas.FlushInterval = time.Hour
+    s.Observers = []server.InferenceObserver{s.Schemas}
+
+    for _, input := range []string{`{"age": 30}`, `{"age": "30"}`} {
+        body := []byte(`{"model_name":"fraud","model_version":"v1","input_data":` + input + `,"output_data":{"score":0.5}}`)
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBuffer(body))
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        if rr.Code != http.StatusCreated {
+            t.Fatalf("Expected 201, got %d", rr.Code)
+        }
+    }
+    // Schemas are saved on flush, which reading them triggers.
+    if repo.Saves != 0 {
+        t.Fatalf("Expected no schema saves on ingest, got %d", repo.Saves)
+    }
+
+    req, _ := http.NewRequest("GET", "/schemas/fraud/v1?format=jsonschema", nil)
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+    }
+    var docs map[string]map[string]interface{}
+    json.Unmarshal(rr.Body.Bytes(), &docs)
+    if docs["input"] == nil || docs["output"] == nil {
+        t.Fatalf("Expected input and output schemas, got %v", docs)
+    }
+
+    req, _ = http.NewRequest("GET", "/schemas/events?model=fraud", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var events []models.SchemaEvent
+    json.Unmarshal(rr.Body.Bytes(), &events)
+    if len(events) != 1 || events[0].Kind != schema.EventTypeChanged || events[0].Payload != "input" {
+        t.Errorf("Expected one input type change, got %+v", events)
+    }
+
+    req, _ = http.NewRequest("GET", "/schemas/fraud/v9", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusNotFound {
+        t.Errorf("Expected 404 for unknown version, got %d", rr.Code)
+    }
+}
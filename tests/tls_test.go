@@ -0,0 +1,165 @@
+package tests
+
+import (
+    "bytes"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "math/big"
+    "net"
+    "net/http"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/auth"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/certs"
+)
+
+type testCert struct {
+    cert *x509.Certificate
+    key  *ecdsa.PrivateKey
+    der  []byte
+}
+
+// issueCert creates a certificate signed by parent, or a self-signed CA
+// when parent is nil.
+func issueCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+    key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    tmpl := &x509.Certificate{
+        SerialNumber: big.NewInt(serial),
+        Subject:      pkix.Name{CommonName: cn},
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().Add(time.Hour),
+        IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+    }
+    signer, signerKey := tmpl, key
+    if parent == nil {
+        tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+        tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+    } else {
+        signer, signerKey = parent.cert, parent.key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+    if err != nil {
+        t.Fatalf("CreateCertificate failed: %v", err)
+    }
+    cert, _ := x509.ParseCertificate(der)
+    return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) writePEM(t *testing.T, certFile, keyFile string) {
+    os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+    if keyFile != "" {
+        keyDER, _ := x509.MarshalECPrivateKey(c.key)
+        os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+    }
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+    return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestTLS_MutualAuthAndReload(t *testing.T) {
+    dir := t.TempDir()
+    certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+    ca := issueCert(t, "test-ca", 1, nil)
+    ca.writePEM(t, caFile, "")
+    issueCert(t, "server-1", 2, ca).writePEM(t, certFile, keyFile)
+    client := issueCert(t, "ingest-svc", 3, ca)
+    stranger := issueCert(t, "ingest-svc", 4, issueCert(t, "other-ca", 5, nil))
+
+    reloader, err := certs.NewReloader(certFile, keyFile, caFile)
+    if err != nil {
+        t.Fatalf("NewReloader returned error: %v", err)
+    }
+    s := setupMockServer()
+    s.Auth = &auth.ClientCerts{Roles: map[string]auth.Role{"ingest-svc": auth.RoleWriter}}
+
+    ln, err := tls.Listen("tcp", "127.0.0.1:0", reloader.TLSConfig(tls.RequireAndVerifyClientCert))
+    if err != nil {
+        t.Fatalf("Listen failed: %v", err)
+    }
+    srv := &http.Server{Handler: s.Router}
+    go srv.Serve(ln)
+    defer srv.Close()
+
+    roots := x509.NewCertPool()
+    roots.AddCert(ca.cert)
+    post := func(c *testCert) (*http.Response, error) {
+        cfg := &tls.Config{RootCAs: roots}
+        if c != nil {
+            cfg.Certificates = []tls.Certificate{c.tlsCert()}
+        }
+        httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+        return httpClient.Post("https://"+ln.Addr().String()+"/inferences", "application/json",
+            bytes.NewBufferString(`{"model_name": "m", "model_version": "v1", "input_data": {}, "output_data": {}}`))
+    }
+
+    resp, err := post(client)
+    if err != nil {
+        t.Fatalf("Request with a client certificate failed: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusCreated || resp.TLS.PeerCertificates[0].Subject.CommonName != "server-1" {
+        t.Errorf("Expected 201 from server-1, got %d from %s", resp.StatusCode, resp.TLS.PeerCertificates[0].Subject.CommonName)
+    }
+    if _, err := post(nil); err == nil {
+        t.Errorf("Expected the handshake to fail without a client certificate")
+    }
+    if _, err := post(stranger); err == nil {
+        t.Errorf("Expected the handshake to fail for a certificate from another CA")
+    }
+
+    // Replace the server certificate; new connections get it after reload.
+    issueCert(t, "server-2", 6, ca).writePEM(t, certFile, keyFile)
+    later := time.Now().Add(time.Minute)
+    os.Chtimes(certFile, later, later)
+    if !reloader.Changed() {
+        t.Fatalf("Expected the certificate change to be detected")
+    }
+    if err := reloader.Reload(); err != nil {
+        t.Fatalf("Reload returned error: %v", err)
+    }
+    resp, err = post(client)
+    if err != nil {
+        t.Fatalf("Request after reload failed: %v", err)
+    }
+    resp.Body.Close()
+    if cn := resp.TLS.PeerCertificates[0].Subject.CommonName; cn != "server-2" {
+        t.Errorf("Expected the reloaded certificate, got %s", cn)
+    }
+
+    // A broken file keeps the last good certificate.
+    os.WriteFile(keyFile, []byte("not a key"), 0o600)
+    if err := reloader.Reload(); err == nil {
+        t.Errorf("Expected an error reloading a broken key")
+    }
+    if resp, err = post(client); err != nil {
+        t.Errorf("Expected the previous certificate to stay in use, got %v", err)
+    } else {
+        resp.Body.Close()
+    }
+}
+
+func TestClientCerts_MapsCommonName(t *testing.T) {
+    ca := issueCert(t, "test-ca", 1, nil)
+    c := &auth.ClientCerts{Roles: map[string]auth.Role{"dashboards": auth.RoleViewer, "*": auth.RoleWriter}}
+    req, _ := http.NewRequest("GET", "/", nil)
+    if id, _ := c.Authenticate(req); id != nil {
+        t.Errorf("Expected no identity without TLS, got %+v", id)
+    }
+    for cn, want := range map[string]auth.Role{"dashboards": auth.RoleViewer, "batch-job": auth.RoleWriter} {
+        req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{issueCert(t, cn, 2, ca).cert}}}
+        id, err := c.Authenticate(req)
+        if err != nil || id.Role != want || id.Name != "cert:"+cn {
+            t.Errorf("Authenticate(%s) = %+v, %v", cn, id, err)
+        }
+    }
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/models"
+    "github.com/Olt-Kondirolli91/ml-monitoring/internal/tracing"
+)
+
+func span(id, parent string, start time.Time, latency float64) models.Inference {
+    return models.Inference{
+        ID:           "inf-" + id,
+        ModelName:    "step-" + id,
+        InputData:    `{}`,
+        OutputData:   `{}`,
+        TraceID:      "t1",
+        SpanID:       id,
+        ParentSpanID: parent,
+        StartedAt:    &start,
+        LatencyMs:    &latency,
+    }
+}
+
+func TestTracing_Build(t *testing.T) {
+    t0 := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+    infs := []models.Inference{
+        span("llm2", "root", t0.Add(300*time.Millisecond), 200),
+        span("root", "", t0, 600),
+        span("retrieval", "root", t0.Add(10*time.Millisecond), 90),
+        span("llm1", "root", t0.Add(100*time.Millisecond), 150),
+        span("tool", "llm1", t0.Add(120*time.Millisecond), 20),
+        span("lost", "missing", t0.Add(700*time.Millisecond), 5),
+        span("a", "b", t0.Add(800*time.Millisecond), 1),
+        span("b", "a", t0.Add(801*time.Millisecond), 1),
+    }
+
+    tr := tracing.Build("t1", infs, nil)
+    if tr.SpanCount != 8 || tr.DurationMs != 802 {
+        t.Errorf("Unexpected trace totals: count=%d duration=%v", tr.SpanCount, tr.DurationMs)
+    }
+    if len(tr.Roots) != 3 || tr.Roots[0].SpanID != "root" {
+        t.Fatalf("Expected root, orphan and cycle roots, got %d roots", len(tr.Roots))
+    }
+    root := tr.Roots[0]
+    var order []string
+    for _, c := range root.Children {
+        order = append(order, c.SpanID)
+    }
+    if len(order) != 3 || order[0] != "retrieval" || order[1] != "llm1" || order[2] != "llm2" {
+        t.Errorf("Expected children ordered by start, got %v", order)
+    }
+    if len(root.Children[1].Children) != 1 || root.Children[1].Children[0].OffsetMs != 120 {
+        t.Errorf("Expected tool call under llm1 at offset 120ms, got %+v", root.Children[1].Children)
+    }
+    if !tr.Roots[1].Orphan || tr.Roots[1].SpanID != "lost" {
+        t.Errorf("Expected orphan span with missing parent, got %+v", tr.Roots[1])
+    }
+    if !tr.Roots[2].Orphan || len(tr.Roots[2].Children) != 1 {
+        t.Errorf("Expected the parent cycle to be broken into one orphan root, got %+v", tr.Roots[2])
+    }
+}
+
+func TestTracing_ParseTraceparent(t *testing.T) {
+    traceID, parent, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+    if err != nil || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || parent != "00f067aa0ba902b7" {
+        t.Errorf("Unexpected parse result: %s %s %v", traceID, parent, err)
+    }
+    for _, bad := range []string{"", "00-xyz-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+        if _, _, err := tracing.ParseTraceparent(bad); err == nil {
+            t.Errorf("Expected error for %q", bad)
+        }
+    }
+}
+
+func TestTraceEndpoint(t *testing.T) {
+    s := setupMockServer()
+
+    post := func(body, traceparent string) (*httptest.ResponseRecorder, map[string]string) {
+        req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(body))
+        if traceparent != "" {
+            req.Header.Set("traceparent", traceparent)
+        }
+        rr := httptest.NewRecorder()
+        s.Router.ServeHTTP(rr, req)
+        var resp map[string]string
+        json.Unmarshal(rr.Body.Bytes(), &resp)
+        return rr, resp
+    }
+
+    rr, root := post(`{"model_name":"rag","model_version":"v1","input_data":{},"output_data":{},
+        "trace_id":"trace-1","started_at":"2025-04-10T12:00:00Z","latency_ms":500}`, "")
+    if rr.Code != http.StatusCreated || root["span_id"] == "" {
+        t.Fatalf("Expected 201 with generated span_id, got %d %v", rr.Code, root)
+    }
+    rr, _ = post(`{"model_name":"retriever","model_version":"v1","input_data":{},"output_data":{},
+        "trace_id":"trace-1","parent_span_id":"`+root["span_id"]+`","started_at":"2025-04-10T12:00:00.050Z","latency_ms":40}`, "")
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+
+    // traceparent header supplies trace and parent when the body does not
+    rr, _ = post(`{"model_name":"llm","model_version":"v1","input_data":{},"output_data":{}}`,
+        "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d", rr.Code)
+    }
+    if rr, _ := post(`{"model_name":"x","input_data":{},"output_data":{},"span_id":"s"}`, ""); rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for span_id without trace_id, got %d", rr.Code)
+    }
+    if rr, _ := post(`{"model_name":"x","input_data":{},"output_data":{}}`, "garbage"); rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for bad traceparent, got %d", rr.Code)
+    }
+
+    req, _ := http.NewRequest("GET", "/traces/trace-1", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("Expected 200, got %d", rr.Code)
+    }
+    var tr tracing.Trace
+    json.Unmarshal(rr.Body.Bytes(), &tr)
+    if tr.SpanCount != 2 || len(tr.Roots) != 1 || len(tr.Roots[0].Children) != 1 ||
+        tr.Roots[0].Children[0].ModelName != "retriever" || tr.Roots[0].Children[0].OffsetMs != 50 {
+        t.Errorf("Unexpected trace: %+v", tr)
+    }
+
+    req, _ = http.NewRequest("GET", "/traces/4bf92f3577b34da6a3ce929d0e0e4736", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    json.Unmarshal(rr.Body.Bytes(), &tr)
+    if len(tr.Roots) != 1 || !tr.Roots[0].Orphan || tr.Roots[0].ParentSpanID != "00f067aa0ba902b7" {
+        t.Errorf("Expected one orphan span under the upstream parent, got %+v", tr.Roots)
+    }
+
+    req, _ = http.NewRequest("GET", "/traces/unknown", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusNotFound {
+        t.Errorf("Expected 404, got %d", rr.Code)
+    }
+}
+
+func TestTraceEndpoint_IncludesLLMCalls(t *testing.T) {
+    s := setupMockServer()
+    s.LLMRepo = &MockLLMRepo{}
+
+    req, _ := http.NewRequest("POST", "/inferences", bytes.NewBufferString(`{"model_name":"rag","model_version":"v1",
+        "input_data":{},"output_data":{},"trace_id":"trace-1","started_at":"2025-04-10T12:00:00Z","latency_ms":500}`))
+    rr := httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var root map[string]string
+    json.Unmarshal(rr.Body.Bytes(), &root)
+    if rr.Code != http.StatusCreated || root["span_id"] == "" {
+        t.Fatalf("Expected 201 with span_id, got %d %s", rr.Code, rr.Body.String())
+    }
+
+    // The call inherits the trace and parent from the traceparent header.
+    req, _ = http.NewRequest("POST", "/llm/calls", bytes.NewBufferString(`{"model":"gpt-4o","prompt":"What is the capital?",
+        "completion":"Paris","started_at":"2025-04-10T12:00:00.100Z","latency_ms":300}`))
+    req.Header.Set("traceparent", "00-"+strings.Repeat("ab", 16)+"-"+root["span_id"]+"-01")
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"span_id"`) {
+        t.Fatalf("Expected 201 with span_id, got %d %s", rr.Code, rr.Body.String())
+    }
+    req, _ = http.NewRequest("POST", "/llm/calls", bytes.NewBufferString(`{"model":"gpt-4o","trace_id":"trace-1",
+        "parent_span_id":"`+root["span_id"]+`","prompt":"Summarise","completion":"Done",
+        "started_at":"2025-04-10T12:00:00.200Z","latency_ms":100}`))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("Expected 201, got %d %s", rr.Code, rr.Body.String())
+    }
+    req, _ = http.NewRequest("POST", "/llm/calls", bytes.NewBufferString(`{"model":"gpt-4o","span_id":"s"}`))
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Errorf("Expected 400 for span_id without trace_id, got %d", rr.Code)
+    }
+
+    req, _ = http.NewRequest("GET", "/traces/trace-1", nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    var tr tracing.Trace
+    json.Unmarshal(rr.Body.Bytes(), &tr)
+    if tr.SpanCount != 2 || len(tr.Roots) != 1 || len(tr.Roots[0].Children) != 1 {
+        t.Fatalf("Unexpected trace: %s", rr.Body.String())
+    }
+    call := tr.Roots[0].Children[0]
+    if tr.Roots[0].Kind != tracing.KindInference || call.Kind != tracing.KindLLMCall || call.CallID == "" ||
+        call.ModelName != "gpt-4o" || call.OffsetMs != 200 || string(call.OutputData) != `"Done"` {
+        t.Errorf("Unexpected LLM call span: %+v", call)
+    }
+
+    // A trace holding only LLM calls is found too.
+    req, _ = http.NewRequest("GET", "/traces/"+strings.Repeat("ab", 16), nil)
+    rr = httptest.NewRecorder()
+    s.Router.ServeHTTP(rr, req)
+    json.Unmarshal(rr.Body.Bytes(), &tr)
+    if rr.Code != http.StatusOK || len(tr.Roots) != 1 || !tr.Roots[0].Orphan || tr.Roots[0].Kind != tracing.KindLLMCall {
+        t.Errorf("Expected one orphan LLM call span, got %d %s", rr.Code, rr.Body.String())
+    }
+}